@@ -0,0 +1,238 @@
+package nioclient
+
+// AccessReport answers "what can user X do anywhere" for access
+// certification: per namespace the objects the user has direct roles on
+// (reverse Read) and the permissions it holds through rewrites and
+// inheritance (List of every relation from ListNamespaces). The first read
+// picks the snapshot every later RPC is pinned to; stored grants already
+// lapsed at that snapshot are left out, as check ignores them.
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultReportConcurrency is the number of concurrent RPCs of
+	// AccessReport when AccessReportOptions.Concurrency is not set.
+	DefaultReportConcurrency = 8
+	// DefaultExpiringWithin is the window in which AccessReport flags a grant
+	// as expiring when AccessReportOptions.ExpiringWithin is not set.
+	DefaultExpiringWithin = 30 * 24 * time.Hour
+)
+
+// AccessReportOptions configures AccessReport.
+type AccessReportOptions struct {
+	// Namespaces restricts the report; empty means every namespace check has
+	// loaded.
+	Namespaces []Ns
+	// Ts is the zookie the report must be at least as fresh as; empty means
+	// TimestampEmpty.
+	Ts Timestamp
+	// Concurrency bounds the RPCs in flight (<= 0: DefaultReportConcurrency).
+	Concurrency int
+	// ExpiringWithin flags direct grants expiring within this window
+	// (<= 0: DefaultExpiringWithin).
+	ExpiringWithin time.Duration
+}
+
+// RoleGrant is a relation granted to the user by a stored tuple.
+type RoleGrant struct {
+	Rel     Rel        `json:"rel"`
+	Expires *time.Time `json:"expires,omitempty"`
+	// ExpiringSoon is set when Expires falls within the report's
+	// ExpiringWithin window.
+	ExpiringSoon bool `json:"expiringSoon,omitempty"`
+}
+
+// ObjectAccess is the access of the user on one object: the relations granted
+// directly and the relations held only through rewrites or inheritance.
+type ObjectAccess struct {
+	Obj       Obj         `json:"obj"`
+	Roles     []RoleGrant `json:"roles,omitempty"`
+	Inherited []Rel       `json:"inherited,omitempty"`
+}
+
+// NamespaceAccess lists the objects of one namespace the user has access to,
+// sorted by Obj.
+type NamespaceAccess struct {
+	Ns      Ns             `json:"ns"`
+	Objects []ObjectAccess `json:"objects"`
+}
+
+// AccessReportResult is the outcome of AccessReport. Namespaces without
+// access are omitted.
+type AccessReportResult struct {
+	UserId      UserId            `json:"userId"`
+	Ts          Timestamp         `json:"ts"`
+	GeneratedAt time.Time         `json:"generatedAt"`
+	Namespaces  []NamespaceAccess `json:"namespaces"`
+}
+
+// AccessReport collects everything userId can do, at one snapshot.
+func (c *checkAPI) AccessReport(ctx context.Context, userId UserId, opts AccessReportOptions) (AccessReportResult, error) {
+	if opts.Ts == "" {
+		opts.Ts = TimestampEmpty
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultReportConcurrency
+	}
+	if opts.ExpiringWithin <= 0 {
+		opts.ExpiringWithin = DefaultExpiringWithin
+	}
+	metas, err := c.ListNamespaces(ctx)
+	if err != nil {
+		return AccessReportResult{}, err
+	}
+	if len(opts.Namespaces) > 0 {
+		metas = slices.DeleteFunc(metas, func(m NamespaceMeta) bool {
+			return !slices.Contains(opts.Namespaces, Ns(m.Name))
+		})
+	}
+	now := time.Now().UTC()
+	report := AccessReportResult{UserId: userId, Ts: opts.Ts, GeneratedAt: now}
+	if len(metas) == 0 {
+		return report, nil
+	}
+
+	// Direct tuples of the first namespace fix the snapshot.
+	type nsAccess struct {
+		mu     sync.Mutex
+		direct []Tuple
+		listed map[Obj][]Rel
+	}
+	access := make([]*nsAccess, len(metas))
+	for i := range access {
+		access[i] = &nsAccess{listed: make(map[Obj][]Rel)}
+	}
+	first, err := c.ReadWithTimestamp(ctx, opts.Ts, FilterByUser(Ns(metas[0].Name), userId, nil))
+	if err != nil {
+		return AccessReportResult{}, err
+	}
+	report.Ts = first.Ts
+	access[0].direct = first.Tuples
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(opts.Concurrency)
+	for i, m := range metas {
+		ns, a := Ns(m.Name), access[i]
+		if i > 0 {
+			g.Go(func() error {
+				res, err := c.ReadWithTimestamp(gctx, report.Ts, FilterByUser(ns, userId, nil))
+				a.direct = res.Tuples
+				return err
+			})
+		}
+		for _, r := range m.Relations {
+			rel := Rel(r.Name)
+			g.Go(func() error {
+				res, err := c.ListWithTimestamp(gctx, ns, rel, userId, report.Ts)
+				if err != nil {
+					return err
+				}
+				a.mu.Lock()
+				defer a.mu.Unlock()
+				for _, obj := range res.Objs {
+					a.listed[Obj(obj)] = append(a.listed[Obj(obj)], rel)
+				}
+				return nil
+			})
+		}
+	}
+	if err := g.Wait(); err != nil {
+		return AccessReportResult{}, err
+	}
+
+	soon := now.Add(opts.ExpiringWithin)
+	at := snapshotTime(report.Ts)
+	for i, m := range metas {
+		objects := make(map[Obj]*ObjectAccess)
+		object := func(obj Obj) *ObjectAccess {
+			if objects[obj] == nil {
+				objects[obj] = &ObjectAccess{Obj: obj}
+			}
+			return objects[obj]
+		}
+		for _, t := range access[i].direct {
+			if lapsedAt(t, at) {
+				continue
+			}
+			grant := RoleGrant{Rel: t.Rel, Expires: t.Expires}
+			if t.Expires != nil {
+				grant.ExpiringSoon = t.Expires.After(now) && !t.Expires.After(soon)
+			}
+			o := object(t.Obj)
+			o.Roles = append(o.Roles, grant)
+		}
+		for obj, rels := range access[i].listed {
+			o := object(obj)
+			for _, rel := range rels {
+				if !slices.ContainsFunc(o.Roles, func(g RoleGrant) bool { return g.Rel == rel }) {
+					o.Inherited = append(o.Inherited, rel)
+				}
+			}
+		}
+		if len(objects) == 0 {
+			continue
+		}
+		na := NamespaceAccess{Ns: Ns(m.Name)}
+		for _, o := range objects {
+			slices.SortFunc(o.Roles, func(a, b RoleGrant) int { return cmp.Compare(a.Rel, b.Rel) })
+			slices.Sort(o.Inherited)
+			na.Objects = append(na.Objects, *o)
+		}
+		slices.SortFunc(na.Objects, func(a, b ObjectAccess) int { return cmp.Compare(a.Obj, b.Obj) })
+		report.Namespaces = append(report.Namespaces, na)
+	}
+	return report, nil
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r AccessReportResult) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// accessReportCSVHeader is the header row of WriteCSV.
+var accessReportCSVHeader = []string{"user_id", "ns", "obj", "rel", "grant", "expires", "expiring_soon"}
+
+// WriteCSV writes one row per relation: grant is "direct" or "inherited";
+// expires is RFC 3339 or empty.
+func (r AccessReportResult) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(accessReportCSVHeader); err != nil {
+		return err
+	}
+	for _, na := range r.Namespaces {
+		for _, o := range na.Objects {
+			for _, g := range o.Roles {
+				expires := ""
+				if g.Expires != nil {
+					expires = g.Expires.UTC().Format(time.RFC3339)
+				}
+				row := []string{string(r.UserId), string(na.Ns), string(o.Obj), string(g.Rel), "direct", expires, strconv.FormatBool(g.ExpiringSoon)}
+				if err := cw.Write(row); err != nil {
+					return err
+				}
+			}
+			for _, rel := range o.Inherited {
+				row := []string{string(r.UserId), string(na.Ns), string(o.Obj), string(rel), "inherited", "", "false"}
+				if err := cw.Write(row); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
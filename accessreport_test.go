@@ -0,0 +1,106 @@
+package nioclient
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+)
+
+func accessReportFixture(t *testing.T) (*checkAPI, time.Time) {
+	soon := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	listed := map[string][]string{
+		"project#viewer": {"p1", "p2"},
+		"project#editor": {"p1"},
+		"folder#viewer":  {"f1"},
+	}
+	fake := &fakeCheckService{
+		read: readTuples(t, "snap",
+			"project:p1#editor@alice",
+			fmt.Sprintf("folder:f1#viewer@alice[expires=%s]", soon.Format(time.RFC3339)),
+			"project:p1#editor@bob",
+			"project:p3#editor@alice[expires=2020-01-15T12:00:00Z]",
+		),
+		list: func(in *proto.ListRequest) (*proto.ListResponse, error) {
+			if in.Ts != "snap" {
+				t.Errorf("list %s#%s at %q, want pinned snap", in.Ns, in.Rel, in.Ts)
+			}
+			return &proto.ListResponse{Ts: "snap", Objs: listed[in.Ns+"#"+in.Rel]}, nil
+		},
+	}
+	ns := &fakeNamespaceService{namespaces: []*proto.NamespaceMeta{
+		{Name: "folder", Relations: []*proto.RelationMeta{{Name: "viewer", Kind: "this"}}},
+		{Name: "group", Relations: []*proto.RelationMeta{{Name: "member", Kind: "this"}}},
+		{Name: "project", Relations: []*proto.RelationMeta{{Name: "viewer", Kind: "union"}, {Name: "editor", Kind: "this"}}},
+	}}
+	return &checkAPI{grpcClient: fake, nsClient: ns}, soon
+}
+
+func TestAccessReport(t *testing.T) {
+	c, soon := accessReportFixture(t)
+	report, err := c.AccessReport(t.Context(), "alice", AccessReportOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Ts != "snap" || len(report.Namespaces) != 2 {
+		t.Fatalf("report = %+v, want folder and project only", report)
+	}
+	folder, project := report.Namespaces[0], report.Namespaces[1]
+	f1 := folder.Objects[0].Roles[0]
+	if f1.Rel != "viewer" || !f1.ExpiringSoon || !f1.Expires.Equal(soon) {
+		t.Errorf("folder f1 = %+v, want expiring viewer", f1)
+	}
+	if len(project.Objects) != 2 {
+		t.Fatalf("project objects = %+v, want p3's lapsed grant left out", project.Objects)
+	}
+	p1, p2 := project.Objects[0], project.Objects[1]
+	if len(p1.Roles) != 1 || p1.Roles[0].Rel != "editor" || !slices.Equal(p1.Inherited, []Rel{"viewer"}) {
+		t.Errorf("p1 = %+v, want direct editor, inherited viewer", p1)
+	}
+	if len(p2.Roles) != 0 || !slices.Equal(p2.Inherited, []Rel{"viewer"}) {
+		t.Errorf("p2 = %+v, want inherited viewer only", p2)
+	}
+}
+
+func TestAccessReportExport(t *testing.T) {
+	c, _ := accessReportFixture(t)
+	report, err := c.AccessReport(t.Context(), "alice", AccessReportOptions{Namespaces: []Ns{"project"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var csvOut bytes.Buffer
+	if err := report.WriteCSV(&csvOut); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		accessReportCSVHeader,
+		{"alice", "project", "p1", "editor", "direct", "", "false"},
+		{"alice", "project", "p1", "viewer", "inherited", "", "false"},
+		{"alice", "project", "p2", "viewer", "inherited", "", "false"},
+	}
+	if !slices.EqualFunc(rows, want, slices.Equal) {
+		t.Fatalf("csv = %q", rows)
+	}
+
+	var jsonOut bytes.Buffer
+	if err := report.WriteJSON(&jsonOut); err != nil {
+		t.Fatal(err)
+	}
+	var back AccessReportResult
+	if err := json.Unmarshal(jsonOut.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.UserId != "alice" || back.Namespaces[0].Objects[0].Roles[0].Rel != "editor" {
+		t.Fatalf("json = %s", jsonOut.String())
+	}
+}
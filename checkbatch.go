@@ -0,0 +1,212 @@
+package nioclient
+
+// Batched checks for list pages and other callers that need many decisions at
+// once. The batch is sent as one check_batch RPC when the server implements it;
+// otherwise the items are fanned out as parallel CheckService.check calls with
+// bounded concurrency. Both paths report every item through observeCheck.
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultCheckBatchConcurrency bounds the parallel check calls of the
+// CheckBatch fallback path when no other limit is configured.
+const DefaultCheckBatchConcurrency = 16
+
+// CheckItem is one ⟨ns, obj, rel, userId⟩ question of a CheckBatch.
+type CheckItem struct {
+	Ns     Ns
+	Obj    Obj
+	Rel    Rel
+	UserId UserId
+}
+
+// CheckItemResult is the outcome of one CheckItem, with the same meaning as
+// the return values of Check. Err is set only for this item; the other items
+// of the batch are still answered.
+type CheckItemResult struct {
+	Principal Principal
+	Ok        bool
+	Err       error
+}
+
+// CheckBatchResult is the outcome of CheckBatch: per-item results in request
+// order and the zookie of the snapshot the server evaluated them at. Ts is
+// only set by the check_batch RPC: when the server has no batch RPC the items
+// are checked one by one, each at its own snapshot at least as fresh as the
+// requested zookie, and Ts is empty. It is empty too when no item was sent.
+type CheckBatchResult struct {
+	Ts      Timestamp
+	Results []CheckItemResult
+}
+
+// WithCheckBatchConcurrency bounds the parallel check calls CheckBatch issues
+// when the server has no batch RPC. n <= 0 restores DefaultCheckBatchConcurrency.
+func (c *Client) WithCheckBatchConcurrency(n int) *Client {
+	c.batchConcurrency = n
+	return c
+}
+
+// WithCheckBatchConcurrency bounds the parallel check calls CheckBatch issues
+// when the server has no batch RPC. n <= 0 restores DefaultCheckBatchConcurrency.
+func (c *SessionClient) WithCheckBatchConcurrency(n int) *SessionClient {
+	c.batchConcurrency = n
+	return c
+}
+
+// CheckBatch answers every item at any current snapshot. See
+// CheckBatchWithTimestamp.
+func (c *checkAPI) CheckBatch(ctx context.Context, items []CheckItem) (CheckBatchResult, error) {
+	return c.CheckBatchWithTimestamp(ctx, items, TimestampEmpty)
+}
+
+// CheckBatchWithTimestamp answers every item at a snapshot at least as fresh
+// as ts. Per-item failures are reported in CheckItemResult.Err; the returned
+// error is non-nil only when the batch as a whole could not be attempted.
+func (c *checkAPI) CheckBatchWithTimestamp(ctx context.Context, items []CheckItem, ts Timestamp) (CheckBatchResult, error) {
+	if len(items) == 0 {
+		return CheckBatchResult{}, nil
+	}
+	if res, ok, err := c.checkBatchNative(ctx, items, ts); ok {
+		return res, err
+	}
+	return c.checkBatchParallel(ctx, items, c.freshen(ctx, ts, checkItemKeys(items)...)), nil
+}
+
+// checkBatchNative sends items as one check_batch RPC at a snapshot at least
+// as fresh as ts. ok is false, and nothing was checked, when the server has no
+// batch RPC.
+func (c *checkAPI) checkBatchNative(ctx context.Context, items []CheckItem, ts Timestamp) (res CheckBatchResult, ok bool, err error) {
+	if c.batchUnsupported.Load() {
+		return CheckBatchResult{}, false, nil
+	}
+	res, err = c.checkBatchRPC(ctx, items, c.freshen(ctx, ts, checkItemKeys(items)...))
+	if status.Code(err) == codes.Unimplemented {
+		// Older check servers: remember and fan out from now on.
+		c.batchUnsupported.Store(true)
+		return CheckBatchResult{}, false, nil
+	}
+	return res, true, err
+}
+
+func (c *checkAPI) checkBatchRPC(ctx context.Context, items []CheckItem, ts Timestamp) (CheckBatchResult, error) {
+	req := &proto.CheckBatchRequest{
+		Items: make([]*proto.CheckBatchItem, 0, len(items)),
+		Ts:    string(ts),
+	}
+	// Impossible is denied without asking the server and items the schema
+	// rejects fail locally, as in CheckWithTimestamp.
+	results := make([]CheckItemResult, len(items))
+	sent := make([]int, 0, len(items))
+	for i, it := range items {
+		if it.Rel == Impossible {
+			continue
+		}
+		if err := c.validateCheck(ctx, it.Ns, it.Rel); err != nil {
+			results[i].Err = fmt.Errorf("check %s: %w", checkString(it.Ns, it.Obj, it.Rel, it.UserId), err)
+			continue
+		}
+		sent = append(sent, i)
+		req.Items = append(req.Items, &proto.CheckBatchItem{
+			Ns:     string(it.Ns),
+			Obj:    string(it.Obj),
+			Rel:    string(it.Rel),
+			UserId: string(it.UserId),
+		})
+	}
+	if len(sent) == 0 {
+		return CheckBatchResult{Results: results}, nil
+	}
+
+	type timed struct {
+		res     *proto.CheckBatchResponse
+		elapsed time.Duration
+	}
+	t, err := retryCall(ctx, c.retry, "check_batch", true, func(ctx context.Context) (timed, error) {
+		begin := time.Now().UnixMilli()
+		res, err := c.grpcClient.CheckBatch(ctx, req)
+		elapsed := time.Duration(time.Now().UnixMilli()-begin) * time.Millisecond
+		if err != nil && status.Code(err) != codes.Unimplemented && !hedgeLost(ctx, err) {
+			c.observeBatch(items, sent, nil, elapsed)
+		}
+		return timed{res, elapsed}, err
+	})
+	if err != nil {
+		return CheckBatchResult{}, fmt.Errorf("check batch: %w", rpcError(err))
+	}
+	res, elapsed := t.res, t.elapsed
+	if len(res.GetResults()) != len(sent) {
+		c.observeBatch(items, sent, nil, elapsed)
+		return CheckBatchResult{}, fmt.Errorf("check batch: %d results for %d items", len(res.GetResults()), len(sent))
+	}
+
+	out := CheckBatchResult{
+		Ts:      Timestamp(res.GetTs()),
+		Results: results,
+	}
+	for j, r := range res.GetResults() {
+		i := sent[j]
+		out.Results[i] = checkBatchResultFromProto(items[i], r)
+	}
+	c.observeBatch(items, sent, out.Results, elapsed)
+	return out, nil
+}
+
+// observeBatch reports every sent item of a batch RPC through observeCheck.
+// results nil means the whole call failed.
+func (c *checkAPI) observeBatch(items []CheckItem, sent []int, results []CheckItemResult, elapsed time.Duration) {
+	if c.observeCheck == nil {
+		return
+	}
+	for _, i := range sent {
+		it := items[i]
+		ok, isError := false, true
+		if results != nil {
+			ok, isError = results[i].Ok, results[i].Err != nil
+		}
+		c.observeCheck(it.Ns, it.Obj, it.Rel, it.UserId, elapsed, ok, isError)
+	}
+}
+
+func checkBatchResultFromProto(it CheckItem, r *proto.CheckBatchResult) CheckItemResult {
+	if code := codes.Code(r.GetCode()); code != codes.OK {
+		return CheckItemResult{Err: fmt.Errorf("check %s: %w", checkString(it.Ns, it.Obj, it.Rel, it.UserId), rpcError(status.Error(code, r.GetMessage())))}
+	}
+	switch {
+	case r.Principal != nil:
+		return CheckItemResult{Principal: Principal(r.Principal.Id), Ok: r.GetOk()}
+	case r.GetOk():
+		return CheckItemResult{Err: ErrEmptyPrincipal}
+	default:
+		return CheckItemResult{}
+	}
+}
+
+// checkBatchParallel fans items out as individual checks. Each call goes
+// through CheckWithTimestamp and therefore through observeCheck.
+func (c *checkAPI) checkBatchParallel(ctx context.Context, items []CheckItem, ts Timestamp) CheckBatchResult {
+	limit := c.batchConcurrency
+	if limit <= 0 {
+		limit = DefaultCheckBatchConcurrency
+	}
+	// No Ts: every check below picks its own snapshot.
+	out := CheckBatchResult{Results: make([]CheckItemResult, len(items))}
+	var g errgroup.Group
+	g.SetLimit(limit)
+	for i, it := range items {
+		g.Go(func() error {
+			principal, ok, err := c.CheckWithTimestamp(ctx, it.Ns, it.Obj, it.Rel, it.UserId, ts)
+			out.Results[i] = CheckItemResult{Principal: principal, Ok: ok, Err: err}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return out
+}
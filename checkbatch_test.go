@@ -0,0 +1,309 @@
+package nioclient
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeCheckService is a proto.CheckServiceClient for RPC-level tests. Methods
+// without a hook panic through the nil embedded interface.
+type fakeCheckService struct {
+	proto.CheckServiceClient
+
+	check      func(*proto.CheckRequest) (*proto.CheckResponse, error)
+	checkBatch func(*proto.CheckBatchRequest) (*proto.CheckBatchResponse, error)
+	write      func(*proto.WriteRequest) (*proto.WriteResponse, error)
+	read       func(*proto.ReadRequest) (*proto.ReadResponse, error)
+	list       func(*proto.ListRequest) (*proto.ListResponse, error)
+	// listStream returns the responses to stream, then err (io.EOF when
+	// nil); a nil hook answers Unimplemented.
+	listStream func(*proto.ListRequest) ([]*proto.ListResponse, error)
+	expand     func(*proto.ExpandRequest) (*proto.ExpandResponse, error)
+	// explain nil answers Unimplemented.
+	explain func(*proto.ExplainRequest) (*proto.ExplainResponse, error)
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeCheckService) count(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[op]
+}
+
+func (f *fakeCheckService) record(op string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[op]++
+}
+
+func (f *fakeCheckService) Check(_ context.Context, in *proto.CheckRequest, _ ...grpc.CallOption) (*proto.CheckResponse, error) {
+	f.record("check")
+	return f.check(in)
+}
+
+func (f *fakeCheckService) CheckBatch(_ context.Context, in *proto.CheckBatchRequest, _ ...grpc.CallOption) (*proto.CheckBatchResponse, error) {
+	f.record("check_batch")
+	if f.checkBatch == nil {
+		return nil, status.Error(codes.Unimplemented, "unknown method check_batch")
+	}
+	return f.checkBatch(in)
+}
+
+func (f *fakeCheckService) Write(_ context.Context, in *proto.WriteRequest, _ ...grpc.CallOption) (*proto.WriteResponse, error) {
+	f.record("write")
+	return f.write(in)
+}
+
+func (f *fakeCheckService) Read(_ context.Context, in *proto.ReadRequest, _ ...grpc.CallOption) (*proto.ReadResponse, error) {
+	f.record("read")
+	return f.read(in)
+}
+
+func (f *fakeCheckService) List(_ context.Context, in *proto.ListRequest, _ ...grpc.CallOption) (*proto.ListResponse, error) {
+	f.record("list")
+	return f.list(in)
+}
+
+func (f *fakeCheckService) ListStream(ctx context.Context, in *proto.ListRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[proto.ListResponse], error) {
+	f.record("list_stream")
+	if f.listStream == nil {
+		return &fakeListStream{ctx: ctx, err: status.Error(codes.Unimplemented, "unknown method list_stream")}, nil
+	}
+	msgs, err := f.listStream(in)
+	if err == nil {
+		err = io.EOF
+	}
+	return &fakeListStream{ctx: ctx, msgs: msgs, err: err}, nil
+}
+
+func (f *fakeCheckService) Expand(_ context.Context, in *proto.ExpandRequest, _ ...grpc.CallOption) (*proto.ExpandResponse, error) {
+	f.record("expand")
+	return f.expand(in)
+}
+
+func (f *fakeCheckService) Explain(_ context.Context, in *proto.ExplainRequest, _ ...grpc.CallOption) (*proto.ExplainResponse, error) {
+	f.record("explain")
+	if f.explain == nil {
+		return nil, status.Error(codes.Unimplemented, "unknown method explain")
+	}
+	return f.explain(in)
+}
+
+// fakeNamespaceService serves a fixed schema.
+type fakeNamespaceService struct {
+	namespaces []*proto.NamespaceMeta
+}
+
+func (f *fakeNamespaceService) ListNamespaces(context.Context, *emptypb.Empty, ...grpc.CallOption) (*proto.ListNamespacesResponse, error) {
+	return &proto.ListNamespacesResponse{Namespaces: f.namespaces}, nil
+}
+
+// fakeListStream replays msgs, then fails with err. Like a real stream it
+// reports the status error on Recv, not when opened.
+type fakeListStream struct {
+	grpc.ClientStream
+	ctx  context.Context
+	msgs []*proto.ListResponse
+	err  error
+	recv int
+}
+
+func (s *fakeListStream) Recv() (*proto.ListResponse, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	if s.recv < len(s.msgs) {
+		s.recv++
+		return s.msgs[s.recv-1], nil
+	}
+	return nil, s.err
+}
+
+// readTuples serves reads from the canonical tuples given, at snapshot ts,
+// applying the TupleSet filters the way check's read does.
+func readTuples(t *testing.T, ts string, tuples ...string) func(*proto.ReadRequest) (*proto.ReadResponse, error) {
+	stored := make([]Tuple, 0, len(tuples))
+	for _, s := range tuples {
+		tuple, err := ParseTuple(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored = append(stored, tuple)
+	}
+	return func(in *proto.ReadRequest) (*proto.ReadResponse, error) {
+		res := &proto.ReadResponse{Ts: ts}
+		for i := range stored {
+			if !slices.ContainsFunc(in.TupleSets, func(set *proto.TupleSet) bool { return tupleSetMatches(set, stored[i]) }) {
+				continue
+			}
+			pt, err := tupleToProto(&stored[i])
+			if err != nil {
+				return nil, err
+			}
+			res.Tuples = append(res.Tuples, pt)
+		}
+		return res, nil
+	}
+}
+
+func tupleSetMatches(set *proto.TupleSet, t Tuple) bool {
+	if Ns(set.Ns) != t.Ns {
+		return false
+	}
+	subject := func(userId string, us *proto.UserSet) bool {
+		if us != nil {
+			return t.UserSet != nil && *t.UserSet == UserSet{Ns: Ns(us.Ns), Obj: Obj(us.Obj), Rel: Rel(us.Rel)}
+		}
+		return t.UserSet == nil && UserId(userId) == t.UserId
+	}
+	switch {
+	case set.GetObjectSpec() != nil:
+		spec := set.GetObjectSpec()
+		return Obj(spec.Obj) == t.Obj && (spec.Rel == nil || Rel(*spec.Rel) == t.Rel)
+	case set.GetUsersetSpec() != nil:
+		spec := set.GetUsersetSpec()
+		return subject(spec.GetUserId(), spec.GetUserSet()) && (spec.Rel == nil || Rel(*spec.Rel) == t.Rel)
+	case set.GetTupleSpec() != nil:
+		spec := set.GetTupleSpec()
+		return Obj(spec.Obj) == t.Obj && Rel(spec.Rel) == t.Rel && subject(spec.GetUserId(), spec.GetUserSet())
+	}
+	return false
+}
+
+// allowViewer grants rel "viewer" to every subject and denies everything else.
+func allowViewer(in *proto.CheckRequest) (*proto.CheckResponse, error) {
+	if in.Rel == "viewer" {
+		return &proto.CheckResponse{Ok: true, Principal: &proto.Principal{Id: in.UserId}}, nil
+	}
+	return &proto.CheckResponse{Ok: false}, nil
+}
+
+func TestCheckBatchUsesBatchRPC(t *testing.T) {
+	fake := &fakeCheckService{
+		checkBatch: func(in *proto.CheckBatchRequest) (*proto.CheckBatchResponse, error) {
+			if in.Ts != "snap" {
+				t.Errorf("batch ts = %q, want snap", in.Ts)
+			}
+			return &proto.CheckBatchResponse{Ts: "used", Results: []*proto.CheckBatchResult{
+				{Ok: true, Principal: &proto.Principal{Id: "u1"}},
+				{Code: int32(codes.NotFound), Message: "no such relation"},
+			}}, nil
+		},
+	}
+	var observed int32
+	c := &checkAPI{grpcClient: fake, observeCheck: func(Ns, Obj, Rel, UserId, time.Duration, bool, bool) {
+		atomic.AddInt32(&observed, 1)
+	}}
+
+	res, err := c.CheckBatchWithTimestamp(context.Background(), []CheckItem{
+		{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u1"},
+		{Ns: "doc", Obj: "1", Rel: "nope", UserId: "u1"},
+		{Ns: "doc", Obj: "1", Rel: Impossible, UserId: "u1"},
+	}, "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Ts != "used" {
+		t.Fatalf("ts = %q, want the server snapshot", res.Ts)
+	}
+	if r := res.Results[0]; !r.Ok || r.Principal != "u1" || r.Err != nil {
+		t.Fatalf("results[0] = %+v", r)
+	}
+	if r := res.Results[1]; r.Ok || status.Code(r.Err) != codes.NotFound {
+		t.Fatalf("results[1] = %+v, want per-item NotFound", r)
+	}
+	if r := res.Results[2]; r.Ok || r.Err != nil {
+		t.Fatalf("results[2] = %+v, want Impossible denied locally", r)
+	}
+	if fake.count("check") != 0 {
+		t.Fatalf("single checks = %d, want 0", fake.count("check"))
+	}
+	if got := atomic.LoadInt32(&observed); got != 2 {
+		t.Fatalf("observeCheck calls = %d, want 2 (one per sent item)", got)
+	}
+}
+
+func TestCheckBatchFallsBackToParallelChecks(t *testing.T) {
+	var inFlight, peak int32
+	fake := &fakeCheckService{
+		check: func(in *proto.CheckRequest) (*proto.CheckResponse, error) {
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			return allowViewer(in)
+		},
+	}
+	var observed int32
+	c := &checkAPI{grpcClient: fake, batchConcurrency: 2, observeCheck: func(Ns, Obj, Rel, UserId, time.Duration, bool, bool) {
+		atomic.AddInt32(&observed, 1)
+	}}
+
+	items := make([]CheckItem, 8)
+	for i := range items {
+		items[i] = CheckItem{Ns: "doc", Obj: "1", Rel: "editor", UserId: "u"}
+	}
+	items[3].Rel = "viewer"
+
+	for round := 0; round < 2; round++ {
+		res, err := c.CheckBatch(context.Background(), items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, r := range res.Results {
+			if r.Ok != (i == 3) {
+				t.Fatalf("round %d results[%d] = %+v", round, i, r)
+			}
+		}
+		if res.Ts != "" {
+			t.Fatalf("round %d ts = %q, want empty: the parallel checks share no snapshot", round, res.Ts)
+		}
+	}
+	if got := fake.count("check_batch"); got != 1 {
+		t.Fatalf("check_batch calls = %d, want 1 (UNIMPLEMENTED is remembered)", got)
+	}
+	if got := fake.count("check"); got != 16 {
+		t.Fatalf("single checks = %d, want 16", got)
+	}
+	if got := atomic.LoadInt32(&peak); got > 2 {
+		t.Fatalf("peak concurrency = %d, want <= 2", got)
+	}
+	if got := atomic.LoadInt32(&observed); got != 16 {
+		t.Fatalf("observeCheck calls = %d, want 16", got)
+	}
+}
+
+func TestCheckBatchNativeChecksNothingWithoutBatchRPC(t *testing.T) {
+	fake := &fakeCheckService{}
+	c := &checkAPI{grpcClient: fake}
+	items := []CheckItem{{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u"}}
+	for round := 0; round < 2; round++ {
+		if _, ok, err := c.checkBatchNative(context.Background(), items, TimestampEmpty); ok || err != nil {
+			t.Fatalf("round %d: ok = %v, err = %v; want not native", round, ok, err)
+		}
+	}
+	if fake.count("check_batch") != 1 || fake.count("check") != 0 {
+		t.Fatalf("check_batch = %d, check = %d; want one probe and no single checks", fake.count("check_batch"), fake.count("check"))
+	}
+}
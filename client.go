@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	proto "github.com/ecociel/nioclient-go/proto"
@@ -102,11 +103,6 @@ type UserSet struct {
 	Rel Rel
 }
 
-// String returns the string representation of the user set.
-func (s UserSet) String() string {
-	return fmt.Sprintf("UserSet(Ns: %s, Obj: %s, Rel: %s)", s.Ns, s.Obj, s.Rel)
-}
-
 // Principal is a user or a group of users.
 type Principal string
 
@@ -286,13 +282,33 @@ type checkAPI struct {
 	nsClient     proto.NamespaceServiceClient
 	observeCheck func(ns Ns, obj Obj, rel Rel, userId UserId, duration time.Duration, ok bool, isError bool)
 	observeList  func(ns Ns, rel Rel, userId UserId, duration time.Duration, isError bool)
+
+	batchConcurrency int         // CheckBatch fallback fan-out; <= 0 = default
+	batchUnsupported atomic.Bool // server answered check_batch with UNIMPLEMENTED
+
+	listStreamUnsupported atomic.Bool // server answered list_stream with UNIMPLEMENTED
+	explainUnsupported    atomic.Bool // server answered explain with UNIMPLEMENTED
+
+	retry   *RetryPolicy // nil = single attempt
+	zookies ZookieStore  // nil = no read-your-writes tracking
+	schema  *schemaCache // nil = no client-side schema validation
 }
 
-func newCheckAPI(checkConn *grpc.ClientConn) *checkAPI {
-	return &checkAPI{
+func newCheckAPI(checkConn *grpc.ClientConn, opts []ClientOption) *checkAPI {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := &checkAPI{
 		grpcClient: proto.NewCheckServiceClient(checkConn),
 		nsClient:   proto.NewNamespaceServiceClient(checkConn),
+		retry:      o.retry,
+		zookies:    o.zookies,
 	}
+	if o.schemaRefresh > 0 {
+		c.schema = newSchemaCache(o.schemaRefresh, c.LoadSchema)
+	}
+	return c
 }
 
 // Client is an RPC-only check client (Check/List/Write/…). It has no session
@@ -319,6 +335,7 @@ type SessionOption func(*sessionOptions)
 type sessionOptions struct {
 	prefix string
 	cfg    ResolverConfig
+	client []ClientOption
 }
 
 // WithPrefix sets the URL prefix used by Wrap for sign-in redirects
@@ -342,8 +359,8 @@ func WithResolverConfig(cfg ResolverConfig) SessionOption {
 // New creates an RPC-only client on the check gRPC connection (CheckService +
 // NamespaceService). Use for Check/List/Write/Read/Expand/Watch without cookie
 // session resolution. For HTTP Wrap, use NewWithSession.
-func New(checkConn *grpc.ClientConn) *Client {
-	return &Client{checkAPI: newCheckAPI(checkConn)}
+func New(checkConn *grpc.ClientConn, opts ...ClientOption) *Client {
+	return &Client{checkAPI: newCheckAPI(checkConn, opts)}
 }
 
 // NewWithSession creates a SessionClient for HTTP Wrap: check RPCs on checkConn
@@ -356,7 +373,7 @@ func NewWithSession(checkConn, sessionConn *grpc.ClientConn, opts ...SessionOpti
 		opt(&o)
 	}
 	return &SessionClient{
-		checkAPI:        newCheckAPI(checkConn),
+		checkAPI:        newCheckAPI(checkConn, o.client),
 		prefix:          o.prefix,
 		sessionResolver: newSessionResolver(sessionConn, o.cfg),
 	}
@@ -424,19 +441,24 @@ func (c *checkAPI) ListResult(ctx context.Context, ns Ns, rel Rel, userId UserId
 // ListWithTimestamp lists objects evaluated at a snapshot at least as fresh as ts.
 // The returned Ts is the snapshot the server actually used.
 func (c *checkAPI) ListWithTimestamp(ctx context.Context, ns Ns, rel Rel, userId UserId, ts Timestamp) (ListResult, error) {
-	begin := time.Now().UnixMilli()
-	list, err := c.grpcClient.List(ctx, &proto.ListRequest{
+	ts = c.freshen(ctx, ts, PrincipalKey(userId))
+	req := &proto.ListRequest{
 		Ns:     string(ns),
 		Rel:    string(rel),
 		UserId: string(userId),
 		Ts:     string(ts),
-	})
-	elapsed := time.Now().UnixMilli() - begin
-	if c.observeList != nil {
-		c.observeList(ns, rel, userId, time.Duration(elapsed)*time.Millisecond, err != nil)
 	}
+	list, err := retryCall(ctx, c.retry, "list", true, func(ctx context.Context) (*proto.ListResponse, error) {
+		begin := time.Now().UnixMilli()
+		list, err := c.grpcClient.List(ctx, req)
+		elapsed := time.Now().UnixMilli() - begin
+		if c.observeList != nil && !hedgeLost(ctx, err) {
+			c.observeList(ns, rel, userId, time.Duration(elapsed)*time.Millisecond, err != nil)
+		}
+		return list, err
+	})
 	if err != nil {
-		return ListResult{}, fmt.Errorf("list %s,%s,%s: %w", ns, rel, userId, err)
+		return ListResult{}, fmt.Errorf("list %s: %w", listString(ns, rel, userId), rpcError(err))
 	}
 	return ListResult{
 		Ts:   Timestamp(list.GetTs()),
@@ -456,25 +478,32 @@ func (c *checkAPI) CheckWithTimestamp(ctx context.Context, ns Ns, obj Obj, rel R
 	if rel == Impossible {
 		return "", false, nil
 	}
-	begin := time.Now().UnixMilli()
-
-	res, err := c.grpcClient.Check(ctx, &proto.CheckRequest{
+	if err := c.validateCheck(ctx, ns, rel); err != nil {
+		return "", false, fmt.Errorf("check %s: %w", checkString(ns, obj, rel, userId), err)
+	}
+	ts = c.freshen(ctx, ts, PrincipalKey(userId), ObjectKey(ns, obj))
+	req := &proto.CheckRequest{
 		Ns:     string(ns),
 		Obj:    string(obj),
 		Rel:    string(rel),
 		UserId: string(userId),
 		Ts:     string(ts),
-	})
-	elapsed := time.Now().UnixMilli() - begin
-	if c.observeCheck != nil {
-		isOk := false
-		if res != nil {
-			isOk = res.Ok
-		}
-		c.observeCheck(ns, obj, rel, userId, time.Duration(elapsed)*time.Millisecond, isOk, err != nil)
 	}
+	res, err := retryCall(ctx, c.retry, "check", true, func(ctx context.Context) (*proto.CheckResponse, error) {
+		begin := time.Now().UnixMilli()
+		res, err := c.grpcClient.Check(ctx, req)
+		elapsed := time.Now().UnixMilli() - begin
+		if c.observeCheck != nil && !hedgeLost(ctx, err) {
+			isOk := false
+			if res != nil {
+				isOk = res.Ok
+			}
+			c.observeCheck(ns, obj, rel, userId, time.Duration(elapsed)*time.Millisecond, isOk, err != nil)
+		}
+		return res, err
+	})
 	if err != nil {
-		return "", false, err
+		return "", false, fmt.Errorf("check %s: %w", checkString(ns, obj, rel, userId), rpcError(err))
 	}
 	if !res.Ok {
 		if res.Principal != nil {
@@ -573,8 +602,18 @@ func (c *checkAPI) DeleteOneUserSet(ctx context.Context, ns Ns, obj Obj, rel Rel
 
 // Write commits add and del tuples atomically. precondition is an optional OCC
 // zookie (WriteRequest.ts); pass nil for an unconditional write. Returns the
-// commit zookie for read-your-writes / chaining subsequent reads.
+// commit zookie for read-your-writes / chaining subsequent reads; with a
+// ZookieStore it is also recorded under every written object and principal.
+// With WithSchemaValidation, tuples naming an unknown namespace or relation
+// are rejected before the RPC.
+//
+// Only writes with a precondition are retried under a RetryPolicy. If a retried
+// write had in fact committed before its response was lost, the retry reports
+// ErrPreconditionFailed.
 func (c *checkAPI) Write(ctx context.Context, add, del []Tuple, precondition *Timestamp) (Timestamp, error) {
+	if err := c.validateWrite(ctx, add, del); err != nil {
+		return "", fmt.Errorf("write %w", err)
+	}
 	req := &proto.WriteRequest{
 		AddTuples: make([]*proto.Tuple, 0, len(add)),
 		DelTuples: make([]*proto.Tuple, 0, len(del)),
@@ -598,10 +637,17 @@ func (c *checkAPI) Write(ctx context.Context, add, del []Tuple, precondition *Ti
 		req.Ts = &ts
 	}
 
-	res, err := c.grpcClient.Write(ctx, req)
+	var policy *RetryPolicy
+	if precondition != nil {
+		policy = c.retry
+	}
+	res, err := retryCall(ctx, policy, "write", false, func(ctx context.Context) (*proto.WriteResponse, error) {
+		return c.grpcClient.Write(ctx, req)
+	})
 	if err != nil {
-		return "", fmt.Errorf("write: %w", err)
+		return "", fmt.Errorf("write: %w", rpcError(err))
 	}
+	c.recordWrite(ctx, Timestamp(res.GetTs()), add, del)
 	return Timestamp(res.GetTs()), nil
 }
 
@@ -617,7 +663,7 @@ func (c *checkAPI) ContentChangeCheck(ctx context.Context, ns Ns, obj Obj, rel R
 		UserId: string(userId),
 	})
 	if err != nil {
-		return ContentChangeCheckResult{}, fmt.Errorf("content_change_check %s,%s,%s: %w", ns, obj, rel, err)
+		return ContentChangeCheckResult{}, fmt.Errorf("content_change_check %s: %w", UserSet{Ns: ns, Obj: obj, Rel: rel}, rpcError(err))
 	}
 	return ContentChangeCheckResult{
 		Ok: res.GetOk(),
@@ -635,7 +681,7 @@ func (c *checkAPI) Watch(ctx context.Context, ns Ns, startTs Timestamp) (*WatchS
 		StartTs: string(startTs),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("watch %s: %w", ns, err)
+		return nil, fmt.Errorf("watch %s: %w", ns, rpcError(err))
 	}
 	return &WatchStream{stream: stream}, nil
 }
@@ -648,7 +694,7 @@ func (s *WatchStream) Recv() (WatchEvent, error) {
 	}
 	resp, err := s.stream.Recv()
 	if err != nil {
-		return WatchEvent{}, err
+		return WatchEvent{}, rpcError(err)
 	}
 	return watchEventFromProto(resp)
 }
@@ -660,14 +706,18 @@ func (c *checkAPI) Expand(ctx context.Context, ns Ns, obj Obj, rel Rel) (ExpandR
 
 // ExpandWithTimestamp expands at a snapshot at least as fresh as ts.
 func (c *checkAPI) ExpandWithTimestamp(ctx context.Context, ns Ns, obj Obj, rel Rel, ts Timestamp) (ExpandResult, error) {
-	res, err := c.grpcClient.Expand(ctx, &proto.ExpandRequest{
+	ts = c.freshen(ctx, ts, ObjectKey(ns, obj))
+	req := &proto.ExpandRequest{
 		Ns:  string(ns),
 		Obj: string(obj),
 		Rel: string(rel),
 		Ts:  string(ts),
+	}
+	res, err := retryCall(ctx, c.retry, "expand", true, func(ctx context.Context) (*proto.ExpandResponse, error) {
+		return c.grpcClient.Expand(ctx, req)
 	})
 	if err != nil {
-		return ExpandResult{}, fmt.Errorf("expand %s,%s,%s: %w", ns, obj, rel, err)
+		return ExpandResult{}, fmt.Errorf("expand %s: %w", UserSet{Ns: ns, Obj: obj, Rel: rel}, rpcError(err))
 	}
 	usersets := make([]UserSet, 0, len(res.GetUsersets()))
 	for _, us := range res.GetUsersets() {
@@ -688,9 +738,11 @@ func (c *checkAPI) ExpandWithTimestamp(ctx context.Context, ns Ns, obj Obj, rel
 // per namespace the declared relations and the rewrite kind of each.
 // Schema metadata only — no tuples.
 func (c *checkAPI) ListNamespaces(ctx context.Context) ([]NamespaceMeta, error) {
-	res, err := c.nsClient.ListNamespaces(ctx, &emptypb.Empty{})
+	res, err := retryCall(ctx, c.retry, "list_namespaces", true, func(ctx context.Context) (*proto.ListNamespacesResponse, error) {
+		return c.nsClient.ListNamespaces(ctx, &emptypb.Empty{})
+	})
 	if err != nil {
-		return nil, fmt.Errorf("list namespaces: %w", err)
+		return nil, fmt.Errorf("list namespaces: %w", rpcError(err))
 	}
 	out := make([]NamespaceMeta, 0, len(res.GetNamespaces()))
 	for _, ns := range res.GetNamespaces() {
@@ -740,11 +792,26 @@ func (c *checkAPI) Read(ctx context.Context, filters ...ReadFilter) (ReadResult,
 // ReadWithTimestamp returns stored tuples matching filters at a snapshot at
 // least as fresh as ts. The returned Ts is the snapshot the server used.
 func (c *checkAPI) ReadWithTimestamp(ctx context.Context, ts Timestamp, filters ...ReadFilter) (ReadResult, error) {
+	page, err := c.readPage(ctx, ts, 0, "", filters)
+	if err != nil {
+		return ReadResult{}, err
+	}
+	return ReadResult{Ts: page.Ts, Tuples: page.Tuples}, nil
+}
+
+// readPage issues one read RPC. pageSize 0 reads unpaginated; a non-empty
+// cursor continues a paginated read, pinned to the snapshot of its first page.
+func (c *checkAPI) readPage(ctx context.Context, ts Timestamp, pageSize int, cursor string, filters []ReadFilter) (ReadPageResult, error) {
 	if len(filters) == 0 {
-		return ReadResult{}, errors.New("read: at least one filter required")
+		return ReadPageResult{}, errors.New("read: at least one filter required")
+	}
+	if cursor == "" {
+		ts = c.freshen(ctx, ts, readFilterKeys(filters)...)
 	}
 	req := &proto.ReadRequest{
 		TupleSets: make([]*proto.TupleSet, 0, len(filters)),
+		PageSize:  int32(pageSize),
+		PageToken: cursor,
 	}
 	if ts != TimestampEmpty {
 		s := string(ts)
@@ -752,26 +819,29 @@ func (c *checkAPI) ReadWithTimestamp(ctx context.Context, ts Timestamp, filters
 	}
 	for i, f := range filters {
 		if f.set == nil {
-			return ReadResult{}, fmt.Errorf("read filter[%d]: empty filter", i)
+			return ReadPageResult{}, fmt.Errorf("read filter[%d]: empty filter", i)
 		}
 		req.TupleSets = append(req.TupleSets, f.set)
 	}
 
-	res, err := c.grpcClient.Read(ctx, req)
+	res, err := retryCall(ctx, c.retry, "read", true, func(ctx context.Context) (*proto.ReadResponse, error) {
+		return c.grpcClient.Read(ctx, req)
+	})
 	if err != nil {
-		return ReadResult{}, fmt.Errorf("read: %w", err)
+		return ReadPageResult{}, fmt.Errorf("read: %w", rpcError(err))
 	}
 	tuples := make([]Tuple, 0, len(res.GetTuples()))
 	for i, pt := range res.GetTuples() {
 		t, err := tupleFromProto(pt)
 		if err != nil {
-			return ReadResult{}, fmt.Errorf("read tuple[%d]: %w", i, err)
+			return ReadPageResult{}, fmt.Errorf("read tuple[%d]: %w", i, err)
 		}
 		tuples = append(tuples, t)
 	}
-	return ReadResult{
-		Ts:     Timestamp(res.GetTs()),
-		Tuples: tuples,
+	return ReadPageResult{
+		Ts:         Timestamp(res.GetTs()),
+		Tuples:     tuples,
+		NextCursor: res.GetNextPageToken(),
 	}, nil
 }
 
@@ -838,13 +908,13 @@ func tupleFromProto(pt *proto.Tuple) (Tuple, error) {
 	}
 	switch u := pt.GetUser().(type) {
 	case nil:
-		return Tuple{}, fmt.Errorf("tuple %s:%s#%s missing user field", t.Ns, t.Obj, t.Rel)
+		return Tuple{}, fmt.Errorf("tuple %s missing user field", formatTriple(t.Ns, t.Obj, t.Rel))
 	case *proto.Tuple_UserId:
 		t.UserId = UserId(u.UserId)
 	case *proto.Tuple_UserSet:
 		us := u.UserSet
 		if us == nil {
-			return Tuple{}, fmt.Errorf("tuple %s:%s#%s empty userset", t.Ns, t.Obj, t.Rel)
+			return Tuple{}, fmt.Errorf("tuple %s empty userset", formatTriple(t.Ns, t.Obj, t.Rel))
 		}
 		t.UserSet = &UserSet{
 			Ns:  Ns(us.GetNs()),
@@ -852,7 +922,7 @@ func tupleFromProto(pt *proto.Tuple) (Tuple, error) {
 			Rel: Rel(us.GetRel()),
 		}
 	default:
-		return Tuple{}, fmt.Errorf("tuple %s:%s#%s unknown user type", t.Ns, t.Obj, t.Rel)
+		return Tuple{}, fmt.Errorf("tuple %s unknown user type", formatTriple(t.Ns, t.Obj, t.Rel))
 	}
 	if exp, ok := pt.GetCondition().(*proto.Tuple_Expires); ok {
 		tm := time.Unix(exp.Expires, 0).UTC()
@@ -9,11 +9,33 @@ import (
 	nioclient "github.com/ecociel/nioclient-go"
 )
 
+// Usage:
+//
+//	check doc:readme#viewer@user:123
+//	check doc readme viewer user:123
 func main() {
-	ns := os.Args[1]
-	obj := os.Args[2]
-	rel := os.Args[3]
-	userId := os.Args[4]
+	var tuple nioclient.Tuple
+	switch len(os.Args) {
+	case 2:
+		var err error
+		if tuple, err = nioclient.ParseTuple(os.Args[1]); err != nil {
+			log.Fatalf("%v", err)
+		}
+	case 5:
+		tuple = nioclient.Tuple{
+			Ns:     nioclient.Ns(os.Args[1]),
+			Obj:    nioclient.Obj(os.Args[2]),
+			Rel:    nioclient.Rel(os.Args[3]),
+			UserId: nioclient.UserId(os.Args[4]),
+		}
+	default:
+		log.Fatalf("usage: %s ns:obj#rel@user | ns obj rel user", os.Args[0])
+	}
+	userId := tuple.UserId
+	if tuple.UserSet != nil {
+		// check accepts a userset subject in its ns:obj#rel text form.
+		userId = nioclient.UserId(tuple.UserSet.String())
+	}
 
 	conn, err := nioclient.DialCheckInsecure("localhost:50052")
 	if err != nil {
@@ -22,9 +44,9 @@ func main() {
 
 	c := nioclient.New(conn)
 
-	principal, ok, err := c.CheckWithTimestamp(context.Background(), nioclient.Ns(ns), nioclient.Obj(obj), nioclient.Rel(rel), nioclient.UserId(userId), nioclient.TimestampEmpty)
+	principal, ok, err := c.CheckWithTimestamp(context.Background(), tuple.Ns, tuple.Obj, tuple.Rel, userId, nioclient.TimestampEmpty)
 	if err != nil {
 		log.Fatalf("Error: %s", err.Error())
 	}
-	fmt.Printf("Result: %s %v", principal, ok)
+	fmt.Printf("Result: %s %s %v", tuple, principal, ok)
 }
@@ -27,6 +27,11 @@ func main() {
 	}
 	fmt.Printf("Result: %d objects at ts=%s\n", len(res.Objs), res.Ts)
 	for _, obj := range res.Objs {
-		fmt.Printf("%s\n", obj)
+		fmt.Printf("%s\n", nioclient.Tuple{
+			Ns:     nioclient.Ns(ns),
+			Obj:    nioclient.Obj(obj),
+			Rel:    nioclient.Rel(rel),
+			UserId: nioclient.UserId(userId),
+		})
 	}
 }
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	nioclient "github.com/ecociel/nioclient-go"
+	"github.com/ecociel/nioclient-go/schema"
+	"github.com/ecociel/nioclient-go/schema/gen"
+)
+
+// Usage:
+//
+//	nio-gen -config namespaces.yaml -pkg authz -o authz/relations.go
+//	nio-gen -compose docker-compose.yml -pkg authz -o authz/relations.go -resources authz/resources.go
+//	nio-gen -addr localhost:50052 -pkg authz -o authz/relations.go
+//	nio-gen -config namespaces.yaml -word userprofile=UserProfile -o authz/relations.go
+//
+// The constants file is regenerated on every run; the resources skeleton is
+// only written when it does not exist yet (or with -force).
+func main() {
+	config := flag.String("config", "", "namespace YAML file (multi-document)")
+	compose := flag.String("compose", "", "docker-compose file with the namespace YAML inlined in configs.namespaces")
+	addr := flag.String("addr", "", "check address to read the namespaces from with ListNamespaces")
+	pkg := flag.String("pkg", "authz", "package name of the generated files")
+	out := flag.String("o", "", "constants file to write (default: stdout)")
+	resources := flag.String("resources", "", "resource skeleton file to write")
+	force := flag.Bool("force", false, "overwrite an existing resources file")
+	opts := gen.Options{Words: map[string]string{}}
+	flag.Func("word", "spelling of a name segment made of several words, e.g. userprofile=UserProfile (repeatable)", func(s string) error {
+		segment, spelling, ok := strings.Cut(s, "=")
+		if !ok || segment == "" || spelling == "" {
+			return errors.New("want segment=Spelling")
+		}
+		opts.Words[segment] = spelling
+		return nil
+	})
+	flag.Parse()
+	opts.Package = *pkg
+
+	namespaces, err := load(*config, *compose, *addr)
+	if err != nil {
+		log.Fatalf("nio-gen: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := gen.Constants(&buf, namespaces, opts); err != nil {
+		log.Fatalf("nio-gen: %v", err)
+	}
+	if *out == "" {
+		_, _ = os.Stdout.Write(buf.Bytes())
+	} else if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		log.Fatalf("nio-gen: %v", err)
+	}
+
+	if *resources == "" {
+		return
+	}
+	if _, err := os.Stat(*resources); err == nil && !*force {
+		log.Printf("nio-gen: %s exists, not overwritten (use -force)", *resources)
+		return
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("nio-gen: %v", err)
+	}
+	buf.Reset()
+	if err := gen.Resources(&buf, namespaces, opts); err != nil {
+		log.Fatalf("nio-gen: %v", err)
+	}
+	if err := os.WriteFile(*resources, buf.Bytes(), 0o644); err != nil {
+		log.Fatalf("nio-gen: %v", err)
+	}
+}
+
+func load(config, compose, addr string) ([]nioclient.NamespaceMeta, error) {
+	switch {
+	case config != "":
+		namespaces, err := schema.ParseFile(config)
+		return schema.Metas(namespaces), err
+	case compose != "":
+		namespaces, err := schema.ParseCompose(compose, "namespaces")
+		return schema.Metas(namespaces), err
+	case addr != "":
+		conn, err := nioclient.DialCheckInsecure(addr)
+		if err != nil {
+			return nil, fmt.Errorf("connect check-service: %w", err)
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return nioclient.New(conn).ListNamespaces(ctx)
+	}
+	return nil, errors.New("one of -config, -compose or -addr is required")
+}
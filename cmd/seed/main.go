@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	nioclient "github.com/ecociel/nioclient-go"
+	"github.com/ecociel/nioclient-go/schema"
+	"github.com/ecociel/nioclient-go/seed"
+)
+
+// Usage:
+//
+//	seed -policies policies.yaml -dry-run
+//	seed -compose docker-compose.yml -addr localhost:50052 -prune
+func main() {
+	policiesPath := flag.String("policies", "", "policy YAML file (multi-document ns/obj/rel/users)")
+	compose := flag.String("compose", "", "docker-compose file with the policies inlined in configs.policies")
+	addr := flag.String("addr", "localhost:50052", "check address")
+	dryRun := flag.Bool("dry-run", false, "print the changes without writing them")
+	prune := flag.Bool("prune", false, "delete subjects of seeded relations that the file does not list")
+	chunk := flag.Int("chunk", nioclient.DefaultWriteChunkSize, "tuples per write")
+	flag.Parse()
+
+	var policies []schema.Policy
+	var err error
+	switch {
+	case *policiesPath != "":
+		policies, err = schema.ParsePolicyFile(*policiesPath)
+	case *compose != "":
+		policies, err = schema.ParseComposePolicies(*compose, "policies")
+	default:
+		log.Fatalf("usage: seed -policies file | -compose file [-addr host:port] [-dry-run] [-prune]")
+	}
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	conn, err := nioclient.DialCheckInsecure(*addr)
+	if err != nil {
+		log.Fatalf("connect check-service: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	res, err := seed.Apply(ctx, nioclient.New(conn), policies, seed.Options{DryRun: *dryRun, Prune: *prune, ChunkSize: *chunk})
+	for _, t := range res.Delete {
+		fmt.Printf("- %s\n", t)
+	}
+	for _, t := range res.Touch {
+		fmt.Printf("~ %s\n", t)
+	}
+	for _, t := range res.Add {
+		fmt.Printf("+ %s\n", t)
+	}
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if *dryRun {
+		fmt.Printf("dry run: %d to add, %d to make permanent, %d to delete at ts=%s\n", len(res.Add), len(res.Touch), len(res.Delete), res.Ts)
+		return
+	}
+	fmt.Printf("%d added, %d made permanent, %d deleted in %d writes, ts=%s\n", len(res.Add), len(res.Touch), len(res.Delete), res.Writes, res.Ts)
+}
@@ -10,11 +10,35 @@ import (
 	nioclient "github.com/ecociel/nioclient-go"
 )
 
+// Usage:
+//
+//	write doc:readme#viewer@user:123
+//	write doc readme viewer group:eng#member
 func main() {
-	ns := os.Args[1]
-	obj := os.Args[2]
-	rel := os.Args[3]
-	user := os.Args[4]
+	var tuple nioclient.Tuple
+	var err error
+	switch len(os.Args) {
+	case 2:
+		tuple, err = nioclient.ParseTuple(os.Args[1])
+	case 5:
+		tuple = nioclient.Tuple{
+			Ns:  nioclient.Ns(os.Args[1]),
+			Obj: nioclient.Obj(os.Args[2]),
+			Rel: nioclient.Rel(os.Args[3]),
+		}
+		if user := os.Args[4]; strings.Contains(user, "#") {
+			var userSet nioclient.UserSet
+			userSet, err = nioclient.ParseUserSet(user)
+			tuple.UserSet = &userSet
+		} else {
+			tuple.UserId = nioclient.UserId(user)
+		}
+	default:
+		log.Fatalf("usage: %s ns:obj#rel@user | ns obj rel user", os.Args[0])
+	}
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
 	conn, err := nioclient.DialCheckInsecure("localhost:50052")
 	if err != nil {
@@ -23,25 +47,9 @@ func main() {
 
 	c := nioclient.New(conn)
 
-	var ts nioclient.Timestamp
-	if strings.Contains(user, "#") {
-		a := strings.SplitN(user, ":", 2)
-		b := strings.SplitN(a[1], "#", 2)
-
-		userSet := nioclient.UserSet{
-			Ns:  nioclient.Ns(a[0]),
-			Obj: nioclient.Obj(b[0]),
-			Rel: nioclient.Rel(b[1]),
-		}
-		fmt.Printf("%v\n", userSet)
-		ts, err = c.AddOneUserSet(context.Background(),
-			nioclient.Ns(ns), nioclient.Obj(obj), nioclient.Rel(rel), userSet)
-	} else {
-		ts, err = c.AddOneUserId(context.Background(),
-			nioclient.Ns(ns), nioclient.Obj(obj), nioclient.Rel(rel), nioclient.UserId(user))
-	}
+	ts, err := c.Write(context.Background(), []nioclient.Tuple{tuple}, nil, nil)
 	if err != nil {
-		log.Fatalf("add-one: %v", err)
+		log.Fatalf("write %s: %v", tuple, err)
 	}
-	fmt.Printf("committed at ts=%s\n", ts)
+	fmt.Printf("committed %s at ts=%s\n", tuple, ts)
 }
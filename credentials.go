@@ -0,0 +1,170 @@
+package nioclient
+
+// Credential extraction for Wrap, WrapHTTP and Middleware. Browsers send the
+// session cookie; API clients and CLIs send a bearer token or an API key
+// header, and websocket upgrades (which cannot set headers from a browser)
+// pass the token in the query. Each credential resolves to the principal
+// through Wrapper.ResolveToken or its own TokenResolver.
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SessionCookie is the name of the session cookie read by default.
+const SessionCookie = "session"
+
+// TokenResolver maps a raw credential to the principal UserId, with the
+// contract of Wrapper.ResolveToken: found=false with a nil error means the
+// credential is unknown, expired or revoked.
+type TokenResolver interface {
+	ResolveToken(ctx context.Context, token string) (userId UserId, found bool, err error)
+}
+
+// TokenResolverFunc adapts a function to TokenResolver.
+type TokenResolverFunc func(ctx context.Context, token string) (userId UserId, found bool, err error)
+
+func (f TokenResolverFunc) ResolveToken(ctx context.Context, token string) (UserId, bool, error) {
+	return f(ctx, token)
+}
+
+// Credential extracts one kind of credential from a request.
+type Credential struct {
+	// Extract returns the raw credential; ok=false when the request does not
+	// carry it.
+	Extract func(r *http.Request) (token string, ok bool)
+	// Resolver resolves the credential; nil means Wrapper.ResolveToken.
+	Resolver TokenResolver
+	// Challenge is the WWW-Authenticate challenge of a 401 ("" for none).
+	Challenge string
+	// Browser tells whether a request carrying this credential may be a
+	// browser, which is redirected to signin instead of answered 401.
+	Browser bool
+}
+
+// ResolvedBy returns c resolved by resolver instead of Wrapper.ResolveToken.
+func (c Credential) ResolvedBy(resolver TokenResolver) Credential {
+	c.Resolver = resolver
+	return c
+}
+
+// FromCookie reads the credential from the cookie name.
+func FromCookie(name string) Credential {
+	return Credential{
+		Extract: func(r *http.Request) (string, bool) {
+			c, err := r.Cookie(name)
+			if err != nil {
+				return "", false
+			}
+			return c.Value, true
+		},
+		Challenge: fmt.Sprintf("Cookie name=%q", name),
+		Browser:   true,
+	}
+}
+
+// FromBearer reads the credential from an "Authorization: Bearer" header.
+func FromBearer() Credential {
+	return Credential{
+		Extract: func(r *http.Request) (string, bool) {
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				return "", false
+			}
+			token = strings.TrimSpace(token)
+			return token, token != ""
+		},
+		Challenge: "Bearer",
+	}
+}
+
+// FromHeader reads the credential from the header name, e.g. X-API-Key.
+func FromHeader(name string) Credential {
+	return Credential{
+		Extract: func(r *http.Request) (string, bool) {
+			token := r.Header.Get(name)
+			return token, token != ""
+		},
+		Challenge: fmt.Sprintf("APIKey header=%q", name),
+	}
+}
+
+// FromQuery reads the credential from the query parameter name of websocket
+// upgrade requests only: elsewhere a token in the URL leaks into logs and
+// Referer headers.
+func FromQuery(name string) Credential {
+	return Credential{
+		Extract: func(r *http.Request) (string, bool) {
+			if !isWebsocketUpgrade(r) {
+				return "", false
+			}
+			token := r.URL.Query().Get(name)
+			return token, token != ""
+		},
+		Challenge: fmt.Sprintf("APIKey query=%q", name),
+	}
+}
+
+// WithCredentials sets the credentials Wrap looks for, tried in order; the
+// first one the request carries decides (an unknown bearer token does not
+// fall back to the cookie). The default is FromCookie(SessionCookie).
+//
+//	nioclient.WithCredentials(
+//		nioclient.FromCookie("session"),
+//		nioclient.FromBearer(),
+//		nioclient.FromHeader("X-API-Key").ResolvedBy(apiKeys),
+//		nioclient.FromQuery("access_token"))
+func WithCredentials(creds ...Credential) WrapOption {
+	return func(c *wrapConfig) { c.credentials = creds }
+}
+
+// authenticate resolves the first credential r carries. present=false means
+// r carries none; browser tells whether that credential allows a browser.
+func (wd *wrapped) authenticate(r *http.Request) (userId UserId, present, found, browser bool, err error) {
+	for _, c := range wd.cfg.credentials {
+		token, ok := c.Extract(r)
+		if !ok {
+			continue
+		}
+		var resolver TokenResolver = wd.wrapper
+		if c.Resolver != nil {
+			resolver = c.Resolver
+		}
+		userId, found, err = resolver.ResolveToken(r.Context(), token)
+		return userId, true, found, c.Browser, err
+	}
+	return "", false, false, true, nil
+}
+
+// unauthenticated responds to a request without a valid credential: browsers
+// are redirected to signin, API clients get 401 with the challenges of the
+// configured credentials.
+func (wd *wrapped) unauthenticated(rw http.ResponseWriter, r *http.Request, browser bool) {
+	if browser && isBrowser(r) {
+		redirectToSignin(wd.wrapper, rw, r)
+		return
+	}
+	for _, c := range wd.cfg.credentials {
+		if c.Challenge != "" {
+			rw.Header().Add("WWW-Authenticate", c.Challenge)
+		}
+	}
+	http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// isBrowser tells a browser navigation from an API call: API clients ask for
+// something other than HTML, mark XHRs or upgrade the connection. A request
+// without Accept counts as a browser.
+func isBrowser(r *http.Request) bool {
+	if isWebsocketUpgrade(r) || r.Header.Get("X-Requested-With") == "XMLHttpRequest" {
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	return accept == "" || strings.Contains(accept, "text/html")
+}
+
+func isWebsocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
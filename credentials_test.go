@@ -0,0 +1,101 @@
+package nioclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var apiKeys = TokenResolverFunc(func(_ context.Context, token string) (UserId, bool, error) {
+	if token == "key-1" {
+		return "SVC", true, nil
+	}
+	return "", false, nil
+})
+
+func credentialsHandler(w Wrapper) http.Handler {
+	return WrapHTTP(w, func(http.ResponseWriter, *http.Request) (Resource, error) {
+		return testResource{}, nil
+	}, func(rw http.ResponseWriter, _ *http.Request, _ Resource, u User) error {
+		_, _ = rw.Write([]byte("ok:" + u.Principal()))
+		return nil
+	}, WithCredentials(
+		FromCookie("sid"),
+		FromBearer(),
+		FromHeader("X-API-Key").ResolvedBy(apiKeys),
+		FromQuery("access_token"),
+	))
+}
+
+func TestWrapCredentialsInOrder(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		modify func(r *http.Request)
+		want   string
+	}{
+		{"cookie", func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "sid", Value: "tok"}) }, "ok:P"},
+		{"bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer tok") }, "ok:P"},
+		{"api key", func(r *http.Request) { r.Header.Set("X-API-Key", "key-1") }, "ok:SVC"},
+		{"websocket query", func(r *http.Request) {
+			r.URL.RawQuery = "access_token=tok"
+			r.Header.Set("Upgrade", "websocket")
+		}, "ok:P"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &resolvingWrapper{resolvePrincipal: "P"}
+			r := httptest.NewRequest(http.MethodGet, "/articles/1", nil)
+			tt.modify(r)
+			rr := httptest.NewRecorder()
+			credentialsHandler(w).ServeHTTP(rr, r)
+			if rr.Code != http.StatusOK || rr.Body.String() != tt.want {
+				t.Fatalf("status = %d, body = %q, want %q", rr.Code, rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapQueryCredentialOnlyForWebsocket(t *testing.T) {
+	w := &resolvingWrapper{resolvePrincipal: "P"}
+	r := httptest.NewRequest(http.MethodGet, "/articles/1?access_token=tok", nil)
+	r.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+	credentialsHandler(w).ServeHTTP(rr, r)
+	if rr.Code != http.StatusUnauthorized || w.checkCalls != 0 {
+		t.Fatalf("status = %d, checkCalls = %d; want 401 without check", rr.Code, w.checkCalls)
+	}
+}
+
+func TestWrapUnauthenticatedAPIClientGets401(t *testing.T) {
+	w := &resolvingWrapper{prefix: "/app"} // every token is not_found
+	r := httptest.NewRequest(http.MethodGet, "/articles/1", nil)
+	r.Header.Set("Authorization", "Bearer stale")
+	rr := httptest.NewRecorder()
+	credentialsHandler(w).ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rr.Code)
+	}
+	got := rr.Header().Values("WWW-Authenticate")
+	if len(got) != 4 || got[1] != "Bearer" {
+		t.Fatalf("WWW-Authenticate = %q", got)
+	}
+}
+
+func TestWrapUnauthenticatedBrowserRedirects(t *testing.T) {
+	w := &resolvingWrapper{prefix: "/app"}
+	r := httptest.NewRequest(http.MethodGet, "/articles/1", nil)
+	r.Header.Set("Accept", "text/html,application/xhtml+xml")
+	rr := httptest.NewRecorder()
+	credentialsHandler(w).ServeHTTP(rr, r)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want 303", rr.Code)
+	}
+
+	r.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	credentialsHandler(w).ServeHTTP(rr, r)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("JSON client: status = %d, want 401", rr.Code)
+	}
+}
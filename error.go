@@ -1,8 +1,14 @@
 package nioclient
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Problemer is an error interface for errors that can yield a hint
@@ -35,3 +41,136 @@ func (e userError) Status() int {
 func notFound(err error) userError {
 	return userError{cause: err, status: http.StatusNotFound}
 }
+
+// Failure kinds of check RPCs. Every checkAPI method returns errors that match
+// one of these with errors.Is when the server status maps to it; use
+// errors.As with *RPCError for the underlying gRPC code.
+var (
+	// ErrPreconditionFailed: a Write precondition zookie no longer holds (OCC conflict).
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrUnknownNamespace: the namespace is not loaded by check.
+	ErrUnknownNamespace = errors.New("unknown namespace")
+	// ErrUnknownRelation: the relation is not declared in the namespace.
+	ErrUnknownRelation = errors.New("unknown relation")
+	// ErrUnavailable: check could not be reached or did not answer in time.
+	ErrUnavailable = errors.New("check unavailable")
+	// ErrInvalidZookie: a timestamp is not a valid packed zookie.
+	ErrInvalidZookie = errors.New("invalid zookie")
+)
+
+// RPCError is a failed check RPC. Kind is the failure kind (one of the Err*
+// variables above) or nil when the status has no dedicated kind; Err is the
+// gRPC status error, so status.Code keeps working on the wrapped chain.
+type RPCError struct {
+	Code codes.Code
+	Kind error
+	Err  error
+}
+
+func (e *RPCError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RPCError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the failure kind of e.
+func (e *RPCError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// rpcError classifies a gRPC client error into an *RPCError. Errors that do
+// not carry a gRPC status (io.EOF, client-side validation) pass through.
+func rpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var re *RPCError
+	if errors.As(err, &re) {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return &RPCError{Code: st.Code(), Kind: rpcErrorKind(st), Err: err}
+}
+
+// ErrorInfo reasons check attaches to InvalidArgument/NotFound statuses as a
+// google.rpc.ErrorInfo detail. They decide the failure kind independently of
+// the status message wording.
+const (
+	ReasonInvalidZookie    = "INVALID_ZOOKIE"
+	ReasonUnknownNamespace = "UNKNOWN_NAMESPACE"
+	ReasonUnknownRelation  = "UNKNOWN_RELATION"
+)
+
+var reasonKinds = map[string]error{
+	ReasonInvalidZookie:    ErrInvalidZookie,
+	ReasonUnknownNamespace: ErrUnknownNamespace,
+	ReasonUnknownRelation:  ErrUnknownRelation,
+}
+
+// rpcErrorKind maps a status to a failure kind. check reports unknown
+// namespaces, relations and malformed zookies with a generic code; they are
+// told apart by the ErrorInfo reason, or, for servers that send no details,
+// by the status message (see legacyMessageKind).
+func rpcErrorKind(st *status.Status) error {
+	switch st.Code() {
+	case codes.FailedPrecondition, codes.Aborted:
+		return ErrPreconditionFailed
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return ErrUnavailable
+	case codes.InvalidArgument, codes.NotFound:
+		for _, d := range st.Details() {
+			if info, ok := d.(*errdetails.ErrorInfo); ok {
+				return reasonKinds[info.GetReason()]
+			}
+		}
+		return legacyMessageKind(st.Message())
+	}
+	return nil
+}
+
+// legacyMessageKind classifies the status messages of check servers without
+// ErrorInfo details. The wording this client was written against is pinned by
+// TestRPCErrorLegacyMessages; a change on the server side must update both:
+//
+//	cannot unpack zookie …        (malformed ts / zookie)
+//	namespace <ns> not found
+//	unknown relation <ns>#<rel>
+func legacyMessageKind(msg string) error {
+	msg = strings.ToLower(msg)
+	switch {
+	case strings.Contains(msg, "zookie") || strings.Contains(msg, "timestamp"):
+		return ErrInvalidZookie
+	case strings.Contains(msg, "relation"):
+		// Before "namespace": a relation message may name its namespace.
+		return ErrUnknownRelation
+	case strings.Contains(msg, "namespace"):
+		return ErrUnknownNamespace
+	}
+	return nil
+}
+
+// HTTPStatus maps an error to the HTTP status Wrap responds with: Problemer
+// status, 409 for ErrPreconditionFailed, 400 for ErrInvalidZookie (e.g. a
+// malformed check_ts cookie), 503 for ErrUnavailable, and 500 otherwise —
+// including unknown namespaces and relations, which are configuration bugs.
+// Custom ErrorHandlerFuncs can use it to stay consistent with the default.
+func HTTPStatus(err error) int {
+	var problem Problemer
+	switch {
+	case errors.As(err, &problem):
+		return problem.Status()
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusConflict
+	case errors.Is(err, ErrInvalidZookie):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,124 @@
+package nioclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRPCErrorKinds(t *testing.T) {
+	cases := []struct {
+		err  error
+		want error
+	}{
+		{status.Error(codes.FailedPrecondition, "stale precondition"), ErrPreconditionFailed},
+		{status.Error(codes.Aborted, "conflict"), ErrPreconditionFailed},
+		{status.Error(codes.Unavailable, "connection refused"), ErrUnavailable},
+		{status.Error(codes.DeadlineExceeded, "deadline"), ErrUnavailable},
+		{status.Error(codes.NotFound, "namespace doc not found"), ErrUnknownNamespace},
+		{status.Error(codes.InvalidArgument, "unknown relation doc#nope"), ErrUnknownRelation},
+		{status.Error(codes.InvalidArgument, "cannot unpack zookie"), ErrInvalidZookie},
+	}
+	for _, tc := range cases {
+		err := fmt.Errorf("check doc:1#viewer@u1: %w", rpcError(tc.err))
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%v: errors.Is(%v) = false", tc.err, tc.want)
+		}
+		var re *RPCError
+		if !errors.As(err, &re) || re.Code != status.Code(tc.err) {
+			t.Errorf("%v: errors.As *RPCError = %+v", tc.err, re)
+		}
+		if status.Code(err) != status.Code(tc.err) {
+			t.Errorf("%v: status.Code through the chain = %v", tc.err, status.Code(err))
+		}
+	}
+}
+
+func TestRPCErrorReasonDetails(t *testing.T) {
+	withReason := func(code codes.Code, msg, reason string) error {
+		st, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: "check"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.Err()
+	}
+	cases := []struct {
+		err  error
+		want error
+	}{
+		// The reason wins over a message that would classify differently.
+		{withReason(codes.InvalidArgument, "bad relation in namespace", ReasonInvalidZookie), ErrInvalidZookie},
+		{withReason(codes.NotFound, "not found", ReasonUnknownNamespace), ErrUnknownNamespace},
+		{withReason(codes.InvalidArgument, "rejected", ReasonUnknownRelation), ErrUnknownRelation},
+	}
+	for _, tc := range cases {
+		if err := rpcError(tc.err); !errors.Is(err, tc.want) {
+			t.Errorf("%v: errors.Is(%v) = false", tc.err, tc.want)
+		}
+	}
+	// An unknown reason is unclassified, even when the message would match.
+	if err := rpcError(withReason(codes.InvalidArgument, "unknown relation doc#x", "OTHER")); errors.Is(err, ErrUnknownRelation) {
+		t.Fatal("unknown reason must not fall back to the message")
+	}
+}
+
+// TestRPCErrorLegacyMessages pins the status messages of check servers that
+// send no ErrorInfo details (the server contract legacyMessageKind relies on).
+func TestRPCErrorLegacyMessages(t *testing.T) {
+	cases := []struct {
+		code codes.Code
+		msg  string
+		want error
+	}{
+		{codes.InvalidArgument, "cannot unpack zookie: illegal base64 data", ErrInvalidZookie},
+		{codes.InvalidArgument, "invalid timestamp", ErrInvalidZookie},
+		{codes.NotFound, "namespace doc not found", ErrUnknownNamespace},
+		{codes.InvalidArgument, "unknown relation doc#nope", ErrUnknownRelation},
+		{codes.InvalidArgument, "unknown relation nope in namespace doc", ErrUnknownRelation},
+	}
+	for _, tc := range cases {
+		if got := rpcErrorKind(status.New(tc.code, tc.msg)); got != tc.want {
+			t.Errorf("%s %q: kind = %v, want %v", tc.code, tc.msg, got, tc.want)
+		}
+	}
+}
+
+func TestRPCErrorUnclassifiedAndPassThrough(t *testing.T) {
+	err := rpcError(status.Error(codes.Internal, "boom"))
+	for _, kind := range []error{ErrPreconditionFailed, ErrUnknownNamespace, ErrUnknownRelation, ErrUnavailable, ErrInvalidZookie} {
+		if errors.Is(err, kind) {
+			t.Fatalf("Internal must not match %v", kind)
+		}
+	}
+	if rpcError(io.EOF) != io.EOF {
+		t.Fatal("non-status errors must pass through unchanged")
+	}
+	if rpcError(nil) != nil {
+		t.Fatal("nil must stay nil")
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{rpcError(status.Error(codes.Aborted, "conflict")), http.StatusConflict},
+		{rpcError(status.Error(codes.InvalidArgument, "bad zookie")), http.StatusBadRequest},
+		{rpcError(status.Error(codes.Unavailable, "down")), http.StatusServiceUnavailable},
+		{rpcError(status.Error(codes.NotFound, "namespace x")), http.StatusInternalServerError},
+		{problemErr{status: http.StatusTeapot}, http.StatusTeapot},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		if got := HTTPStatus(tc.err); got != tc.want {
+			t.Errorf("HTTPStatus(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
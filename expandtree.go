@@ -0,0 +1,195 @@
+package nioclient
+
+// ExpandRecursive follows the usersets of Expand until every branch ends in
+// user ids, a cycle or the depth limit. Each distinct userset is expanded once
+// (so the walk reaches a fixed point even when branches share usersets), all
+// expands after the first are pinned to its zookie, and at most Concurrency
+// expands are in flight.
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultExpandDepth is the number of levels ExpandRecursive follows below
+	// the root when ExpandOptions.MaxDepth is not set.
+	DefaultExpandDepth = 16
+	// DefaultExpandConcurrency is the number of concurrent expands when
+	// ExpandOptions.Concurrency is not set.
+	DefaultExpandConcurrency = 8
+)
+
+// ExpandOptions configures ExpandRecursive.
+type ExpandOptions struct {
+	// Ts is the zookie the root expand must be at least as fresh as; empty
+	// means TimestampEmpty.
+	Ts Timestamp
+	// MaxDepth bounds the levels followed below the root (<= 0: DefaultExpandDepth).
+	MaxDepth int
+	// Concurrency bounds the expands in flight (<= 0: DefaultExpandConcurrency).
+	Concurrency int
+}
+
+// ExpandNode is one userset of an ExpandTree: its direct user ids and the
+// usersets it contains. Object pointers (rel "...") are not followed.
+type ExpandNode struct {
+	UserSet  UserSet
+	UserIds  []string
+	Children []*ExpandNode
+	// Cycle marks a userset already on the path from the root; it is not
+	// expanded again.
+	Cycle bool
+	// Truncated marks a userset below MaxDepth; it is not expanded.
+	Truncated bool
+}
+
+// ExpandTree is the outcome of ExpandRecursive. UserIds is the sorted,
+// deduplicated union of the user ids of every node. Complete is false when a
+// node was truncated at MaxDepth, so UserIds may miss users.
+type ExpandTree struct {
+	Ts       Timestamp
+	Root     *ExpandNode
+	UserIds  []string
+	Complete bool
+}
+
+// expandCall is one expand of a userset shared by every node that reaches it.
+type expandCall struct {
+	done chan struct{}
+	res  ExpandResult
+	err  error
+}
+
+type treeExpander struct {
+	c        *checkAPI
+	ts       Timestamp
+	maxDepth int
+	sem      chan struct{}
+
+	mu        sync.Mutex
+	calls     map[UserSet]*expandCall
+	truncated bool
+}
+
+// ExpandRecursive expands ⟨ns, obj, rel⟩ and every userset it contains into a
+// tree, and flattens it into the effective user ids.
+func (c *checkAPI) ExpandRecursive(ctx context.Context, ns Ns, obj Obj, rel Rel, opts ExpandOptions) (ExpandTree, error) {
+	ts := opts.Ts
+	if ts == "" {
+		ts = TimestampEmpty
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultExpandDepth
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultExpandConcurrency
+	}
+
+	root := UserSet{Ns: ns, Obj: obj, Rel: rel}
+	first, err := c.ExpandWithTimestamp(ctx, ns, obj, rel, ts)
+	if err != nil {
+		return ExpandTree{}, err
+	}
+	x := &treeExpander{
+		c:        c,
+		ts:       first.Ts,
+		maxDepth: opts.MaxDepth,
+		sem:      make(chan struct{}, opts.Concurrency),
+		calls:    make(map[UserSet]*expandCall),
+	}
+	done := make(chan struct{})
+	close(done)
+	x.calls[root] = &expandCall{done: done, res: first}
+
+	g, gctx := errgroup.WithContext(ctx)
+	node := x.node(gctx, g, root, nil, 0)
+	if err := g.Wait(); err != nil {
+		return ExpandTree{}, err
+	}
+
+	seen := make(map[string]struct{})
+	var userIds []string
+	var collect func(n *ExpandNode)
+	collect = func(n *ExpandNode) {
+		for _, id := range n.UserIds {
+			if _, dup := seen[id]; !dup {
+				seen[id] = struct{}{}
+				userIds = append(userIds, id)
+			}
+		}
+		for _, child := range n.Children {
+			collect(child)
+		}
+	}
+	collect(node)
+	slices.Sort(userIds)
+	return ExpandTree{Ts: first.Ts, Root: node, UserIds: userIds, Complete: !x.truncated}, nil
+}
+
+// node returns the node of us and fills it in the background on g: the
+// expand result, then one child per contained userset.
+func (x *treeExpander) node(ctx context.Context, g *errgroup.Group, us UserSet, path []UserSet, depth int) *ExpandNode {
+	n := &ExpandNode{UserSet: us}
+	switch {
+	case slices.Contains(path, us):
+		n.Cycle = true
+		return n
+	case depth > x.maxDepth:
+		n.Truncated = true
+		x.mu.Lock()
+		x.truncated = true
+		x.mu.Unlock()
+		return n
+	}
+	path = append(slices.Clip(path), us)
+	g.Go(func() error {
+		res, err := x.expand(ctx, us)
+		if err != nil {
+			return err
+		}
+		n.UserIds = res.UserIds
+		for _, child := range res.Usersets {
+			if child.Rel == RelUnspecified {
+				continue
+			}
+			n.Children = append(n.Children, x.node(ctx, g, child, path, depth+1))
+		}
+		return nil
+	})
+	return n
+}
+
+// expand returns the pinned expand of us, issuing the RPC only for the first
+// caller.
+func (x *treeExpander) expand(ctx context.Context, us UserSet) (ExpandResult, error) {
+	x.mu.Lock()
+	call, ok := x.calls[us]
+	if !ok {
+		call = &expandCall{done: make(chan struct{})}
+		x.calls[us] = call
+	}
+	x.mu.Unlock()
+	if ok {
+		select {
+		case <-call.done:
+			return call.res, call.err
+		case <-ctx.Done():
+			return ExpandResult{}, ctx.Err()
+		}
+	}
+
+	defer close(call.done)
+	select {
+	case x.sem <- struct{}{}:
+	case <-ctx.Done():
+		call.err = ctx.Err()
+		return ExpandResult{}, call.err
+	}
+	call.res, call.err = x.c.ExpandWithTimestamp(ctx, us.Ns, us.Obj, us.Rel, x.ts)
+	<-x.sem
+	return call.res, call.err
+}
@@ -0,0 +1,90 @@
+package nioclient
+
+import (
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+)
+
+// groupGraph serves expands of a small group graph: doc:d1#viewer contains
+// group a and b, both contain group c, and c contains a again (a cycle).
+func groupGraph(t *testing.T, inFlight, peak *int32) *fakeCheckService {
+	graph := map[string]*proto.ExpandResponse{
+		"doc:d1#viewer": {UserIds: []string{"u0"}, Usersets: []*proto.UserSet{
+			{Ns: "group", Obj: "a", Rel: "member"}, {Ns: "group", Obj: "b", Rel: "member"},
+			{Ns: "folder", Obj: "f1", Rel: "..."}}},
+		"group:a#member": {UserIds: []string{"u1"}, Usersets: []*proto.UserSet{{Ns: "group", Obj: "c", Rel: "member"}}},
+		"group:b#member": {UserIds: []string{"u2", "u1"}, Usersets: []*proto.UserSet{{Ns: "group", Obj: "c", Rel: "member"}}},
+		"group:c#member": {UserIds: []string{"u3"}, Usersets: []*proto.UserSet{{Ns: "group", Obj: "a", Rel: "member"}}},
+	}
+	return &fakeCheckService{expand: func(in *proto.ExpandRequest) (*proto.ExpandResponse, error) {
+		key := in.Ns + ":" + in.Obj + "#" + in.Rel
+		if key != "doc:d1#viewer" && in.Ts != "snap" {
+			t.Errorf("expand %s at %q, want pinned snap", key, in.Ts)
+		}
+		if inFlight != nil {
+			n := atomic.AddInt32(inFlight, 1)
+			for {
+				p := atomic.LoadInt32(peak)
+				if n <= p || atomic.CompareAndSwapInt32(peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(inFlight, -1)
+		}
+		res := graph[key]
+		return &proto.ExpandResponse{Ts: "snap", UserIds: res.UserIds, Usersets: res.Usersets}, nil
+	}}
+}
+
+func TestExpandRecursiveFlattensAndDetectsCycles(t *testing.T) {
+	fake := groupGraph(t, nil, nil)
+	c := &checkAPI{grpcClient: fake}
+
+	tree, err := c.ExpandRecursive(t.Context(), "doc", "d1", "viewer", ExpandOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tree.UserIds, []string{"u0", "u1", "u2", "u3"}) || !tree.Complete || tree.Ts != "snap" {
+		t.Fatalf("tree = %+v", tree)
+	}
+	// Every userset is expanded once, even though c is reached twice.
+	if got := fake.count("expand"); got != 4 {
+		t.Fatalf("expands = %d, want 4", got)
+	}
+	// doc → a → c → a is a cycle.
+	a := tree.Root.Children[0]
+	cNode := a.Children[0]
+	if len(tree.Root.Children) != 2 || !cNode.Children[0].Cycle {
+		t.Fatalf("a → c → %+v, want a cycle back to a", cNode.Children[0])
+	}
+}
+
+func TestExpandRecursiveDepthLimit(t *testing.T) {
+	c := &checkAPI{grpcClient: groupGraph(t, nil, nil)}
+	tree, err := c.ExpandRecursive(t.Context(), "doc", "d1", "viewer", ExpandOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Complete || slices.Contains(tree.UserIds, "u3") {
+		t.Fatalf("tree = %+v, want truncated before group c", tree)
+	}
+	if !tree.Root.Children[0].Children[0].Truncated {
+		t.Fatal("group c not marked truncated")
+	}
+}
+
+func TestExpandRecursiveConcurrencyLimit(t *testing.T) {
+	var inFlight, peak int32
+	c := &checkAPI{grpcClient: groupGraph(t, &inFlight, &peak)}
+	if _, err := c.ExpandRecursive(t.Context(), "doc", "d1", "viewer", ExpandOptions{Concurrency: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if peak != 1 {
+		t.Fatalf("peak concurrent expands = %d, want 1", peak)
+	}
+}
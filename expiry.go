@@ -0,0 +1,284 @@
+package nioclient
+
+// Managing time-bound grants (tuples with Expires): find the ones lapsing
+// soon, move an expiry atomically, revoke the ones already lapsed, and a
+// scheduler emitting an event shortly before each grant lapses so owners can
+// be notified.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// ErrGrantNotFound is returned by SetGrantExpiry and ExtendGrant when the
+// tuple is not stored.
+var ErrGrantNotFound = errors.New("grant not found")
+
+// ExpiringResult holds grants found by FindExpiring or FindExpired, sorted by
+// Expires, and the snapshot they were read at.
+type ExpiringResult struct {
+	Ts     Timestamp
+	Tuples []Tuple
+}
+
+// FindExpiring returns the stored tuples matching filters that are still
+// valid but expire within the next window.
+func (c *checkAPI) FindExpiring(ctx context.Context, window time.Duration, filters ...ReadFilter) (ExpiringResult, error) {
+	now := time.Now()
+	return c.findExpiry(ctx, filters, func(exp time.Time) bool {
+		return exp.After(now) && !exp.After(now.Add(window))
+	})
+}
+
+// FindExpired returns the stored tuples matching filters whose expiry has
+// passed. check already ignores them; SweepExpired deletes them.
+func (c *checkAPI) FindExpired(ctx context.Context, filters ...ReadFilter) (ExpiringResult, error) {
+	now := time.Now()
+	return c.findExpiry(ctx, filters, func(exp time.Time) bool {
+		return !exp.After(now)
+	})
+}
+
+// findExpiry pages through the tuples matching filters at one snapshot and
+// keeps those whose expiry satisfies keep.
+func (c *checkAPI) findExpiry(ctx context.Context, filters []ReadFilter, keep func(time.Time) bool) (ExpiringResult, error) {
+	var res ExpiringResult
+	ts, cursor := TimestampEmpty, ""
+	for {
+		page, err := c.readPage(ctx, ts, DefaultReadPageSize, cursor, filters)
+		if err != nil {
+			return ExpiringResult{}, err
+		}
+		if cursor == "" {
+			ts, res.Ts = page.Ts, page.Ts
+		}
+		for _, t := range page.Tuples {
+			if t.Expires != nil && keep(*t.Expires) {
+				res.Tuples = append(res.Tuples, t)
+			}
+		}
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	slices.SortStableFunc(res.Tuples, func(a, b Tuple) int { return a.Expires.Compare(*b.Expires) })
+	return res, nil
+}
+
+// SetGrantExpiry replaces the expiry of the stored tuple t (matched without
+// its Expires) with expires; nil makes the grant permanent. The change is a
+// WriteBatch.Touch sent by UpdateTuples, so it is atomic against concurrent
+// writes. Returns the commit zookie, or ErrGrantNotFound when t is not stored.
+func (c *checkAPI) SetGrantExpiry(ctx context.Context, t Tuple, expires *time.Time) (Timestamp, error) {
+	return c.updateGrant(ctx, t, func(*time.Time) *time.Time { return expires })
+}
+
+// ExtendGrant moves the expiry of the stored tuple t by d (negative d
+// shortens it). A lapsed grant is extended from now; a permanent grant is
+// left unchanged. Atomic like SetGrantExpiry.
+func (c *checkAPI) ExtendGrant(ctx context.Context, t Tuple, d time.Duration) (Timestamp, error) {
+	return c.updateGrant(ctx, t, func(cur *time.Time) *time.Time {
+		if cur == nil {
+			return nil
+		}
+		from := *cur
+		if now := time.Now().UTC(); from.Before(now) {
+			from = now
+		}
+		next := from.Add(d)
+		return &next
+	})
+}
+
+func (c *checkAPI) updateGrant(ctx context.Context, t Tuple, next func(cur *time.Time) *time.Time) (Timestamp, error) {
+	if err := validateTuple(t); err != nil {
+		return "", err
+	}
+	key := keyOf(t)
+	rel := t.Rel
+	ts, err := c.UpdateTuples(ctx, []ReadFilter{FilterByObject(t.Ns, t.Obj, &rel)}, func(current []Tuple) (add, del []Tuple, err error) {
+		i := slices.IndexFunc(current, func(s Tuple) bool { return keyOf(s) == key })
+		if i < 0 {
+			return nil, nil, fmt.Errorf("%s: %w", t, ErrGrantNotFound)
+		}
+		stored := current[i]
+		exp := next(stored.Expires)
+		if exp == nil && stored.Expires == nil || exp != nil && stored.Expires != nil && exp.Equal(*stored.Expires) {
+			return nil, nil, nil
+		}
+		updated := stored
+		if exp != nil {
+			utc := exp.UTC()
+			updated.Expires = &utc
+		} else {
+			updated.Expires = nil
+		}
+		add, del = c.NewWriteBatch().Touch(updated).tuples()
+		return add, del, nil
+	}, UpdateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("update grant: %w", err)
+	}
+	return ts, nil
+}
+
+// SweepResult is the outcome of SweepExpired: the tuples deleted and the
+// commit zookie of the last delete.
+type SweepResult struct {
+	Ts      Timestamp
+	Revoked []Tuple
+}
+
+// SweepExpired deletes the lapsed tuples matching filters in writes of at
+// most chunkSize tuples (DefaultWriteChunkSize when <= 0). Each write is
+// conditioned on the previous one (the first on the read snapshot), so a
+// grant extended concurrently is never deleted: the sweep stops with
+// ErrPreconditionFailed and can simply be run again. On error the result
+// still lists the tuples already deleted.
+func (c *checkAPI) SweepExpired(ctx context.Context, chunkSize int, filters ...ReadFilter) (SweepResult, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultWriteChunkSize
+	}
+	expired, err := c.FindExpired(ctx, filters...)
+	if err != nil {
+		return SweepResult{}, fmt.Errorf("sweep expired: %w", err)
+	}
+	res := SweepResult{Ts: expired.Ts}
+	for chunk := range slices.Chunk(expired.Tuples, chunkSize) {
+		b := c.NewWriteBatch()
+		for _, t := range chunk {
+			b.Delete(t)
+		}
+		precondition := res.Ts
+		ts, err := b.Commit(ctx, &precondition)
+		if err != nil {
+			return res, fmt.Errorf("sweep expired: %d of %d deleted: %w", len(res.Revoked), len(expired.Tuples), err)
+		}
+		res.Ts = ts
+		res.Revoked = append(res.Revoked, chunk...)
+	}
+	return res, nil
+}
+
+const (
+	// DefaultExpiryLead is how long before a grant lapses ExpiryScheduler
+	// emits its event when ExpirySchedulerOptions.Lead is not set.
+	DefaultExpiryLead = 24 * time.Hour
+	// DefaultExpiryScanInterval is how often ExpiryScheduler reads the grants
+	// when ExpirySchedulerOptions.Interval is not set.
+	DefaultExpiryScanInterval = 5 * time.Minute
+)
+
+// ExpiryEvent announces that Tuple lapses at Expires.
+type ExpiryEvent struct {
+	Tuple   Tuple
+	Expires time.Time
+}
+
+// ExpirySchedulerOptions configures an ExpiryScheduler.
+type ExpirySchedulerOptions struct {
+	// Filters select the grants to watch; at least one is required.
+	Filters []ReadFilter
+	// Lead is how long before the expiry the event is emitted
+	// (<= 0: DefaultExpiryLead).
+	Lead time.Duration
+	// Interval is how often the grants are read again
+	// (<= 0: DefaultExpiryScanInterval).
+	Interval time.Duration
+}
+
+// ExpiryScheduler emits an ExpiryEvent Lead before each watched grant lapses.
+// Grants are re-read every Interval, so new and extended grants are picked up;
+// an extended grant is announced again for its new expiry. Create it with
+// NewExpiryScheduler and start it with Run.
+type ExpiryScheduler struct {
+	c    *checkAPI
+	opts ExpirySchedulerOptions
+}
+
+// NewExpiryScheduler creates a scheduler watching the grants selected by
+// opts.Filters.
+func (c *checkAPI) NewExpiryScheduler(opts ExpirySchedulerOptions) *ExpiryScheduler {
+	if opts.Lead <= 0 {
+		opts.Lead = DefaultExpiryLead
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultExpiryScanInterval
+	}
+	return &ExpiryScheduler{c: c, opts: opts}
+}
+
+type expiryKey struct {
+	tuple   tupleKey
+	expires int64 // unix millis; time.Time keys would compare locations
+}
+
+func expiryKeyOf(t Tuple, expires time.Time) expiryKey {
+	return expiryKey{tuple: keyOf(t), expires: expires.UnixMilli()}
+}
+
+// Run calls emit for every grant entering its lead window, from the calling
+// goroutine, until ctx is done; it then returns ctx.Err(). A grant already
+// inside the window when first seen is announced immediately. Failed scans
+// are logged and retried at the next interval.
+func (s *ExpiryScheduler) Run(ctx context.Context, emit func(ExpiryEvent)) error {
+	if len(s.opts.Filters) == 0 {
+		return errors.New("expiry scheduler: at least one filter required")
+	}
+	emitted := make(map[expiryKey]bool)
+	var pending []ExpiryEvent // inside the next scan window, not yet due
+	nextScan := time.Now()
+	for {
+		now := time.Now()
+		if !now.Before(nextScan) {
+			found, err := s.c.FindExpiring(ctx, s.opts.Lead+s.opts.Interval, s.opts.Filters...)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				log.Printf("expiry scheduler: %v", err)
+			} else {
+				pending = pending[:0]
+				for _, t := range found.Tuples {
+					k := expiryKeyOf(t, *t.Expires)
+					if !emitted[k] {
+						pending = append(pending, ExpiryEvent{Tuple: t, Expires: *t.Expires})
+					}
+				}
+				for k := range emitted {
+					if k.expires < now.UnixMilli() {
+						delete(emitted, k) // lapsed; never seen again
+					}
+				}
+			}
+			nextScan = now.Add(s.opts.Interval)
+		}
+
+		wake := nextScan
+		pending = slices.DeleteFunc(pending, func(e ExpiryEvent) bool {
+			due := e.Expires.Add(-s.opts.Lead)
+			if now.Before(due) {
+				if due.Before(wake) {
+					wake = due
+				}
+				return false
+			}
+			emitted[expiryKeyOf(e.Tuple, e.Expires)] = true
+			emit(e)
+			return true
+		})
+
+		timer := time.NewTimer(max(time.Until(wake), 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package nioclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+)
+
+// expiryFixture stores a lapsed, a soon-expiring, a late-expiring and a
+// permanent grant on doc:d1.
+func expiryFixture(t *testing.T) (fake *fakeCheckService, soon time.Time) {
+	now := time.Now().UTC().Truncate(time.Second)
+	soon = now.Add(2 * time.Hour)
+	at := func(tm time.Time) string { return tm.Format(time.RFC3339) }
+	fake = &fakeCheckService{read: readTuples(t, "snap",
+		fmt.Sprintf("doc:d1#viewer@lapsed[expires=%s]", at(now.Add(-time.Hour))),
+		fmt.Sprintf("doc:d1#viewer@soon[expires=%s]", at(soon)),
+		fmt.Sprintf("doc:d1#viewer@late[expires=%s]", at(now.Add(30*24*time.Hour))),
+		"doc:d1#viewer@forever",
+	)}
+	return fake, soon
+}
+
+func TestFindExpiringAndExpired(t *testing.T) {
+	fake, _ := expiryFixture(t)
+	c := &checkAPI{grpcClient: fake}
+	filter := FilterByObject("doc", "d1", nil)
+
+	expiring, err := c.FindExpiring(t.Context(), 24*time.Hour, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expiring.Tuples) != 1 || expiring.Tuples[0].UserId != "soon" || expiring.Ts != "snap" {
+		t.Fatalf("expiring = %+v", expiring)
+	}
+	expired, err := c.FindExpired(t.Context(), filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired.Tuples) != 1 || expired.Tuples[0].UserId != "lapsed" {
+		t.Fatalf("expired = %+v", expired)
+	}
+}
+
+func TestExtendGrantRewritesExpiryWithPrecondition(t *testing.T) {
+	fake, soon := expiryFixture(t)
+	var write *proto.WriteRequest
+	fake.write = func(in *proto.WriteRequest) (*proto.WriteResponse, error) {
+		write = in
+		return &proto.WriteResponse{Ts: "commit"}, nil
+	}
+	c := &checkAPI{grpcClient: fake}
+
+	ts, err := c.ExtendGrant(t.Context(), Tuple{Ns: "doc", Obj: "d1", Rel: "viewer", UserId: "soon"}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts != "commit" || write.GetTs() != "snap" {
+		t.Fatalf("ts = %q, precondition = %q", ts, write.GetTs())
+	}
+	if len(write.DelTuples) != 1 || len(write.AddTuples) != 1 || write.DelTuples[0].Condition != nil {
+		t.Fatalf("write = %v, want a touch of the grant", write)
+	}
+	if got, want := write.AddTuples[0].GetExpires(), soon.Add(24*time.Hour).Unix(); got != want {
+		t.Fatalf("new expiry = %d, want %d", got, want)
+	}
+}
+
+func TestSetGrantExpiryNotFound(t *testing.T) {
+	fake, _ := expiryFixture(t)
+	c := &checkAPI{grpcClient: fake}
+	_, err := c.SetGrantExpiry(t.Context(), Tuple{Ns: "doc", Obj: "d1", Rel: "viewer", UserId: "nobody"}, nil)
+	if !errors.Is(err, ErrGrantNotFound) {
+		t.Fatalf("err = %v, want ErrGrantNotFound", err)
+	}
+}
+
+func TestSweepExpiredChainsPreconditions(t *testing.T) {
+	now := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	var tuples []string
+	for i := range 5 {
+		tuples = append(tuples, fmt.Sprintf("doc:d1#viewer@u%d[expires=%s]", i, now))
+	}
+	var preconditions []string
+	fake := &fakeCheckService{
+		read: readTuples(t, "snap", tuples...),
+		write: func(in *proto.WriteRequest) (*proto.WriteResponse, error) {
+			preconditions = append(preconditions, in.GetTs())
+			for _, d := range in.DelTuples {
+				if d.Condition != nil {
+					t.Errorf("delete %v carries its expiry", d)
+				}
+			}
+			return &proto.WriteResponse{Ts: fmt.Sprintf("c%d", len(preconditions))}, nil
+		},
+	}
+	c := &checkAPI{grpcClient: fake}
+
+	res, err := c.SweepExpired(t.Context(), 2, FilterByObject("doc", "d1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Revoked) != 5 || res.Ts != "c3" {
+		t.Fatalf("res = %+v", res)
+	}
+	if fmt.Sprint(preconditions) != "[snap c1 c2]" {
+		t.Fatalf("preconditions = %v, want each write chained to the previous", preconditions)
+	}
+}
+
+func TestExpirySchedulerEmitsOnce(t *testing.T) {
+	fake, soon := expiryFixture(t)
+	c := &checkAPI{grpcClient: fake}
+	s := c.NewExpiryScheduler(ExpirySchedulerOptions{
+		Filters:  []ReadFilter{FilterByObject("doc", "d1", nil)},
+		Lead:     3 * time.Hour,
+		Interval: 10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	var events []ExpiryEvent
+	err := s.Run(ctx, func(e ExpiryEvent) { events = append(events, e) })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run = %v", err)
+	}
+	if len(events) != 1 || events[0].Tuple.UserId != "soon" || !events[0].Expires.Equal(soon) {
+		t.Fatalf("events = %+v, want one for the soon grant", events)
+	}
+	if fake.count("read") < 3 {
+		t.Fatalf("reads = %d, want several scans", fake.count("read"))
+	}
+}
@@ -0,0 +1,398 @@
+package nioclient
+
+// CheckExplain answers a check together with its derivation: the tree of
+// usersets visited, which rewrite each relation uses, and the stored tuples
+// (with their expiry) linking them. Tuples lapsed at the snapshot are left
+// out, as check ignores them. Servers with the explain RPC build the
+// tree themselves; otherwise the client reconstructs it by walking Expand and
+// Read at one snapshot, labelled with ListNamespaces metadata. The client-side
+// walk costs two RPCs per visited userset — it is a support tool, not for the
+// request path.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultExplainDepth bounds the client-side walk of CheckExplain.
+const DefaultExplainDepth = 8
+
+// ExplainNode is one userset visited while evaluating a check.
+type ExplainNode struct {
+	UserSet UserSet
+	// Kind is the rewrite of the relation: this | computed | tuple_to | union;
+	// empty when the schema does not declare the relation.
+	Kind    string
+	Matched bool
+	// Via is the stored tuple linking the node to its parent: a userset
+	// subject, or the tupleset tuple of a tuple_to. Nil for the root and for
+	// computed rewrites.
+	Via *Tuple
+	// Grant is the stored tuple granting the user directly (possibly through
+	// allUsers / authenticatedUsers).
+	Grant *Tuple
+	// Expires is when the first of Via and Grant lapses; nil when both are
+	// permanent (or absent).
+	Expires   *time.Time
+	Children  []*ExplainNode
+	Truncated bool // not expanded: depth limit or cycle
+}
+
+// Explanation is the outcome of CheckExplain. Ok and Principal are the check
+// decision at Ts; Root explains it. Native reports whether the check server
+// built the tree.
+type Explanation struct {
+	Ts        Timestamp
+	Ns        Ns
+	Obj       Obj
+	Rel       Rel
+	UserId    UserId
+	Ok        bool
+	Principal Principal
+	Root      *ExplainNode
+	Native    bool
+}
+
+// CheckExplain checks whether userId has rel on ⟨ns, obj⟩ at a snapshot at
+// least as fresh as ts and explains the decision.
+func (c *checkAPI) CheckExplain(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId, ts Timestamp) (Explanation, error) {
+	ts = c.freshen(ctx, ts, PrincipalKey(userId), ObjectKey(ns, obj))
+	if !c.explainUnsupported.Load() {
+		e, err := c.explainRPC(ctx, ns, obj, rel, userId, ts)
+		if status.Code(err) != codes.Unimplemented {
+			if err != nil {
+				return Explanation{}, fmt.Errorf("explain %s: %w", checkString(ns, obj, rel, userId), rpcError(err))
+			}
+			return e, nil
+		}
+		// Older check servers: remember and explain client-side from now on.
+		c.explainUnsupported.Store(true)
+	}
+	e, err := c.explainWalk(ctx, ns, obj, rel, userId, ts)
+	if err != nil {
+		return Explanation{}, fmt.Errorf("explain %s: %w", checkString(ns, obj, rel, userId), err)
+	}
+	return e, nil
+}
+
+func (c *checkAPI) explainRPC(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId, ts Timestamp) (Explanation, error) {
+	req := &proto.ExplainRequest{
+		Ns:     string(ns),
+		Obj:    string(obj),
+		Rel:    string(rel),
+		UserId: string(userId),
+		Ts:     string(ts),
+	}
+	res, err := retryCall(ctx, c.retry, "explain", true, func(ctx context.Context) (*proto.ExplainResponse, error) {
+		return c.grpcClient.Explain(ctx, req)
+	})
+	if err != nil {
+		return Explanation{}, err
+	}
+	root, err := explainNodeFromProto(res.GetRoot())
+	if err != nil {
+		return Explanation{}, err
+	}
+	e := Explanation{
+		Ts: Timestamp(res.GetTs()), Ns: ns, Obj: obj, Rel: rel, UserId: userId,
+		Ok: res.GetOk(), Root: root, Native: true,
+	}
+	if res.Principal != nil {
+		e.Principal = Principal(res.Principal.GetId())
+	}
+	return e, nil
+}
+
+func explainNodeFromProto(pn *proto.ExplainNode) (*ExplainNode, error) {
+	if pn == nil {
+		return nil, nil
+	}
+	n := &ExplainNode{
+		UserSet:   UserSet{Ns: Ns(pn.GetNs()), Obj: Obj(pn.GetObj()), Rel: Rel(pn.GetRel())},
+		Kind:      pn.GetKind(),
+		Matched:   pn.GetMatched(),
+		Truncated: pn.GetTruncated(),
+	}
+	for _, link := range []struct {
+		src *proto.Tuple
+		dst **Tuple
+	}{{pn.Via, &n.Via}, {pn.Grant, &n.Grant}} {
+		if link.src == nil {
+			continue
+		}
+		t, err := tupleFromProto(link.src)
+		if err != nil {
+			return nil, fmt.Errorf("explain node %s: %w", n.UserSet, err)
+		}
+		*link.dst = &t
+	}
+	n.Expires = earliestExpiry(n.Via, n.Grant)
+	for _, pc := range pn.GetChildren() {
+		child, err := explainNodeFromProto(pc)
+		if err != nil {
+			return nil, err
+		}
+		n.Children = append(n.Children, child)
+	}
+	return n, nil
+}
+
+// explainer walks Expand/Read at one snapshot to rebuild a check derivation.
+type explainer struct {
+	c      *checkAPI
+	userId UserId
+	ts     Timestamp
+	at     time.Time          // instant of ts, for tuple expiry
+	kinds  map[UserSet]string // keyed by ⟨ns, "", rel⟩
+	onPath map[UserSet]bool
+}
+
+func (c *checkAPI) explainWalk(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId, ts Timestamp) (Explanation, error) {
+	metas, err := c.ListNamespaces(ctx)
+	if err != nil {
+		return Explanation{}, err
+	}
+	kinds := make(map[UserSet]string)
+	for _, m := range metas {
+		for _, r := range m.Relations {
+			kinds[UserSet{Ns: Ns(m.Name), Rel: Rel(r.Name)}] = r.Kind
+		}
+	}
+
+	// The root expand picks the snapshot every later RPC is pinned to.
+	root := UserSet{Ns: ns, Obj: obj, Rel: rel}
+	exp, err := c.ExpandWithTimestamp(ctx, ns, obj, rel, ts)
+	if err != nil {
+		return Explanation{}, err
+	}
+	principal, ok, err := c.CheckWithTimestamp(ctx, ns, obj, rel, userId, exp.Ts)
+	if err != nil {
+		return Explanation{}, err
+	}
+	e := &explainer{c: c, userId: userId, ts: exp.Ts, at: snapshotTime(exp.Ts), kinds: kinds, onPath: make(map[UserSet]bool)}
+	node, err := e.walk(ctx, root, exp, 1)
+	if err != nil {
+		return Explanation{}, err
+	}
+	node.Expires = earliestExpiry(node.Grant)
+	return Explanation{
+		Ts: exp.Ts, Ns: ns, Obj: obj, Rel: rel, UserId: userId,
+		Ok: ok, Principal: principal, Root: node,
+	}, nil
+}
+
+func (e *explainer) kind(us UserSet) string {
+	return e.kinds[UserSet{Ns: us.Ns, Rel: us.Rel}]
+}
+
+// grants reports whether a direct subject includes the explained user.
+func (e *explainer) grants(subject UserId) bool {
+	return subject == e.userId || subject == UserIdAllUsers ||
+		subject == UserIdAuthenticatedUsers && e.userId != "" && e.userId != UserId(Anonymous)
+}
+
+// earliestExpiry returns the first expiry among ts, nil when none expires.
+func earliestExpiry(ts ...*Tuple) *time.Time {
+	var first *time.Time
+	for _, t := range ts {
+		if t != nil && t.Expires != nil && (first == nil || t.Expires.Before(*first)) {
+			first = t.Expires
+		}
+	}
+	return first
+}
+
+func (e *explainer) walk(ctx context.Context, us UserSet, exp ExpandResult, depth int) (*ExplainNode, error) {
+	n := &ExplainNode{UserSet: us, Kind: e.kind(us)}
+	read, err := e.c.ReadWithTimestamp(ctx, e.ts, FilterByObject(us.Ns, us.Obj, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	// Subjects of the relation itself, and links to other objects through
+	// other relations (the tuplesets of tuple_to rewrites).
+	members := make(map[UserSet]*Tuple)
+	links := make(map[UserSet]*Tuple) // keyed by ⟨ns, obj, ""⟩
+	children := slices.Clone(exp.Usersets)
+	for i := range read.Tuples {
+		t := &read.Tuples[i]
+		if lapsedAt(*t, e.at) {
+			continue // check ignores it at this snapshot
+		}
+		switch {
+		case t.Rel == us.Rel && t.UserSet == nil:
+			if n.Grant == nil && e.grants(t.UserId) {
+				n.Grant = t
+			}
+		case t.Rel == us.Rel:
+			members[*t.UserSet] = t
+			if !slices.Contains(children, *t.UserSet) {
+				children = append(children, *t.UserSet)
+			}
+		case t.UserSet != nil:
+			key := UserSet{Ns: t.UserSet.Ns, Obj: t.UserSet.Obj}
+			if _, ok := links[key]; !ok {
+				links[key] = t
+			}
+		}
+	}
+	n.Matched = n.Grant != nil || slices.Contains(exp.UserIds, string(e.userId))
+
+	e.onPath[us] = true
+	defer delete(e.onPath, us)
+	for _, child := range children {
+		if child.Rel == RelUnspecified {
+			continue // an object pointer, not a userset
+		}
+		via := members[child]
+		if via == nil {
+			via = links[UserSet{Ns: child.Ns, Obj: child.Obj}]
+		}
+		if e.onPath[child] || depth >= DefaultExplainDepth {
+			n.Children = append(n.Children, &ExplainNode{UserSet: child, Kind: e.kind(child), Via: via, Expires: earliestExpiry(via), Truncated: true})
+			continue
+		}
+		cexp, err := e.c.ExpandWithTimestamp(ctx, child.Ns, child.Obj, child.Rel, e.ts)
+		if err != nil {
+			return nil, err
+		}
+		cn, err := e.walk(ctx, child, cexp, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		cn.Via = via
+		cn.Expires = earliestExpiry(via, cn.Grant)
+		n.Matched = n.Matched || cn.Matched
+		n.Children = append(n.Children, cn)
+	}
+	return n, nil
+}
+
+// WriteText renders the explanation as an indented tree, one userset per
+// line, + for usersets containing the user and - for the others:
+//
+//	allowed: doc:d1#viewer@u1 (principal u1) at AQAAAAAAAA== [client]
+//	+ doc:d1#viewer (union)
+//	  + doc:d1#editor (this) until 2030-01-15T12:00:00Z
+//	      grant doc:d1#editor@u1[expires=2030-01-15T12:00:00Z]
+//	  - folder:f1#viewer (union) via doc:d1#parent@folder:f1#...
+func (e Explanation) WriteText(w io.Writer) error {
+	decision := "denied"
+	if e.Ok {
+		decision = "allowed"
+	}
+	source := "client"
+	if e.Native {
+		source = "server"
+	}
+	subject := Tuple{Ns: e.Ns, Obj: e.Obj, Rel: e.Rel, UserId: e.UserId}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", decision, subject)
+	if e.Principal != "" {
+		fmt.Fprintf(&b, " (principal %s)", e.Principal)
+	}
+	fmt.Fprintf(&b, " at %s [%s]\n", e.Ts, source)
+	if e.Root != nil {
+		e.Root.writeText(&b, 0)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// String returns the WriteText rendering.
+func (e Explanation) String() string {
+	var b strings.Builder
+	_ = e.WriteText(&b)
+	return b.String()
+}
+
+func (n *ExplainNode) writeText(b *strings.Builder, depth int) {
+	indent := strings.Repeat("  ", depth)
+	mark := "-"
+	if n.Matched {
+		mark = "+"
+	}
+	fmt.Fprintf(b, "%s%s %s", indent, mark, n.UserSet)
+	if n.Kind != "" {
+		fmt.Fprintf(b, " (%s)", n.Kind)
+	}
+	if n.Via != nil {
+		fmt.Fprintf(b, " via %s", n.Via)
+	}
+	if n.Expires != nil {
+		fmt.Fprintf(b, " until %s", n.Expires.UTC().Format(time.RFC3339))
+	}
+	if n.Truncated {
+		b.WriteString(" …")
+	}
+	b.WriteByte('\n')
+	if n.Grant != nil {
+		fmt.Fprintf(b, "%s    grant %s\n", indent, n.Grant)
+	}
+	for _, c := range n.Children {
+		c.writeText(b, depth+1)
+	}
+}
+
+// explanationJSON and explainNodeJSON are the JSON renderings; usersets and
+// tuples use the canonical text form.
+type explanationJSON struct {
+	Ts        Timestamp    `json:"ts"`
+	Check     string       `json:"check"`
+	Ok        bool         `json:"ok"`
+	Principal Principal    `json:"principal,omitempty"`
+	Native    bool         `json:"native"`
+	Root      *ExplainNode `json:"root,omitempty"`
+}
+
+type explainNodeJSON struct {
+	UserSet   string         `json:"userset"`
+	Kind      string         `json:"kind,omitempty"`
+	Matched   bool           `json:"matched"`
+	Via       string         `json:"via,omitempty"`
+	Grant     string         `json:"grant,omitempty"`
+	Expires   *time.Time     `json:"expires,omitempty"`
+	Truncated bool           `json:"truncated,omitempty"`
+	Children  []*ExplainNode `json:"children,omitempty"`
+}
+
+// MarshalJSON renders the explanation with usersets and tuples in canonical
+// text form.
+func (e Explanation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(explanationJSON{
+		Ts:        e.Ts,
+		Check:     Tuple{Ns: e.Ns, Obj: e.Obj, Rel: e.Rel, UserId: e.UserId}.String(),
+		Ok:        e.Ok,
+		Principal: e.Principal,
+		Native:    e.Native,
+		Root:      e.Root,
+	})
+}
+
+// MarshalJSON renders the node with usersets and tuples in canonical text form.
+func (n *ExplainNode) MarshalJSON() ([]byte, error) {
+	j := explainNodeJSON{
+		UserSet:   n.UserSet.String(),
+		Kind:      n.Kind,
+		Matched:   n.Matched,
+		Expires:   n.Expires,
+		Truncated: n.Truncated,
+		Children:  n.Children,
+	}
+	if n.Via != nil {
+		j.Via = n.Via.String()
+	}
+	if n.Grant != nil {
+		j.Grant = n.Grant.String()
+	}
+	return json.Marshal(j)
+}
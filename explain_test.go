@@ -0,0 +1,139 @@
+package nioclient
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+)
+
+// explainFixture is doc:d1#viewer = this ∪ editor ∪ parent→viewer, with u1
+// granted viewer on the parent folder until 2030 and a lapsed editor grant.
+func explainFixture(t *testing.T) *checkAPI {
+	expires := time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC).Unix()
+	lapsed := time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC).Unix()
+	tuples := map[string][]*proto.Tuple{
+		"doc:d1": {{Ns: "doc", Obj: "d1", Rel: "parent",
+			User: &proto.Tuple_UserSet{UserSet: &proto.UserSet{Ns: "folder", Obj: "f1", Rel: "..."}}},
+			{Ns: "doc", Obj: "d1", Rel: "editor",
+				User: &proto.Tuple_UserId{UserId: "u1"}, Condition: &proto.Tuple_Expires{Expires: lapsed}}},
+		"folder:f1": {{Ns: "folder", Obj: "f1", Rel: "viewer",
+			User: &proto.Tuple_UserId{UserId: "u1"}, Condition: &proto.Tuple_Expires{Expires: expires}}},
+	}
+	expand := map[string]*proto.ExpandResponse{
+		"doc:d1#viewer": {Ts: "snap", UserIds: []string{"u1"}, Usersets: []*proto.UserSet{
+			{Ns: "doc", Obj: "d1", Rel: "editor"}, {Ns: "folder", Obj: "f1", Rel: "viewer"}}},
+		"doc:d1#editor":    {Ts: "snap"},
+		"folder:f1#viewer": {Ts: "snap", UserIds: []string{"u1"}},
+	}
+	expands := 0
+	fake := &fakeCheckService{
+		check: allowViewer,
+		expand: func(in *proto.ExpandRequest) (*proto.ExpandResponse, error) {
+			// The root expand picks the snapshot; every later one is pinned to it.
+			if expands++; expands > 1 && in.Ts != "snap" {
+				t.Errorf("expand %s:%s#%s at %q, want pinned snap", in.Ns, in.Obj, in.Rel, in.Ts)
+			}
+			return expand[in.Ns+":"+in.Obj+"#"+in.Rel], nil
+		},
+		read: func(in *proto.ReadRequest) (*proto.ReadResponse, error) {
+			set := in.TupleSets[0]
+			return &proto.ReadResponse{Ts: "snap", Tuples: tuples[set.Ns+":"+set.GetObjectSpec().Obj]}, nil
+		},
+	}
+	ns := &fakeNamespaceService{namespaces: []*proto.NamespaceMeta{
+		{Name: "doc", Relations: []*proto.RelationMeta{
+			{Name: "viewer", Kind: "union"}, {Name: "editor", Kind: "this"}, {Name: "parent", Kind: "this"}}},
+		{Name: "folder", Relations: []*proto.RelationMeta{{Name: "viewer", Kind: "this"}}},
+	}}
+	return &checkAPI{grpcClient: fake, nsClient: ns}
+}
+
+func TestCheckExplainClientSide(t *testing.T) {
+	c := explainFixture(t)
+	e, err := c.CheckExplain(t.Context(), "doc", "d1", "viewer", "u1", TimestampEmpty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Ok || e.Native || e.Ts != "snap" {
+		t.Fatalf("explanation = %+v", e)
+	}
+	root := e.Root
+	if !root.Matched || root.Kind != "union" || len(root.Children) != 2 {
+		t.Fatalf("root = %+v", root)
+	}
+	editor, folder := root.Children[0], root.Children[1]
+	if editor.Matched || editor.Via != nil || editor.Grant != nil {
+		t.Errorf("editor = %+v, want unmatched rewrite edge without the lapsed grant", editor)
+	}
+	if !folder.Matched || folder.Via == nil || folder.Via.Rel != "parent" {
+		t.Errorf("folder = %+v, want matched via the parent tuple", folder)
+	}
+	if folder.Grant == nil || folder.Grant.Expires == nil {
+		t.Fatalf("folder grant = %+v, want the expiring tuple", folder.Grant)
+	}
+	if folder.Expires == nil || !folder.Expires.Equal(*folder.Grant.Expires) {
+		t.Errorf("folder expires = %v, want the grant's expiry", folder.Expires)
+	}
+
+	text := e.String()
+	for _, want := range []string{
+		"allowed: doc:d1#viewer@u1 (principal u1) at snap [client]",
+		"+ doc:d1#viewer (union)",
+		"  - doc:d1#editor (this)",
+		"  + folder:f1#viewer (this) via doc:d1#parent@folder:f1#... until 2030-01-15T12:00:00Z",
+		"grant folder:f1#viewer@u1[expires=2030-01-15T12:00:00Z]",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestCheckExplainJSON(t *testing.T) {
+	c := explainFixture(t)
+	e, err := c.CheckExplain(t.Context(), "doc", "d1", "viewer", "u1", TimestampEmpty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Check string `json:"check"`
+		Root  struct {
+			Children []struct {
+				Via   string `json:"via"`
+				Grant string `json:"grant"`
+			} `json:"children"`
+		} `json:"root"`
+	}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Check != "doc:d1#viewer@u1" || got.Root.Children[1].Via != "doc:d1#parent@folder:f1#..." {
+		t.Fatalf("json = %s", raw)
+	}
+}
+
+func TestCheckExplainNative(t *testing.T) {
+	fake := &fakeCheckService{explain: func(in *proto.ExplainRequest) (*proto.ExplainResponse, error) {
+		return &proto.ExplainResponse{Ts: "snap", Ok: false, Root: &proto.ExplainNode{
+			Ns: in.Ns, Obj: in.Obj, Rel: in.Rel, Kind: "this",
+		}}, nil
+	}}
+	c := &checkAPI{grpcClient: fake}
+	e, err := c.CheckExplain(t.Context(), "doc", "d1", "viewer", "u2", TimestampEmpty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Ok || !e.Native || e.Root.UserSet.String() != "doc:d1#viewer" {
+		t.Fatalf("explanation = %+v", e)
+	}
+	if fake.count("expand") != 0 {
+		t.Fatal("native explain also walked client-side")
+	}
+}
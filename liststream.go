@@ -0,0 +1,145 @@
+package nioclient
+
+// Streaming List. ListWithTimestamp materialises every object id; ListIter
+// yields them as the server streams them (list_stream) and ListLimit stops
+// after N. Against servers without list_stream (UNIMPLEMENTED) the client
+// remembers the answer and serves both from the unary list RPC.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListIter iterates over the objects in ns userId has rel to, evaluated at a
+// snapshot at least as fresh as ts. Breaking out of the loop cancels the
+// stream. A failure yields an empty Obj and the error, then ends the
+// iteration.
+func (c *checkAPI) ListIter(ctx context.Context, ns Ns, rel Rel, userId UserId, ts Timestamp) iter.Seq2[Obj, error] {
+	return func(yield func(Obj, error) bool) {
+		stopped := false
+		_, err := c.listStream(ctx, ns, rel, userId, ts, 0, func(obj string) bool {
+			stopped = !yield(Obj(obj), nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield("", err)
+		}
+	}
+}
+
+// ListLimit lists at most limit objects in ns userId has rel to, evaluated at
+// a snapshot at least as fresh as ts. Which objects make the cut is up to the
+// server. limit <= 0 lists all objects, as ListWithTimestamp.
+func (c *checkAPI) ListLimit(ctx context.Context, ns Ns, rel Rel, userId UserId, ts Timestamp, limit int) (ListResult, error) {
+	if limit <= 0 {
+		return c.ListWithTimestamp(ctx, ns, rel, userId, ts)
+	}
+	objs := make([]string, 0, min(limit, 1024))
+	snap, err := c.listStream(ctx, ns, rel, userId, ts, limit, func(obj string) bool {
+		objs = append(objs, obj)
+		return len(objs) < limit
+	})
+	if err != nil {
+		return ListResult{}, err
+	}
+	return ListResult{Ts: snap, Objs: objs}, nil
+}
+
+// listStream calls emit for each listed object (at most limit when > 0) until
+// emit returns false, and returns the evaluation snapshot.
+func (c *checkAPI) listStream(ctx context.Context, ns Ns, rel Rel, userId UserId, ts Timestamp, limit int, emit func(obj string) bool) (Timestamp, error) {
+	if !c.listStreamUnsupported.Load() {
+		req := &proto.ListRequest{
+			Ns:     string(ns),
+			Rel:    string(rel),
+			UserId: string(userId),
+			Ts:     string(c.freshen(ctx, ts, PrincipalKey(userId))),
+			Limit:  uint32(max(limit, 0)),
+		}
+		snap, err := c.listStreamRPC(ctx, req, limit, emit)
+		if status.Code(err) != codes.Unimplemented {
+			if err != nil {
+				return "", fmt.Errorf("list %s: %w", listString(ns, rel, userId), rpcError(err))
+			}
+			return snap, nil
+		}
+		// Older check servers: remember and use unary list from now on.
+		c.listStreamUnsupported.Store(true)
+	}
+
+	res, err := c.ListWithTimestamp(ctx, ns, rel, userId, ts)
+	if err != nil {
+		return "", err
+	}
+	for i, obj := range res.Objs {
+		if limit > 0 && i == limit || !emit(obj) {
+			break
+		}
+	}
+	return res.Ts, nil
+}
+
+// listOpened is a list stream and its first response; first is nil for an
+// empty stream.
+type listOpened struct {
+	stream grpc.ServerStreamingClient[proto.ListResponse]
+	first  *proto.ListResponse
+}
+
+// listStreamRPC runs one list_stream call. Opening the stream up to the first
+// response is retried under the RetryPolicy; once objects were emitted a
+// failure is returned as is. Each call is reported once to WithObserveList.
+func (c *checkAPI) listStreamRPC(ctx context.Context, req *proto.ListRequest, limit int, emit func(obj string) bool) (ts Timestamp, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // ends the stream when emit stops early
+
+	begin := time.Now()
+	defer func() {
+		if c.observeList != nil && status.Code(err) != codes.Unimplemented {
+			c.observeList(Ns(req.Ns), Rel(req.Rel), UserId(req.UserId), time.Since(begin), err != nil)
+		}
+	}()
+
+	opened, err := retryCall(ctx, c.retry, "list_stream", false, func(ctx context.Context) (listOpened, error) {
+		stream, err := c.grpcClient.ListStream(ctx, req)
+		if err != nil {
+			return listOpened{}, err
+		}
+		first, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return listOpened{stream: stream}, nil
+		}
+		return listOpened{stream: stream, first: first}, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	n := 0
+	for res := opened.first; res != nil; {
+		ts = Timestamp(res.GetTs())
+		for _, obj := range res.GetObjs() {
+			if limit > 0 && n == limit || !emit(obj) {
+				return ts, nil
+			}
+			n++
+		}
+		res, err = opened.stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return ts, nil
+}
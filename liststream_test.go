@@ -0,0 +1,98 @@
+package nioclient
+
+import (
+	"slices"
+	"testing"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func chunks(ts string, objs ...[]string) []*proto.ListResponse {
+	res := make([]*proto.ListResponse, 0, len(objs))
+	for _, o := range objs {
+		res = append(res, &proto.ListResponse{Ts: ts, Objs: o})
+	}
+	return res
+}
+
+func TestListIterStreams(t *testing.T) {
+	fake := &fakeCheckService{listStream: func(in *proto.ListRequest) ([]*proto.ListResponse, error) {
+		if in.Limit != 0 {
+			t.Errorf("ListIter sent limit %d", in.Limit)
+		}
+		return chunks("snap", []string{"a", "b"}, []string{"c"}), nil
+	}}
+	c := &checkAPI{grpcClient: fake}
+
+	var got []string
+	for obj, err := range c.ListIter(t.Context(), "doc", "viewer", "u1", TimestampEmpty) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(obj))
+	}
+	if !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("objs = %v", got)
+	}
+}
+
+func TestListIterReportsMidStreamError(t *testing.T) {
+	fake := &fakeCheckService{listStream: func(*proto.ListRequest) ([]*proto.ListResponse, error) {
+		return chunks("snap", []string{"a"}), status.Error(codes.Internal, "boom")
+	}}
+	c := &checkAPI{grpcClient: fake}
+
+	var got []string
+	var last error
+	for obj, err := range c.ListIter(t.Context(), "doc", "viewer", "u1", TimestampEmpty) {
+		if err != nil {
+			last = err
+			continue
+		}
+		got = append(got, string(obj))
+	}
+	if !slices.Equal(got, []string{"a"}) || status.Code(last) != codes.Internal {
+		t.Fatalf("objs = %v, err = %v", got, last)
+	}
+}
+
+func TestListLimitStopsAfterN(t *testing.T) {
+	fake := &fakeCheckService{listStream: func(in *proto.ListRequest) ([]*proto.ListResponse, error) {
+		if in.Limit != 3 {
+			t.Errorf("limit = %d, want 3", in.Limit)
+		}
+		// A server ignoring the limit still only yields 3 objects.
+		return chunks("snap", []string{"a", "b"}, []string{"c", "d"}, []string{"e"}), nil
+	}}
+	c := &checkAPI{grpcClient: fake}
+
+	res, err := c.ListLimit(t.Context(), "doc", "viewer", "u1", TimestampEmpty, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(res.Objs, []string{"a", "b", "c"}) || res.Ts != "snap" {
+		t.Fatalf("res = %+v", res)
+	}
+}
+
+func TestListLimitFallsBackToUnaryList(t *testing.T) {
+	fake := &fakeCheckService{list: func(*proto.ListRequest) (*proto.ListResponse, error) {
+		return &proto.ListResponse{Ts: "snap", Objs: []string{"a", "b", "c"}}, nil
+	}}
+	c := &checkAPI{grpcClient: fake}
+
+	for range 2 {
+		res, err := c.ListLimit(t.Context(), "doc", "viewer", "u1", TimestampEmpty, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(res.Objs, []string{"a", "b"}) {
+			t.Fatalf("objs = %v", res.Objs)
+		}
+	}
+	if fake.count("list_stream") != 1 || fake.count("list") != 2 {
+		t.Fatalf("list_stream = %d, list = %d; want the stream tried once", fake.count("list_stream"), fake.count("list"))
+	}
+}
@@ -0,0 +1,132 @@
+package nioclient
+
+// Standard func(http.Handler) http.Handler middleware for routers that do not
+// fit Wrap's handler signature (chi, gorilla/mux, echo via its adapters).
+// Authenticate resolves the session once and stores the User in the request
+// context; Require performs the per-route Requires check of Wrap. Helpers
+// deeper in the call stack read the User with UserFromContext instead of
+// having it threaded through.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+type userCtxKey struct{}
+
+// ContextWithUser returns ctx carrying u, for UserFromContext.
+func ContextWithUser(ctx context.Context, u User) context.Context {
+	return context.WithValue(ctx, userCtxKey{}, u)
+}
+
+// UserFromContext returns the User of the request stored by Authenticate,
+// Require, Wrap or WrapHTTP. ok is false outside of them.
+func UserFromContext(ctx context.Context) (u User, ok bool) {
+	u, ok = ctx.Value(userCtxKey{}).(User)
+	return u, ok
+}
+
+// Middleware builds the Authenticate and Require middleware of a Wrapper.
+// WrapOptions apply as for Wrap (request memo, cookie zookies); route samples
+// are ignored.
+//
+//	mw := nioclient.NewMiddleware(client, nioclient.WithRequestMemo())
+//	r := chi.NewRouter()
+//	r.Use(mw.Authenticate)
+//	r.With(mw.Require(extractArticle)).Get("/articles/{id}", getArticle)
+type Middleware struct {
+	wd *wrapped
+}
+
+// NewMiddleware returns the Middleware of wrapper.
+func NewMiddleware(wrapper Wrapper, opts ...WrapOption) *Middleware {
+	return &Middleware{wd: newWrapped(wrapper, opts)}
+}
+
+// Authenticate resolves the request's credential (see WithCredentials) and
+// serves next with the User in the request context. Requests without a
+// credential continue as Anonymous; Require (or the handler) decides whether
+// that is enough. An unknown credential is redirected to signin (401 for API
+// clients) and a resolve error responds 500.
+//
+// The User is not bound to a resource: HasRel needs ns, obj and rel until
+// Require binds it to the route's resource. Its checks honour the check_ts
+// cookie and use the request context of Authenticate.
+func (m *Middleware) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		r = m.wd.withZookies(rw, r)
+		principal := Anonymous
+		userId, present, found, browser, err := m.wd.authenticate(r)
+		if err != nil {
+			if errMsg := errorHandlerFunc(fmt.Errorf("resolve: %w", err), rw, r); errMsg != "" {
+				log.Printf("%s %s: error=%s (resolve)", r.Method, r.RequestURI, errMsg)
+			}
+			return
+		}
+		if present {
+			if !found {
+				m.wd.unauthenticated(rw, r, browser)
+				return
+			}
+			principal = Principal(userId)
+		}
+		user := m.wd.newUser(r, "", "", principal)
+		if present {
+			user.userId = userId
+		}
+		next.ServeHTTP(rw, r.WithContext(ContextWithUser(r.Context(), user)))
+	})
+}
+
+// Require returns middleware checking the relation the extracted resource
+// Requires for the request method, like Wrap: an extract error responds 404,
+// an anonymous user is redirected to signin or answered 401 (unless the
+// resource is public) and a denied check responds 403. The User in the
+// context of next is bound to the resource, so HasRel(rel) checks it. Require
+// must run after Authenticate.
+func (m *Middleware) Require(extract HTTPExtractFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			u, _ := UserFromContext(r.Context())
+			authenticated, ok := u.(*user)
+			if !ok {
+				if errMsg := errorHandlerFunc(errors.New("require: no user in context, Authenticate must run first"), rw, r); errMsg != "" {
+					log.Printf("%s %s: error=%s (require)", r.Method, r.RequestURI, errMsg)
+				}
+				return
+			}
+			resource, err := extract(rw, r)
+			if err != nil {
+				if errMsg := errorHandlerFunc(notFound(err), rw, r); errMsg != "" {
+					log.Printf("%s %s: error=%s (extract)", r.Method, r.RequestURI, errMsg)
+				}
+				return
+			}
+			if resource == nil {
+				return
+			}
+			ns, obj, rel := resource.Requires(r.Method)
+
+			bound := *authenticated
+			bound.ns, bound.obj = ns, obj
+			if !bound.IsAuthenticated() {
+				if _, ok := resource.(publicResource); ok {
+					next.ServeHTTP(rw, r.WithContext(ContextWithUser(r.Context(), &bound)))
+					return
+				}
+				m.wd.unauthenticated(rw, r, true)
+				return
+			}
+			Observe(rw, r, func(w http.ResponseWriter) error {
+				if ok, err := m.wd.authorize(w, r, &bound, resource, rel, authenticated.userId); !ok {
+					return err
+				}
+				next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), &bound)))
+				return nil
+			})
+		})
+	}
+}
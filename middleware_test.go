@@ -0,0 +1,137 @@
+package nioclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// principalFromContext answers with the principal of the context User, as a
+// helper deep in the call stack would read it.
+func principalFromContext(rw http.ResponseWriter, r *http.Request) {
+	u, ok := UserFromContext(r.Context())
+	if !ok {
+		http.Error(rw, "no user", http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write([]byte("user:" + u.Principal()))
+}
+
+func TestAuthenticateStoresUserInContext(t *testing.T) {
+	w := &resolvingWrapper{resolvePrincipal: "P"}
+	h := NewMiddleware(w).Authenticate(http.HandlerFunc(principalFromContext))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, requestWithSession("tok"))
+	if rr.Code != http.StatusOK || rr.Body.String() != "user:P" {
+		t.Fatalf("status = %d, body = %q", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, requestWithSession(""))
+	if rr.Code != http.StatusOK || rr.Body.String() != "user:"+string(Anonymous) {
+		t.Fatalf("without session: status = %d, body = %q", rr.Code, rr.Body.String())
+	}
+	if w.checkCalls != 0 {
+		t.Fatalf("checkCalls = %d, want 0 without Require", w.checkCalls)
+	}
+}
+
+func TestRequireChecksAndBindsResource(t *testing.T) {
+	w := &resolvingWrapper{resolvePrincipal: "P"}
+	mw := NewMiddleware(w, WithRequestMemo())
+	mux := http.NewServeMux()
+	mux.Handle("GET /articles/{id}", mw.Require(extractArticleID)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		u, _ := UserFromContext(r.Context())
+		if ok, err := u.HasRel("article.get"); !ok || err != nil { // bound to article:1, memo hit
+			t.Errorf("HasRel = %v, %v", ok, err)
+		}
+		principalFromContext(rw, r)
+	})))
+	h := mw.Authenticate(mux)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, requestWithSession("tok"))
+	if rr.Code != http.StatusOK || rr.Body.String() != "user:P" {
+		t.Fatalf("status = %d, body = %q", rr.Code, rr.Body.String())
+	}
+	if w.checkCalls != 1 || w.lastCheckUserId != "P" {
+		t.Fatalf("checkCalls = %d (subject %q), want 1 for P", w.checkCalls, w.lastCheckUserId)
+	}
+}
+
+// teamWrapper grants through a group: the principal of a granted check is not
+// the subject checked.
+type teamWrapper struct{ resolvingWrapper }
+
+func (w *teamWrapper) Check(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId) (Principal, bool, error) {
+	_, ok, err := w.resolvingWrapper.Check(ctx, ns, obj, rel, userId)
+	return "team:eng", ok, err
+}
+
+func (w *teamWrapper) CheckWithTimestamp(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId, _ Timestamp) (Principal, bool, error) {
+	return w.Check(ctx, ns, obj, rel, userId)
+}
+
+func TestRequireChecksResolvedUserId(t *testing.T) {
+	w := &teamWrapper{resolvingWrapper{resolvePrincipal: "P"}}
+	mw := NewMiddleware(w)
+	// The inner Require sees the User bound by the outer one, whose principal
+	// is the granting group; it must still check the resolved user.
+	require := mw.Require(extractArticleID)
+	mux := http.NewServeMux()
+	mux.Handle("GET /articles/{id}", require(require(http.HandlerFunc(principalFromContext))))
+	h := mw.Authenticate(mux)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, requestWithSession("tok"))
+	if rr.Code != http.StatusOK || rr.Body.String() != "user:team:eng" {
+		t.Fatalf("status = %d, body = %q", rr.Code, rr.Body.String())
+	}
+	if w.checkCalls != 2 || w.lastCheckUserId != "P" {
+		t.Fatalf("checkCalls = %d (subject %q), want 2 for P", w.checkCalls, w.lastCheckUserId)
+	}
+}
+
+func TestRequireRedirectsAnonymous(t *testing.T) {
+	w := &resolvingWrapper{prefix: "/app"}
+	mw := NewMiddleware(w)
+	h := mw.Authenticate(mw.Require(func(http.ResponseWriter, *http.Request) (Resource, error) {
+		return testResource{}, nil
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("handler called for anonymous user")
+	})))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, requestWithSession("unknown"))
+	if rr.Code != http.StatusSeeOther || !strings.HasPrefix(rr.Header().Get("Location"), "/app/signin?back=") {
+		t.Fatalf("status = %d, Location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+}
+
+func TestRequireWithoutAuthenticateIs500(t *testing.T) {
+	h := NewMiddleware(&resolvingWrapper{}).Require(extractArticleID)(http.HandlerFunc(principalFromContext))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, requestWithSession("tok"))
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rr.Code)
+	}
+}
+
+func TestWrapHTTPStoresUserInContext(t *testing.T) {
+	w := &resolvingWrapper{resolvePrincipal: "P"}
+	mux := http.NewServeMux()
+	mux.Handle("GET /articles/{id}", WrapHTTP(w, extractArticleID, func(rw http.ResponseWriter, r *http.Request, _ Resource, _ User) error {
+		principalFromContext(rw, r)
+		return nil
+	}))
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, requestWithSession("tok"))
+	if rr.Body.String() != "user:P" {
+		t.Fatalf("body = %q", rr.Body.String())
+	}
+}
@@ -0,0 +1,287 @@
+package nioclient
+
+// Per-route authorization policies. Resource.Requires names one ⟨ns, obj, rel⟩;
+// a PolicyResource can instead require an expression over several checks:
+//
+//	All(Check("project", p, "project.edit"), Check("org", o, "org.view"))
+//	Any(Check("project", p, "project.owner"), Check(NsIam, ObjRoot, RelRootAdmin))
+//
+// When the server has the native check_batch RPC (SessionClient against a
+// current check), Wrap sends all leaves in one batch and they are answered at
+// one zookie. Otherwise it checks the leaves in parallel through the User's
+// check (check_ts cookie, request memo), cancelling the checks a
+// short-circuited clause no longer needs. The clause that denied is reported
+// to WithPolicyObserver.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Policy is an authorization expression built with All, Any, Not and Check.
+type Policy interface {
+	String() string
+	eval(ctx context.Context, check checkLeaf) (ok bool, principal Principal, denied Policy, err error)
+	leaves(out []CheckItem) []CheckItem
+}
+
+// PolicyResource is a Resource whose routes require a Policy. Wrap evaluates
+// Policy(method) instead of the Requires triple when it is non-nil; Requires
+// still names the ⟨ns, obj⟩ the User is bound to.
+type PolicyResource interface {
+	Resource
+	Policy(method string) Policy
+}
+
+// PolicyDecision is the outcome of a route's policy. Denied is the clause that
+// decided a denial (nil when Ok); Ts is the zookie a native check_batch
+// answered every leaf at, and empty when the leaves were checked one by one.
+type PolicyDecision struct {
+	Policy Policy
+	Ok     bool
+	Denied Policy
+	Ts     Timestamp
+}
+
+// WithPolicyObserver reports the decision of every policy evaluated for the
+// routes it is applied to, e.g. to log which clause denied a request.
+func WithPolicyObserver(f func(r *http.Request, d PolicyDecision)) WrapOption {
+	return func(c *wrapConfig) { c.policyObserve = f }
+}
+
+// checkLeaf checks one leaf for the request's principal.
+type checkLeaf func(ctx context.Context, ns Ns, obj Obj, rel Rel) (principal Principal, ok bool, err error)
+
+type checkPolicy struct {
+	ns  Ns
+	obj Obj
+	rel Rel
+}
+
+// Check is the policy leaf granting when the principal has rel on ⟨ns, obj⟩.
+func Check(ns Ns, obj Obj, rel Rel) Policy {
+	return checkPolicy{ns: ns, obj: obj, rel: rel}
+}
+
+func (p checkPolicy) String() string {
+	return fmt.Sprintf("check(%s)", UserSet{Ns: p.ns, Obj: p.obj, Rel: p.rel})
+}
+
+func (p checkPolicy) eval(ctx context.Context, check checkLeaf) (bool, Principal, Policy, error) {
+	if p.rel == Impossible {
+		return false, "", p, nil
+	}
+	principal, ok, err := check(ctx, p.ns, p.obj, p.rel)
+	switch {
+	case err != nil:
+		return false, "", nil, err
+	case ok:
+		return true, principal, nil, nil
+	}
+	return false, "", p, nil
+}
+
+func (p checkPolicy) leaves(out []CheckItem) []CheckItem {
+	if p.rel == Impossible {
+		return out
+	}
+	return append(out, CheckItem{Ns: p.ns, Obj: p.obj, Rel: p.rel})
+}
+
+type notPolicy struct{ p Policy }
+
+// Not grants when p denies. An error of p is an error of Not. A grant of Not
+// binds no principal.
+func Not(p Policy) Policy {
+	return notPolicy{p: p}
+}
+
+func (n notPolicy) String() string { return "not(" + n.p.String() + ")" }
+
+func (n notPolicy) eval(ctx context.Context, check checkLeaf) (bool, Principal, Policy, error) {
+	ok, _, _, err := n.p.eval(ctx, check)
+	if err != nil {
+		return false, "", nil, err
+	}
+	if ok {
+		return false, "", n, nil
+	}
+	return true, "", nil, nil
+}
+
+func (n notPolicy) leaves(out []CheckItem) []CheckItem { return n.p.leaves(out) }
+
+type listPolicy struct {
+	all bool
+	ps  []Policy
+}
+
+// All grants when every p grants. It denies as soon as one p denies; the
+// denied clause is that p's. All() grants.
+func All(ps ...Policy) Policy {
+	return listPolicy{all: true, ps: ps}
+}
+
+// Any grants as soon as one p grants; the denied clause of Any is the Any
+// itself. Any() denies.
+func Any(ps ...Policy) Policy {
+	return listPolicy{ps: ps}
+}
+
+func (l listPolicy) String() string {
+	parts := make([]string, len(l.ps))
+	for i, p := range l.ps {
+		parts[i] = p.String()
+	}
+	op := "any"
+	if l.all {
+		op = "all"
+	}
+	return op + "(" + strings.Join(parts, ", ") + ")"
+}
+
+func (l listPolicy) leaves(out []CheckItem) []CheckItem {
+	for _, p := range l.ps {
+		out = p.leaves(out)
+	}
+	return out
+}
+
+// eval evaluates the clauses concurrently and stops at the first that decides
+// the list (denies for All, grants for Any), cancelling the others. Errors
+// only count when no clause decided. The principal of a grant is the one of
+// the granting clause for Any and of the first clause binding one for All.
+func (l listPolicy) eval(ctx context.Context, check checkLeaf) (bool, Principal, Policy, error) {
+	if len(l.ps) == 0 {
+		if l.all {
+			return true, "", nil, nil
+		}
+		return false, "", l, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		i         int
+		ok        bool
+		principal Principal
+		denied    Policy
+		err       error
+	}
+	results := make(chan result, len(l.ps))
+	for i, p := range l.ps {
+		go func() {
+			ok, principal, denied, err := p.eval(ctx, check)
+			results <- result{i: i, ok: ok, principal: principal, denied: denied, err: err}
+		}()
+	}
+	var errs []error
+	principals := make([]Principal, len(l.ps))
+	for range l.ps {
+		res := <-results
+		switch {
+		case res.err != nil:
+			errs = append(errs, res.err)
+		case l.all && !res.ok:
+			return false, "", res.denied, nil
+		case !l.all && res.ok:
+			return true, res.principal, nil, nil
+		}
+		principals[res.i] = res.principal
+	}
+	if len(errs) > 0 {
+		return false, "", nil, errors.Join(errs...)
+	}
+	if l.all {
+		for _, p := range principals {
+			if p != "" {
+				return true, p, nil, nil
+			}
+		}
+		return true, "", nil, nil
+	}
+	return false, "", l, nil
+}
+
+// policyBatcher is implemented by wrappers that can check many items at one
+// snapshot, such as SessionClient. ok is false when the server cannot.
+type policyBatcher interface {
+	checkBatchNative(ctx context.Context, items []CheckItem, ts Timestamp) (res CheckBatchResult, ok bool, err error)
+}
+
+// authorize runs the route's authorization for userId: the resource's
+// policy when it has one, else the Requires relation rel. When granted it sets
+// the user's principal and returns true; otherwise it has responded 403.
+func (wd *wrapped) authorize(w http.ResponseWriter, r *http.Request, user *user, resource Resource, rel Rel, userId UserId) (bool, error) {
+	pr, ok := resource.(PolicyResource)
+	if !ok {
+		return gate(w, user, rel, userId)
+	}
+	policy := pr.Policy(r.Method)
+	if policy == nil {
+		return gate(w, user, rel, userId)
+	}
+
+	d, principal, err := wd.evalPolicy(r, user, policy, userId)
+	if err != nil {
+		return false, fmt.Errorf("policy %s: %w", policy, err)
+	}
+	if wd.cfg.policyObserve != nil {
+		wd.cfg.policyObserve(r, d)
+	}
+	if !d.Ok {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("Forbidden"))
+		return false, nil
+	}
+	user.principal = principal
+	return true, nil
+}
+
+// evalPolicy evaluates policy for userId. principal is the one the leaves
+// deciding a grant returned (never one under Not), or userId when they
+// returned none.
+//
+// A native check_batch answers all leaves in one call at the check_ts zookie.
+// That call bypasses user.check, so the leaves are neither read from nor
+// stored in the request memo. Without it the leaves go through user.check
+// and short-circuit.
+func (wd *wrapped) evalPolicy(r *http.Request, user *user, policy Policy, userId UserId) (PolicyDecision, Principal, error) {
+	d := PolicyDecision{Policy: policy}
+	check := func(ctx context.Context, ns Ns, obj Obj, rel Rel) (Principal, bool, error) {
+		return user.check(ctx, ns, obj, rel, userId)
+	}
+
+	items := policy.leaves(nil)
+	if batcher, ok := wd.wrapper.(policyBatcher); ok && len(items) > 0 {
+		for i := range items {
+			items[i].UserId = userId
+		}
+		ts, _ := checkTimestampOf(r)
+		res, native, err := batcher.checkBatchNative(user.ctx, items, ts)
+		if err != nil {
+			return d, "", err
+		}
+		if native {
+			d.Ts = res.Ts
+			answers := make(map[UserSet]CheckItemResult, len(items))
+			for i, it := range items {
+				answers[UserSet{Ns: it.Ns, Obj: it.Obj, Rel: it.Rel}] = res.Results[i]
+			}
+			check = func(_ context.Context, ns Ns, obj Obj, rel Rel) (Principal, bool, error) {
+				a := answers[UserSet{Ns: ns, Obj: obj, Rel: rel}]
+				return a.Principal, a.Ok, a.Err
+			}
+		}
+	}
+
+	ok, principal, denied, err := policy.eval(user.ctx, check)
+	d.Ok, d.Denied = ok, denied
+	if principal == "" {
+		principal = Principal(userId)
+	}
+	return d, principal, err
+}
@@ -0,0 +1,221 @@
+package nioclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// grantingWrapper grants the usersets in grants, to the principal in
+// principals when there is one; a userset in block waits for the check's
+// context to be cancelled.
+type grantingWrapper struct {
+	resolvingWrapper
+	grants     map[UserSet]bool
+	principals map[UserSet]Principal
+	block      map[UserSet]bool
+
+	mu      sync.Mutex
+	checked []UserSet
+}
+
+func (w *grantingWrapper) Check(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId) (Principal, bool, error) {
+	us := UserSet{Ns: ns, Obj: obj, Rel: rel}
+	w.mu.Lock()
+	w.checked = append(w.checked, us)
+	w.mu.Unlock()
+	if w.block[us] {
+		<-ctx.Done()
+		return "", false, ctx.Err()
+	}
+	if p, ok := w.principals[us]; ok {
+		return p, w.grants[us], nil
+	}
+	return Principal(userId), w.grants[us], nil
+}
+
+func (w *grantingWrapper) CheckWithTimestamp(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId, _ Timestamp) (Principal, bool, error) {
+	return w.Check(ctx, ns, obj, rel, userId)
+}
+
+// batchingWrapper also answers a native check_batch, at a fixed zookie; with
+// unsupported set it behaves like an older server without one.
+type batchingWrapper struct {
+	grantingWrapper
+	unsupported bool
+	batches     int
+}
+
+func (w *batchingWrapper) checkBatchNative(ctx context.Context, items []CheckItem, _ Timestamp) (CheckBatchResult, bool, error) {
+	if w.unsupported {
+		return CheckBatchResult{}, false, nil
+	}
+	w.batches++
+	res := CheckBatchResult{Ts: "ts-batch", Results: make([]CheckItemResult, len(items))}
+	for i, it := range items {
+		res.Results[i].Principal, res.Results[i].Ok, res.Results[i].Err = w.grantingWrapper.Check(ctx, it.Ns, it.Obj, it.Rel, it.UserId)
+	}
+	return res, true, nil
+}
+
+type policyResource struct{ policy Policy }
+
+func (policyResource) Requires(string) (Ns, Obj, Rel) { return "project", "p1", "project.edit" }
+func (r policyResource) Policy(string) Policy         { return r.policy }
+
+var (
+	projectEdit = Check("project", "p1", "project.edit")
+	orgView     = Check("org", "o1", "org.view")
+	projectOwn  = Check("project", "p1", "project.owner")
+	rootAdmin   = Check(NsIam, ObjRoot, "iam.admin")
+)
+
+func servePolicy(t *testing.T, w Wrapper, policy Policy) (int, PolicyDecision) {
+	t.Helper()
+	var d PolicyDecision
+	h := WrapHTTP(w, func(http.ResponseWriter, *http.Request) (Resource, error) {
+		return policyResource{policy: policy}, nil
+	}, func(rw http.ResponseWriter, _ *http.Request, _ Resource, _ User) error {
+		rw.WriteHeader(http.StatusOK)
+		return nil
+	}, WithPolicyObserver(func(_ *http.Request, got PolicyDecision) { d = got }))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, requestWithSession("tok"))
+	return rr.Code, d
+}
+
+func TestWrapPolicy(t *testing.T) {
+	grants := map[UserSet]bool{
+		{Ns: "project", Obj: "p1", Rel: "project.edit"}: true,
+		{Ns: NsIam, Obj: ObjRoot, Rel: "iam.admin"}:     true,
+	}
+	for _, tt := range []struct {
+		name       string
+		policy     Policy
+		wantStatus int
+		wantDenied string
+	}{
+		{"all denied by one clause", All(projectEdit, orgView), http.StatusForbidden, "check(org:o1#org.view)"},
+		{"any granted by one clause", Any(projectOwn, rootAdmin), http.StatusOK, ""},
+		{"any denied", Any(projectOwn, orgView), http.StatusForbidden, "any(check(project:p1#project.owner), check(org:o1#org.view))"},
+		{"not", All(projectEdit, Not(orgView)), http.StatusOK, ""},
+		{"not denied", Not(rootAdmin), http.StatusForbidden, "not(check(iam:root#iam.admin))"},
+		{"impossible", Check("project", "p1", Impossible), http.StatusForbidden, "check(project:p1#impossible)"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, w := range []Wrapper{
+				&grantingWrapper{resolvingWrapper: resolvingWrapper{resolvePrincipal: "P"}, grants: grants},
+				&batchingWrapper{grantingWrapper: grantingWrapper{resolvingWrapper: resolvingWrapper{resolvePrincipal: "P"}, grants: grants}},
+				&batchingWrapper{grantingWrapper: grantingWrapper{resolvingWrapper: resolvingWrapper{resolvePrincipal: "P"}, grants: grants}, unsupported: true},
+			} {
+				code, d := servePolicy(t, w, tt.policy)
+				if code != tt.wantStatus || d.Ok != (tt.wantStatus == http.StatusOK) {
+					t.Fatalf("%T: status = %d, decision = %+v", w, code, d)
+				}
+				denied := ""
+				if d.Denied != nil {
+					denied = d.Denied.String()
+				}
+				if denied != tt.wantDenied {
+					t.Fatalf("%T: denied = %q, want %q", w, denied, tt.wantDenied)
+				}
+				if b, ok := w.(*batchingWrapper); (!ok || b.unsupported) && d.Ts != "" {
+					t.Fatalf("%T: ts = %q, want empty without a batch", w, d.Ts)
+				}
+			}
+		})
+	}
+}
+
+func TestWrapPolicyBatchesAtOneZookie(t *testing.T) {
+	w := &batchingWrapper{grantingWrapper: grantingWrapper{
+		resolvingWrapper: resolvingWrapper{resolvePrincipal: "P"},
+		grants:           map[UserSet]bool{{Ns: "project", Obj: "p1", Rel: "project.edit"}: true, {Ns: "org", Obj: "o1", Rel: "org.view"}: true},
+	}}
+	code, d := servePolicy(t, w, All(projectEdit, orgView))
+	if code != http.StatusOK || w.batches != 1 || d.Ts != "ts-batch" {
+		t.Fatalf("status = %d, batches = %d, decision = %+v", code, w.batches, d)
+	}
+}
+
+func TestWrapPolicyShortCircuitCancels(t *testing.T) {
+	block := map[UserSet]bool{{Ns: "org", Obj: "o1", Rel: "org.view"}: true}
+	for _, w := range []Wrapper{
+		&grantingWrapper{resolvingWrapper: resolvingWrapper{resolvePrincipal: "P"}, block: block},
+		// Without a native check_batch a batcher checks leaf by leaf, too.
+		&batchingWrapper{grantingWrapper: grantingWrapper{resolvingWrapper: resolvingWrapper{resolvePrincipal: "P"}, block: block}, unsupported: true},
+	} {
+		done := make(chan int)
+		go func() {
+			code, _ := servePolicy(t, w, All(orgView, projectEdit)) // project.edit denies
+			done <- code
+		}()
+		select {
+		case code := <-done:
+			if code != http.StatusForbidden {
+				t.Fatalf("%T: status = %d, want 403", w, code)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%T: All did not short-circuit on the denied clause", w)
+		}
+	}
+}
+
+func TestWrapPolicyPrincipalOfDecidingLeaves(t *testing.T) {
+	x := UserSet{Ns: "project", Obj: "p1", Rel: "project.owner"}
+	y := UserSet{Ns: "org", Obj: "o1", Rel: "org.view"}
+	for _, batched := range []bool{false, true} {
+		w := &batchingWrapper{grantingWrapper: grantingWrapper{
+			resolvingWrapper: resolvingWrapper{resolvePrincipal: "P"},
+			grants:           map[UserSet]bool{x: true, y: true},
+			principals:       map[UserSet]Principal{x: "owner", y: "viewer"},
+		}, unsupported: !batched}
+		var got string
+		h := WrapHTTP(w, func(http.ResponseWriter, *http.Request) (Resource, error) {
+			return policyResource{policy: Any(orgView, Not(projectOwn))}, nil
+		}, func(rw http.ResponseWriter, _ *http.Request, _ Resource, u User) error {
+			got = u.Principal()
+			return nil
+		})
+		h.ServeHTTP(httptest.NewRecorder(), requestWithSession("tok"))
+		if got != "viewer" {
+			t.Fatalf("batched=%v: principal = %q, want the granting leaf's, not the negated one's", batched, got)
+		}
+	}
+}
+
+func TestPolicyErrorOnlyWithoutDecision(t *testing.T) {
+	boom := errors.New("boom")
+	check := func(_ context.Context, _ Ns, obj Obj, _ Rel) (Principal, bool, error) {
+		if obj == "o1" {
+			return "", false, boom
+		}
+		return "P", obj == "ok", nil
+	}
+	if ok, _, _, err := Any(orgView, Check("doc", "ok", "doc.get")).eval(t.Context(), check); !ok || err != nil {
+		t.Fatalf("any = %v, %v; want granted despite the failed clause", ok, err)
+	}
+	if _, _, _, err := All(orgView, Check("doc", "ok", "doc.get")).eval(t.Context(), check); !errors.Is(err, boom) {
+		t.Fatalf("all err = %v, want boom", err)
+	}
+}
+
+func TestVerifyRoutesChecksPolicyLeaves(t *testing.T) {
+	reg := &RouteRegistry{}
+	Wrap(&resolvingWrapper{}, extractTest, okHandler, reg.Sample("/projects/:id", policyResource{policy: All(projectEdit, Check("org", "o1", "org.veiw"))}))
+	schema := staticNamespaces{
+		{Name: "project", Relations: []RelationMeta{{Name: "project.edit", Kind: KindComputed}}},
+		{Name: "org", Relations: []RelationMeta{{Name: "org.view", Kind: KindComputed}}},
+	}
+	got, err := reg.Verify(t.Context(), schema, []string{http.MethodGet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Rel != "org.veiw" || !errors.Is(got[0].Err, ErrUnknownRelation) {
+		t.Fatalf("mismatches = %v", got)
+	}
+}
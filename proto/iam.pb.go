@@ -196,6 +196,512 @@ func (x *CheckResponse) GetOk() bool {
 	return false
 }
 
+// One item of a CheckBatchRequest; every item is evaluated at the batch ts.
+type CheckBatchItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ns            string                 `protobuf:"bytes,1,opt,name=ns,proto3" json:"ns,omitempty"`
+	Obj           string                 `protobuf:"bytes,2,opt,name=obj,proto3" json:"obj,omitempty"`
+	Rel           string                 `protobuf:"bytes,3,opt,name=rel,proto3" json:"rel,omitempty"`
+	UserId        string                 `protobuf:"bytes,4,opt,name=userId,proto3" json:"userId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckBatchItem) Reset() {
+	*x = CheckBatchItem{}
+	mi := &file_iam_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckBatchItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckBatchItem) ProtoMessage() {}
+
+func (x *CheckBatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_iam_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckBatchItem.ProtoReflect.Descriptor instead.
+func (*CheckBatchItem) Descriptor() ([]byte, []int) {
+	return file_iam_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CheckBatchItem) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+func (x *CheckBatchItem) GetObj() string {
+	if x != nil {
+		return x.Obj
+	}
+	return ""
+}
+
+func (x *CheckBatchItem) GetRel() string {
+	if x != nil {
+		return x.Rel
+	}
+	return ""
+}
+
+func (x *CheckBatchItem) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type CheckBatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Items []*CheckBatchItem      `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	// Opaque zookie (see CheckRequest.ts) shared by every item.
+	Ts            string `protobuf:"bytes,2,opt,name=ts,proto3" json:"ts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckBatchRequest) Reset() {
+	*x = CheckBatchRequest{}
+	mi := &file_iam_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckBatchRequest) ProtoMessage() {}
+
+func (x *CheckBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iam_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckBatchRequest.ProtoReflect.Descriptor instead.
+func (*CheckBatchRequest) Descriptor() ([]byte, []int) {
+	return file_iam_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CheckBatchRequest) GetItems() []*CheckBatchItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *CheckBatchRequest) GetTs() string {
+	if x != nil {
+		return x.Ts
+	}
+	return ""
+}
+
+// Per-item outcome, in request order. A non-zero code is a gRPC status code
+// for this item only; the other items are still answered.
+type CheckBatchResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Principal     *Principal             `protobuf:"bytes,1,opt,name=principal,proto3,oneof" json:"principal,omitempty"`
+	Ok            bool                   `protobuf:"varint,2,opt,name=ok,proto3" json:"ok,omitempty"`
+	Code          int32                  `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckBatchResult) Reset() {
+	*x = CheckBatchResult{}
+	mi := &file_iam_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckBatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckBatchResult) ProtoMessage() {}
+
+func (x *CheckBatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_iam_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckBatchResult.ProtoReflect.Descriptor instead.
+func (*CheckBatchResult) Descriptor() ([]byte, []int) {
+	return file_iam_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CheckBatchResult) GetPrincipal() *Principal {
+	if x != nil {
+		return x.Principal
+	}
+	return nil
+}
+
+func (x *CheckBatchResult) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *CheckBatchResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *CheckBatchResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type CheckBatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Opaque packed evaluation snapshot zookie used for every item.
+	Ts            string              `protobuf:"bytes,1,opt,name=ts,proto3" json:"ts,omitempty"`
+	Results       []*CheckBatchResult `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckBatchResponse) Reset() {
+	*x = CheckBatchResponse{}
+	mi := &file_iam_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckBatchResponse) ProtoMessage() {}
+
+func (x *CheckBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iam_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckBatchResponse.ProtoReflect.Descriptor instead.
+func (*CheckBatchResponse) Descriptor() ([]byte, []int) {
+	return file_iam_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CheckBatchResponse) GetTs() string {
+	if x != nil {
+		return x.Ts
+	}
+	return ""
+}
+
+func (x *CheckBatchResponse) GetResults() []*CheckBatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type ExplainRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Ns     string                 `protobuf:"bytes,1,opt,name=ns,proto3" json:"ns,omitempty"`
+	Obj    string                 `protobuf:"bytes,2,opt,name=obj,proto3" json:"obj,omitempty"`
+	Rel    string                 `protobuf:"bytes,3,opt,name=rel,proto3" json:"rel,omitempty"`
+	UserId string                 `protobuf:"bytes,4,opt,name=userId,proto3" json:"userId,omitempty"`
+	// Opaque zookie (see CheckRequest.ts).
+	Ts            string `protobuf:"bytes,5,opt,name=ts,proto3" json:"ts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainRequest) Reset() {
+	*x = ExplainRequest{}
+	mi := &file_iam_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainRequest) ProtoMessage() {}
+
+func (x *ExplainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iam_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainRequest.ProtoReflect.Descriptor instead.
+func (*ExplainRequest) Descriptor() ([]byte, []int) {
+	return file_iam_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ExplainRequest) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+func (x *ExplainRequest) GetObj() string {
+	if x != nil {
+		return x.Obj
+	}
+	return ""
+}
+
+func (x *ExplainRequest) GetRel() string {
+	if x != nil {
+		return x.Rel
+	}
+	return ""
+}
+
+func (x *ExplainRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ExplainRequest) GetTs() string {
+	if x != nil {
+		return x.Ts
+	}
+	return ""
+}
+
+// One userset visited while evaluating a check.
+type ExplainNode struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ns    string                 `protobuf:"bytes,1,opt,name=ns,proto3" json:"ns,omitempty"`
+	Obj   string                 `protobuf:"bytes,2,opt,name=obj,proto3" json:"obj,omitempty"`
+	Rel   string                 `protobuf:"bytes,3,opt,name=rel,proto3" json:"rel,omitempty"`
+	// Rewrite of the relation: this | computed | tuple_to | union.
+	Kind string `protobuf:"bytes,4,opt,name=kind,proto3" json:"kind,omitempty"`
+	// Whether the user is a member of this userset.
+	Matched bool `protobuf:"varint,5,opt,name=matched,proto3" json:"matched,omitempty"`
+	// Stored tuple linking this node to its parent; unset for the root and for
+	// pure rewrite edges.
+	Via *Tuple `protobuf:"bytes,6,opt,name=via,proto3,oneof" json:"via,omitempty"`
+	// Stored tuple granting the user directly on this userset.
+	Grant    *Tuple         `protobuf:"bytes,7,opt,name=grant,proto3,oneof" json:"grant,omitempty"`
+	Children []*ExplainNode `protobuf:"bytes,8,rep,name=children,proto3" json:"children,omitempty"`
+	// Evaluation stopped here (depth limit or cycle).
+	Truncated     bool `protobuf:"varint,9,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainNode) Reset() {
+	*x = ExplainNode{}
+	mi := &file_iam_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainNode) ProtoMessage() {}
+
+func (x *ExplainNode) ProtoReflect() protoreflect.Message {
+	mi := &file_iam_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainNode.ProtoReflect.Descriptor instead.
+func (*ExplainNode) Descriptor() ([]byte, []int) {
+	return file_iam_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ExplainNode) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+func (x *ExplainNode) GetObj() string {
+	if x != nil {
+		return x.Obj
+	}
+	return ""
+}
+
+func (x *ExplainNode) GetRel() string {
+	if x != nil {
+		return x.Rel
+	}
+	return ""
+}
+
+func (x *ExplainNode) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *ExplainNode) GetMatched() bool {
+	if x != nil {
+		return x.Matched
+	}
+	return false
+}
+
+func (x *ExplainNode) GetVia() *Tuple {
+	if x != nil {
+		return x.Via
+	}
+	return nil
+}
+
+func (x *ExplainNode) GetGrant() *Tuple {
+	if x != nil {
+		return x.Grant
+	}
+	return nil
+}
+
+func (x *ExplainNode) GetChildren() []*ExplainNode {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+func (x *ExplainNode) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type ExplainResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Opaque packed evaluation snapshot zookie.
+	Ts            string       `protobuf:"bytes,1,opt,name=ts,proto3" json:"ts,omitempty"`
+	Ok            bool         `protobuf:"varint,2,opt,name=ok,proto3" json:"ok,omitempty"`
+	Principal     *Principal   `protobuf:"bytes,3,opt,name=principal,proto3,oneof" json:"principal,omitempty"`
+	Root          *ExplainNode `protobuf:"bytes,4,opt,name=root,proto3" json:"root,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainResponse) Reset() {
+	*x = ExplainResponse{}
+	mi := &file_iam_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainResponse) ProtoMessage() {}
+
+func (x *ExplainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iam_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainResponse.ProtoReflect.Descriptor instead.
+func (*ExplainResponse) Descriptor() ([]byte, []int) {
+	return file_iam_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ExplainResponse) GetTs() string {
+	if x != nil {
+		return x.Ts
+	}
+	return ""
+}
+
+func (x *ExplainResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *ExplainResponse) GetPrincipal() *Principal {
+	if x != nil {
+		return x.Principal
+	}
+	return nil
+}
+
+func (x *ExplainResponse) GetRoot() *ExplainNode {
+	if x != nil {
+		return x.Root
+	}
+	return nil
+}
+
 type ContentChangeCheckRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Ns            string                 `protobuf:"bytes,1,opt,name=ns,proto3" json:"ns,omitempty"`
@@ -208,7 +714,7 @@ type ContentChangeCheckRequest struct {
 
 func (x *ContentChangeCheckRequest) Reset() {
 	*x = ContentChangeCheckRequest{}
-	mi := &file_iam_proto_msgTypes[3]
+	mi := &file_iam_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -220,7 +726,7 @@ func (x *ContentChangeCheckRequest) String() string {
 func (*ContentChangeCheckRequest) ProtoMessage() {}
 
 func (x *ContentChangeCheckRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[3]
+	mi := &file_iam_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -233,7 +739,7 @@ func (x *ContentChangeCheckRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ContentChangeCheckRequest.ProtoReflect.Descriptor instead.
 func (*ContentChangeCheckRequest) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{3}
+	return file_iam_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ContentChangeCheckRequest) GetNs() string {
@@ -275,7 +781,7 @@ type ContentChangeCheckResponse struct {
 
 func (x *ContentChangeCheckResponse) Reset() {
 	*x = ContentChangeCheckResponse{}
-	mi := &file_iam_proto_msgTypes[4]
+	mi := &file_iam_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -287,7 +793,7 @@ func (x *ContentChangeCheckResponse) String() string {
 func (*ContentChangeCheckResponse) ProtoMessage() {}
 
 func (x *ContentChangeCheckResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[4]
+	mi := &file_iam_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -300,7 +806,7 @@ func (x *ContentChangeCheckResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ContentChangeCheckResponse.ProtoReflect.Descriptor instead.
 func (*ContentChangeCheckResponse) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{4}
+	return file_iam_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ContentChangeCheckResponse) GetOk() bool {
@@ -323,14 +829,16 @@ type ListRequest struct {
 	Rel    string                 `protobuf:"bytes,3,opt,name=rel,proto3" json:"rel,omitempty"`
 	UserId string                 `protobuf:"bytes,4,opt,name=userId,proto3" json:"userId,omitempty"`
 	// Opaque zookie (see CheckRequest.ts).
-	Ts            string `protobuf:"bytes,5,opt,name=ts,proto3" json:"ts,omitempty"`
+	Ts string `protobuf:"bytes,5,opt,name=ts,proto3" json:"ts,omitempty"`
+	// Maximum objects to return; 0 = all.
+	Limit         uint32 `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListRequest) Reset() {
 	*x = ListRequest{}
-	mi := &file_iam_proto_msgTypes[5]
+	mi := &file_iam_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -342,7 +850,7 @@ func (x *ListRequest) String() string {
 func (*ListRequest) ProtoMessage() {}
 
 func (x *ListRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[5]
+	mi := &file_iam_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -355,7 +863,7 @@ func (x *ListRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
 func (*ListRequest) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{5}
+	return file_iam_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ListRequest) GetNs() string {
@@ -386,6 +894,15 @@ func (x *ListRequest) GetTs() string {
 	return ""
 }
 
+func (x *ListRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Also the message of list_stream, where each response carries the next chunk
+// of objs and every chunk has the same ts.
 type ListResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	Objs  []string               `protobuf:"bytes,1,rep,name=objs,proto3" json:"objs,omitempty"`
@@ -398,7 +915,7 @@ type ListResponse struct {
 
 func (x *ListResponse) Reset() {
 	*x = ListResponse{}
-	mi := &file_iam_proto_msgTypes[6]
+	mi := &file_iam_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -410,7 +927,7 @@ func (x *ListResponse) String() string {
 func (*ListResponse) ProtoMessage() {}
 
 func (x *ListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[6]
+	mi := &file_iam_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -423,7 +940,7 @@ func (x *ListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
 func (*ListResponse) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{6}
+	return file_iam_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ListResponse) GetObjs() []string {
@@ -453,7 +970,7 @@ type ExpandRequest struct {
 
 func (x *ExpandRequest) Reset() {
 	*x = ExpandRequest{}
-	mi := &file_iam_proto_msgTypes[7]
+	mi := &file_iam_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -465,7 +982,7 @@ func (x *ExpandRequest) String() string {
 func (*ExpandRequest) ProtoMessage() {}
 
 func (x *ExpandRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[7]
+	mi := &file_iam_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -478,7 +995,7 @@ func (x *ExpandRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExpandRequest.ProtoReflect.Descriptor instead.
 func (*ExpandRequest) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{7}
+	return file_iam_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *ExpandRequest) GetNs() string {
@@ -521,7 +1038,7 @@ type ExpandResponse struct {
 
 func (x *ExpandResponse) Reset() {
 	*x = ExpandResponse{}
-	mi := &file_iam_proto_msgTypes[8]
+	mi := &file_iam_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -533,7 +1050,7 @@ func (x *ExpandResponse) String() string {
 func (*ExpandResponse) ProtoMessage() {}
 
 func (x *ExpandResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[8]
+	mi := &file_iam_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -546,7 +1063,7 @@ func (x *ExpandResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExpandResponse.ProtoReflect.Descriptor instead.
 func (*ExpandResponse) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{8}
+	return file_iam_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ExpandResponse) GetTs() string {
@@ -581,7 +1098,7 @@ type UserSet struct {
 
 func (x *UserSet) Reset() {
 	*x = UserSet{}
-	mi := &file_iam_proto_msgTypes[9]
+	mi := &file_iam_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -593,7 +1110,7 @@ func (x *UserSet) String() string {
 func (*UserSet) ProtoMessage() {}
 
 func (x *UserSet) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[9]
+	mi := &file_iam_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -606,7 +1123,7 @@ func (x *UserSet) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserSet.ProtoReflect.Descriptor instead.
 func (*UserSet) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{9}
+	return file_iam_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *UserSet) GetNs() string {
@@ -650,7 +1167,7 @@ type Tuple struct {
 
 func (x *Tuple) Reset() {
 	*x = Tuple{}
-	mi := &file_iam_proto_msgTypes[10]
+	mi := &file_iam_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -662,7 +1179,7 @@ func (x *Tuple) String() string {
 func (*Tuple) ProtoMessage() {}
 
 func (x *Tuple) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[10]
+	mi := &file_iam_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -675,7 +1192,7 @@ func (x *Tuple) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Tuple.ProtoReflect.Descriptor instead.
 func (*Tuple) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{10}
+	return file_iam_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *Tuple) GetNs() string {
@@ -781,7 +1298,7 @@ type TupleSet struct {
 
 func (x *TupleSet) Reset() {
 	*x = TupleSet{}
-	mi := &file_iam_proto_msgTypes[11]
+	mi := &file_iam_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -793,7 +1310,7 @@ func (x *TupleSet) String() string {
 func (*TupleSet) ProtoMessage() {}
 
 func (x *TupleSet) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[11]
+	mi := &file_iam_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -806,7 +1323,7 @@ func (x *TupleSet) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TupleSet.ProtoReflect.Descriptor instead.
 func (*TupleSet) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{11}
+	return file_iam_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *TupleSet) GetNs() string {
@@ -875,15 +1392,20 @@ func (*TupleSet_UsersetSpec) isTupleSet_Spec() {}
 type ReadRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Optional opaque packed zookie (see CheckRequest.ts).
-	Ts            *string     `protobuf:"bytes,1,opt,name=ts,proto3,oneof" json:"ts,omitempty"`
-	TupleSets     []*TupleSet `protobuf:"bytes,2,rep,name=tuple_sets,json=tupleSets,proto3" json:"tuple_sets,omitempty"`
+	Ts        *string     `protobuf:"bytes,1,opt,name=ts,proto3,oneof" json:"ts,omitempty"`
+	TupleSets []*TupleSet `protobuf:"bytes,2,rep,name=tuple_sets,json=tupleSets,proto3" json:"tuple_sets,omitempty"`
+	// Maximum tuples per response; 0 = unpaginated (all tuples).
+	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous page; empty for the first page. The
+	// token pins the snapshot of the first page.
+	PageToken     string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReadRequest) Reset() {
 	*x = ReadRequest{}
-	mi := &file_iam_proto_msgTypes[12]
+	mi := &file_iam_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -895,7 +1417,7 @@ func (x *ReadRequest) String() string {
 func (*ReadRequest) ProtoMessage() {}
 
 func (x *ReadRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[12]
+	mi := &file_iam_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -908,7 +1430,7 @@ func (x *ReadRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadRequest.ProtoReflect.Descriptor instead.
 func (*ReadRequest) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{12}
+	return file_iam_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *ReadRequest) GetTs() string {
@@ -925,18 +1447,34 @@ func (x *ReadRequest) GetTupleSets() []*TupleSet {
 	return nil
 }
 
+func (x *ReadRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ReadRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
 type ReadResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Opaque packed evaluation snapshot zookie.
-	Ts            string   `protobuf:"bytes,1,opt,name=ts,proto3" json:"ts,omitempty"`
-	Tuples        []*Tuple `protobuf:"bytes,2,rep,name=tuples,proto3" json:"tuples,omitempty"`
+	Ts     string   `protobuf:"bytes,1,opt,name=ts,proto3" json:"ts,omitempty"`
+	Tuples []*Tuple `protobuf:"bytes,2,rep,name=tuples,proto3" json:"tuples,omitempty"`
+	// Token for the next page; empty on the last page.
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReadResponse) Reset() {
 	*x = ReadResponse{}
-	mi := &file_iam_proto_msgTypes[13]
+	mi := &file_iam_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -948,7 +1486,7 @@ func (x *ReadResponse) String() string {
 func (*ReadResponse) ProtoMessage() {}
 
 func (x *ReadResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[13]
+	mi := &file_iam_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -961,7 +1499,7 @@ func (x *ReadResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadResponse.ProtoReflect.Descriptor instead.
 func (*ReadResponse) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{13}
+	return file_iam_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *ReadResponse) GetTs() string {
@@ -978,6 +1516,13 @@ func (x *ReadResponse) GetTuples() []*Tuple {
 	return nil
 }
 
+func (x *ReadResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
 type WriteRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Optional precondition zookie (OCC); opaque pack format.
@@ -990,7 +1535,7 @@ type WriteRequest struct {
 
 func (x *WriteRequest) Reset() {
 	*x = WriteRequest{}
-	mi := &file_iam_proto_msgTypes[14]
+	mi := &file_iam_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1002,7 +1547,7 @@ func (x *WriteRequest) String() string {
 func (*WriteRequest) ProtoMessage() {}
 
 func (x *WriteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[14]
+	mi := &file_iam_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1015,7 +1560,7 @@ func (x *WriteRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WriteRequest.ProtoReflect.Descriptor instead.
 func (*WriteRequest) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{14}
+	return file_iam_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *WriteRequest) GetTs() string {
@@ -1049,7 +1594,7 @@ type WriteResponse struct {
 
 func (x *WriteResponse) Reset() {
 	*x = WriteResponse{}
-	mi := &file_iam_proto_msgTypes[15]
+	mi := &file_iam_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1061,7 +1606,7 @@ func (x *WriteResponse) String() string {
 func (*WriteResponse) ProtoMessage() {}
 
 func (x *WriteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[15]
+	mi := &file_iam_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1074,7 +1619,7 @@ func (x *WriteResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WriteResponse.ProtoReflect.Descriptor instead.
 func (*WriteResponse) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{15}
+	return file_iam_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *WriteResponse) GetTs() string {
@@ -1095,7 +1640,7 @@ type WatchRequest struct {
 
 func (x *WatchRequest) Reset() {
 	*x = WatchRequest{}
-	mi := &file_iam_proto_msgTypes[16]
+	mi := &file_iam_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1107,7 +1652,7 @@ func (x *WatchRequest) String() string {
 func (*WatchRequest) ProtoMessage() {}
 
 func (x *WatchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[16]
+	mi := &file_iam_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1120,7 +1665,7 @@ func (x *WatchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
 func (*WatchRequest) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{16}
+	return file_iam_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *WatchRequest) GetNs() string {
@@ -1153,7 +1698,7 @@ type WatchResponse struct {
 
 func (x *WatchResponse) Reset() {
 	*x = WatchResponse{}
-	mi := &file_iam_proto_msgTypes[17]
+	mi := &file_iam_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1165,7 +1710,7 @@ func (x *WatchResponse) String() string {
 func (*WatchResponse) ProtoMessage() {}
 
 func (x *WatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[17]
+	mi := &file_iam_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1178,7 +1723,7 @@ func (x *WatchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
 func (*WatchResponse) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{17}
+	return file_iam_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *WatchResponse) GetTs() string {
@@ -1206,7 +1751,7 @@ type Update struct {
 
 func (x *Update) Reset() {
 	*x = Update{}
-	mi := &file_iam_proto_msgTypes[18]
+	mi := &file_iam_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1218,7 +1763,7 @@ func (x *Update) String() string {
 func (*Update) ProtoMessage() {}
 
 func (x *Update) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[18]
+	mi := &file_iam_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1231,7 +1776,7 @@ func (x *Update) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Update.ProtoReflect.Descriptor instead.
 func (*Update) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{18}
+	return file_iam_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *Update) GetTuple() *Tuple {
@@ -1260,7 +1805,7 @@ type RelationMeta struct {
 
 func (x *RelationMeta) Reset() {
 	*x = RelationMeta{}
-	mi := &file_iam_proto_msgTypes[19]
+	mi := &file_iam_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1272,7 +1817,7 @@ func (x *RelationMeta) String() string {
 func (*RelationMeta) ProtoMessage() {}
 
 func (x *RelationMeta) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[19]
+	mi := &file_iam_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1285,7 +1830,7 @@ func (x *RelationMeta) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RelationMeta.ProtoReflect.Descriptor instead.
 func (*RelationMeta) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{19}
+	return file_iam_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *RelationMeta) GetName() string {
@@ -1312,7 +1857,7 @@ type NamespaceMeta struct {
 
 func (x *NamespaceMeta) Reset() {
 	*x = NamespaceMeta{}
-	mi := &file_iam_proto_msgTypes[20]
+	mi := &file_iam_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1324,7 +1869,7 @@ func (x *NamespaceMeta) String() string {
 func (*NamespaceMeta) ProtoMessage() {}
 
 func (x *NamespaceMeta) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[20]
+	mi := &file_iam_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1337,7 +1882,7 @@ func (x *NamespaceMeta) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NamespaceMeta.ProtoReflect.Descriptor instead.
 func (*NamespaceMeta) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{20}
+	return file_iam_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *NamespaceMeta) GetName() string {
@@ -1363,7 +1908,7 @@ type ListNamespacesResponse struct {
 
 func (x *ListNamespacesResponse) Reset() {
 	*x = ListNamespacesResponse{}
-	mi := &file_iam_proto_msgTypes[21]
+	mi := &file_iam_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1375,7 +1920,7 @@ func (x *ListNamespacesResponse) String() string {
 func (*ListNamespacesResponse) ProtoMessage() {}
 
 func (x *ListNamespacesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[21]
+	mi := &file_iam_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1388,7 +1933,7 @@ func (x *ListNamespacesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListNamespacesResponse.ProtoReflect.Descriptor instead.
 func (*ListNamespacesResponse) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{21}
+	return file_iam_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *ListNamespacesResponse) GetNamespaces() []*NamespaceMeta {
@@ -1413,7 +1958,7 @@ type TupleSet_TupleSpec struct {
 
 func (x *TupleSet_TupleSpec) Reset() {
 	*x = TupleSet_TupleSpec{}
-	mi := &file_iam_proto_msgTypes[22]
+	mi := &file_iam_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1425,7 +1970,7 @@ func (x *TupleSet_TupleSpec) String() string {
 func (*TupleSet_TupleSpec) ProtoMessage() {}
 
 func (x *TupleSet_TupleSpec) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[22]
+	mi := &file_iam_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1438,7 +1983,7 @@ func (x *TupleSet_TupleSpec) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TupleSet_TupleSpec.ProtoReflect.Descriptor instead.
 func (*TupleSet_TupleSpec) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{11, 0}
+	return file_iam_proto_rawDescGZIP(), []int{18, 0}
 }
 
 func (x *TupleSet_TupleSpec) GetObj() string {
@@ -1506,7 +2051,7 @@ type TupleSet_ObjectSpec struct {
 
 func (x *TupleSet_ObjectSpec) Reset() {
 	*x = TupleSet_ObjectSpec{}
-	mi := &file_iam_proto_msgTypes[23]
+	mi := &file_iam_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1518,7 +2063,7 @@ func (x *TupleSet_ObjectSpec) String() string {
 func (*TupleSet_ObjectSpec) ProtoMessage() {}
 
 func (x *TupleSet_ObjectSpec) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[23]
+	mi := &file_iam_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1531,7 +2076,7 @@ func (x *TupleSet_ObjectSpec) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TupleSet_ObjectSpec.ProtoReflect.Descriptor instead.
 func (*TupleSet_ObjectSpec) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{11, 1}
+	return file_iam_proto_rawDescGZIP(), []int{18, 1}
 }
 
 func (x *TupleSet_ObjectSpec) GetObj() string {
@@ -1565,7 +2110,7 @@ type TupleSet_UserSetSpec struct {
 
 func (x *TupleSet_UserSetSpec) Reset() {
 	*x = TupleSet_UserSetSpec{}
-	mi := &file_iam_proto_msgTypes[24]
+	mi := &file_iam_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1577,7 +2122,7 @@ func (x *TupleSet_UserSetSpec) String() string {
 func (*TupleSet_UserSetSpec) ProtoMessage() {}
 
 func (x *TupleSet_UserSetSpec) ProtoReflect() protoreflect.Message {
-	mi := &file_iam_proto_msgTypes[24]
+	mi := &file_iam_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1590,7 +2135,7 @@ func (x *TupleSet_UserSetSpec) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TupleSet_UserSetSpec.ProtoReflect.Descriptor instead.
 func (*TupleSet_UserSetSpec) Descriptor() ([]byte, []int) {
-	return file_iam_proto_rawDescGZIP(), []int{11, 2}
+	return file_iam_proto_rawDescGZIP(), []int{18, 2}
 }
 
 func (x *TupleSet_UserSetSpec) GetUser() isTupleSet_UserSetSpec_User {
@@ -1658,6 +2203,49 @@ const file_iam_proto_rawDesc = "" +
 	"\tprincipal\x18\x01 \x01(\v2\r.am.PrincipalH\x00R\tprincipal\x88\x01\x01\x12\x0e\n" +
 	"\x02ok\x18\x02 \x01(\bR\x02okB\f\n" +
 	"\n" +
+	"_principal\"\\\n" +
+	"\x0eCheckBatchItem\x12\x0e\n" +
+	"\x02ns\x18\x01 \x01(\tR\x02ns\x12\x10\n" +
+	"\x03obj\x18\x02 \x01(\tR\x03obj\x12\x10\n" +
+	"\x03rel\x18\x03 \x01(\tR\x03rel\x12\x16\n" +
+	"\x06userId\x18\x04 \x01(\tR\x06userId\"M\n" +
+	"\x11CheckBatchRequest\x12(\n" +
+	"\x05items\x18\x01 \x03(\v2\x12.am.CheckBatchItemR\x05items\x12\x0e\n" +
+	"\x02ts\x18\x02 \x01(\tR\x02ts\"\x90\x01\n" +
+	"\x10CheckBatchResult\x120\n" +
+	"\tprincipal\x18\x01 \x01(\v2\r.am.PrincipalH\x00R\tprincipal\x88\x01\x01\x12\x0e\n" +
+	"\x02ok\x18\x02 \x01(\bR\x02ok\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessageB\f\n" +
+	"\n" +
+	"_principal\"T\n" +
+	"\x12CheckBatchResponse\x12\x0e\n" +
+	"\x02ts\x18\x01 \x01(\tR\x02ts\x12.\n" +
+	"\aresults\x18\x02 \x03(\v2\x14.am.CheckBatchResultR\aresults\"l\n" +
+	"\x0eExplainRequest\x12\x0e\n" +
+	"\x02ns\x18\x01 \x01(\tR\x02ns\x12\x10\n" +
+	"\x03obj\x18\x02 \x01(\tR\x03obj\x12\x10\n" +
+	"\x03rel\x18\x03 \x01(\tR\x03rel\x12\x16\n" +
+	"\x06userId\x18\x04 \x01(\tR\x06userId\x12\x0e\n" +
+	"\x02ts\x18\x05 \x01(\tR\x02ts\"\x94\x02\n" +
+	"\vExplainNode\x12\x0e\n" +
+	"\x02ns\x18\x01 \x01(\tR\x02ns\x12\x10\n" +
+	"\x03obj\x18\x02 \x01(\tR\x03obj\x12\x10\n" +
+	"\x03rel\x18\x03 \x01(\tR\x03rel\x12\x12\n" +
+	"\x04kind\x18\x04 \x01(\tR\x04kind\x12\x18\n" +
+	"\amatched\x18\x05 \x01(\bR\amatched\x12 \n" +
+	"\x03via\x18\x06 \x01(\v2\t.am.TupleH\x00R\x03via\x88\x01\x01\x12$\n" +
+	"\x05grant\x18\a \x01(\v2\t.am.TupleH\x01R\x05grant\x88\x01\x01\x12+\n" +
+	"\bchildren\x18\b \x03(\v2\x0f.am.ExplainNodeR\bchildren\x12\x1c\n" +
+	"\ttruncated\x18\t \x01(\bR\ttruncatedB\x06\n" +
+	"\x04_viaB\b\n" +
+	"\x06_grant\"\x96\x01\n" +
+	"\x0fExplainResponse\x12\x0e\n" +
+	"\x02ts\x18\x01 \x01(\tR\x02ts\x12\x0e\n" +
+	"\x02ok\x18\x02 \x01(\bR\x02ok\x120\n" +
+	"\tprincipal\x18\x03 \x01(\v2\r.am.PrincipalH\x00R\tprincipal\x88\x01\x01\x12#\n" +
+	"\x04root\x18\x04 \x01(\v2\x0f.am.ExplainNodeR\x04rootB\f\n" +
+	"\n" +
 	"_principal\"g\n" +
 	"\x19ContentChangeCheckRequest\x12\x0e\n" +
 	"\x02ns\x18\x01 \x01(\tR\x02ns\x12\x10\n" +
@@ -1666,12 +2254,13 @@ const file_iam_proto_rawDesc = "" +
 	"\x06userId\x18\x04 \x01(\tR\x06userId\"<\n" +
 	"\x1aContentChangeCheckResponse\x12\x0e\n" +
 	"\x02ok\x18\x02 \x01(\bR\x02ok\x12\x0e\n" +
-	"\x02ts\x18\x03 \x01(\tR\x02ts\"W\n" +
+	"\x02ts\x18\x03 \x01(\tR\x02ts\"m\n" +
 	"\vListRequest\x12\x0e\n" +
 	"\x02ns\x18\x01 \x01(\tR\x02ns\x12\x10\n" +
 	"\x03rel\x18\x03 \x01(\tR\x03rel\x12\x16\n" +
 	"\x06userId\x18\x04 \x01(\tR\x06userId\x12\x0e\n" +
-	"\x02ts\x18\x05 \x01(\tR\x02ts\"2\n" +
+	"\x02ts\x18\x05 \x01(\tR\x02ts\x12\x14\n" +
+	"\x05limit\x18\x06 \x01(\rR\x05limit\"2\n" +
 	"\fListResponse\x12\x12\n" +
 	"\x04objs\x18\x01 \x03(\tR\x04objs\x12\x0e\n" +
 	"\x02ts\x18\x02 \x01(\tR\x02ts\"S\n" +
@@ -1721,15 +2310,19 @@ const file_iam_proto_rawDesc = "" +
 	"\x03rel\x18\x03 \x01(\tH\x01R\x03rel\x88\x01\x01B\x06\n" +
 	"\x04userB\x06\n" +
 	"\x04_relB\x06\n" +
-	"\x04spec\"V\n" +
+	"\x04spec\"\x92\x01\n" +
 	"\vReadRequest\x12\x13\n" +
 	"\x02ts\x18\x01 \x01(\tH\x00R\x02ts\x88\x01\x01\x12+\n" +
 	"\n" +
-	"tuple_sets\x18\x02 \x03(\v2\f.am.TupleSetR\ttupleSetsB\x05\n" +
-	"\x03_ts\"A\n" +
+	"tuple_sets\x18\x02 \x03(\v2\f.am.TupleSetR\ttupleSets\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageTokenB\x05\n" +
+	"\x03_ts\"i\n" +
 	"\fReadResponse\x12\x0e\n" +
 	"\x02ts\x18\x01 \x01(\tR\x02ts\x12!\n" +
-	"\x06tuples\x18\x02 \x03(\v2\t.am.TupleR\x06tuples\"~\n" +
+	"\x06tuples\x18\x02 \x03(\v2\t.am.TupleR\x06tuples\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"~\n" +
 	"\fWriteRequest\x12\x13\n" +
 	"\x02ts\x18\x01 \x01(\tH\x00R\x02ts\x88\x01\x01\x12(\n" +
 	"\n" +
@@ -1758,11 +2351,14 @@ const file_iam_proto_rawDesc = "" +
 	"\x16ListNamespacesResponse\x121\n" +
 	"\n" +
 	"namespaces\x18\x01 \x03(\v2\x11.am.NamespaceMetaR\n" +
-	"namespaces2\xf8\x02\n" +
+	"namespaces2\x9e\x04\n" +
 	"\fCheckService\x12,\n" +
-	"\x05check\x12\x10.am.CheckRequest\x1a\x11.am.CheckResponse\x12U\n" +
+	"\x05check\x12\x10.am.CheckRequest\x1a\x11.am.CheckResponse\x12<\n" +
+	"\vcheck_batch\x12\x15.am.CheckBatchRequest\x1a\x16.am.CheckBatchResponse\x122\n" +
+	"\aexplain\x12\x12.am.ExplainRequest\x1a\x13.am.ExplainResponse\x12U\n" +
 	"\x14content_change_check\x12\x1d.am.ContentChangeCheckRequest\x1a\x1e.am.ContentChangeCheckResponse\x12)\n" +
-	"\x04list\x12\x0f.am.ListRequest\x1a\x10.am.ListResponse\x12/\n" +
+	"\x04list\x12\x0f.am.ListRequest\x1a\x10.am.ListResponse\x122\n" +
+	"\vlist_stream\x12\x0f.am.ListRequest\x1a\x10.am.ListResponse0\x01\x12/\n" +
 	"\x06expand\x12\x11.am.ExpandRequest\x1a\x12.am.ExpandResponse\x12)\n" +
 	"\x04read\x12\x0f.am.ReadRequest\x1a\x10.am.ReadResponse\x12,\n" +
 	"\x05write\x12\x10.am.WriteRequest\x1a\x11.am.WriteResponse\x12.\n" +
@@ -1782,73 +2378,94 @@ func file_iam_proto_rawDescGZIP() []byte {
 	return file_iam_proto_rawDescData
 }
 
-var file_iam_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_iam_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
 var file_iam_proto_goTypes = []any{
 	(*Principal)(nil),                  // 0: am.Principal
 	(*CheckRequest)(nil),               // 1: am.CheckRequest
 	(*CheckResponse)(nil),              // 2: am.CheckResponse
-	(*ContentChangeCheckRequest)(nil),  // 3: am.ContentChangeCheckRequest
-	(*ContentChangeCheckResponse)(nil), // 4: am.ContentChangeCheckResponse
-	(*ListRequest)(nil),                // 5: am.ListRequest
-	(*ListResponse)(nil),               // 6: am.ListResponse
-	(*ExpandRequest)(nil),              // 7: am.ExpandRequest
-	(*ExpandResponse)(nil),             // 8: am.ExpandResponse
-	(*UserSet)(nil),                    // 9: am.UserSet
-	(*Tuple)(nil),                      // 10: am.Tuple
-	(*TupleSet)(nil),                   // 11: am.TupleSet
-	(*ReadRequest)(nil),                // 12: am.ReadRequest
-	(*ReadResponse)(nil),               // 13: am.ReadResponse
-	(*WriteRequest)(nil),               // 14: am.WriteRequest
-	(*WriteResponse)(nil),              // 15: am.WriteResponse
-	(*WatchRequest)(nil),               // 16: am.WatchRequest
-	(*WatchResponse)(nil),              // 17: am.WatchResponse
-	(*Update)(nil),                     // 18: am.Update
-	(*RelationMeta)(nil),               // 19: am.RelationMeta
-	(*NamespaceMeta)(nil),              // 20: am.NamespaceMeta
-	(*ListNamespacesResponse)(nil),     // 21: am.ListNamespacesResponse
-	(*TupleSet_TupleSpec)(nil),         // 22: am.TupleSet.TupleSpec
-	(*TupleSet_ObjectSpec)(nil),        // 23: am.TupleSet.ObjectSpec
-	(*TupleSet_UserSetSpec)(nil),       // 24: am.TupleSet.UserSetSpec
-	(*emptypb.Empty)(nil),              // 25: google.protobuf.Empty
+	(*CheckBatchItem)(nil),             // 3: am.CheckBatchItem
+	(*CheckBatchRequest)(nil),          // 4: am.CheckBatchRequest
+	(*CheckBatchResult)(nil),           // 5: am.CheckBatchResult
+	(*CheckBatchResponse)(nil),         // 6: am.CheckBatchResponse
+	(*ExplainRequest)(nil),             // 7: am.ExplainRequest
+	(*ExplainNode)(nil),                // 8: am.ExplainNode
+	(*ExplainResponse)(nil),            // 9: am.ExplainResponse
+	(*ContentChangeCheckRequest)(nil),  // 10: am.ContentChangeCheckRequest
+	(*ContentChangeCheckResponse)(nil), // 11: am.ContentChangeCheckResponse
+	(*ListRequest)(nil),                // 12: am.ListRequest
+	(*ListResponse)(nil),               // 13: am.ListResponse
+	(*ExpandRequest)(nil),              // 14: am.ExpandRequest
+	(*ExpandResponse)(nil),             // 15: am.ExpandResponse
+	(*UserSet)(nil),                    // 16: am.UserSet
+	(*Tuple)(nil),                      // 17: am.Tuple
+	(*TupleSet)(nil),                   // 18: am.TupleSet
+	(*ReadRequest)(nil),                // 19: am.ReadRequest
+	(*ReadResponse)(nil),               // 20: am.ReadResponse
+	(*WriteRequest)(nil),               // 21: am.WriteRequest
+	(*WriteResponse)(nil),              // 22: am.WriteResponse
+	(*WatchRequest)(nil),               // 23: am.WatchRequest
+	(*WatchResponse)(nil),              // 24: am.WatchResponse
+	(*Update)(nil),                     // 25: am.Update
+	(*RelationMeta)(nil),               // 26: am.RelationMeta
+	(*NamespaceMeta)(nil),              // 27: am.NamespaceMeta
+	(*ListNamespacesResponse)(nil),     // 28: am.ListNamespacesResponse
+	(*TupleSet_TupleSpec)(nil),         // 29: am.TupleSet.TupleSpec
+	(*TupleSet_ObjectSpec)(nil),        // 30: am.TupleSet.ObjectSpec
+	(*TupleSet_UserSetSpec)(nil),       // 31: am.TupleSet.UserSetSpec
+	(*emptypb.Empty)(nil),              // 32: google.protobuf.Empty
 }
 var file_iam_proto_depIdxs = []int32{
 	0,  // 0: am.CheckResponse.principal:type_name -> am.Principal
-	9,  // 1: am.ExpandResponse.usersets:type_name -> am.UserSet
-	9,  // 2: am.Tuple.userSet:type_name -> am.UserSet
-	22, // 3: am.TupleSet.tuple_spec:type_name -> am.TupleSet.TupleSpec
-	23, // 4: am.TupleSet.object_spec:type_name -> am.TupleSet.ObjectSpec
-	24, // 5: am.TupleSet.userset_spec:type_name -> am.TupleSet.UserSetSpec
-	11, // 6: am.ReadRequest.tuple_sets:type_name -> am.TupleSet
-	10, // 7: am.ReadResponse.tuples:type_name -> am.Tuple
-	10, // 8: am.WriteRequest.add_tuples:type_name -> am.Tuple
-	10, // 9: am.WriteRequest.del_tuples:type_name -> am.Tuple
-	18, // 10: am.WatchResponse.updates:type_name -> am.Update
-	10, // 11: am.Update.tuple:type_name -> am.Tuple
-	19, // 12: am.NamespaceMeta.relations:type_name -> am.RelationMeta
-	20, // 13: am.ListNamespacesResponse.namespaces:type_name -> am.NamespaceMeta
-	9,  // 14: am.TupleSet.TupleSpec.user_set:type_name -> am.UserSet
-	9,  // 15: am.TupleSet.UserSetSpec.user_set:type_name -> am.UserSet
-	1,  // 16: am.CheckService.check:input_type -> am.CheckRequest
-	3,  // 17: am.CheckService.content_change_check:input_type -> am.ContentChangeCheckRequest
-	5,  // 18: am.CheckService.list:input_type -> am.ListRequest
-	7,  // 19: am.CheckService.expand:input_type -> am.ExpandRequest
-	12, // 20: am.CheckService.read:input_type -> am.ReadRequest
-	14, // 21: am.CheckService.write:input_type -> am.WriteRequest
-	16, // 22: am.CheckService.Watch:input_type -> am.WatchRequest
-	25, // 23: am.NamespaceService.ListNamespaces:input_type -> google.protobuf.Empty
-	2,  // 24: am.CheckService.check:output_type -> am.CheckResponse
-	4,  // 25: am.CheckService.content_change_check:output_type -> am.ContentChangeCheckResponse
-	6,  // 26: am.CheckService.list:output_type -> am.ListResponse
-	8,  // 27: am.CheckService.expand:output_type -> am.ExpandResponse
-	13, // 28: am.CheckService.read:output_type -> am.ReadResponse
-	15, // 29: am.CheckService.write:output_type -> am.WriteResponse
-	17, // 30: am.CheckService.Watch:output_type -> am.WatchResponse
-	21, // 31: am.NamespaceService.ListNamespaces:output_type -> am.ListNamespacesResponse
-	24, // [24:32] is the sub-list for method output_type
-	16, // [16:24] is the sub-list for method input_type
-	16, // [16:16] is the sub-list for extension type_name
-	16, // [16:16] is the sub-list for extension extendee
-	0,  // [0:16] is the sub-list for field type_name
+	3,  // 1: am.CheckBatchRequest.items:type_name -> am.CheckBatchItem
+	0,  // 2: am.CheckBatchResult.principal:type_name -> am.Principal
+	5,  // 3: am.CheckBatchResponse.results:type_name -> am.CheckBatchResult
+	17, // 4: am.ExplainNode.via:type_name -> am.Tuple
+	17, // 5: am.ExplainNode.grant:type_name -> am.Tuple
+	8,  // 6: am.ExplainNode.children:type_name -> am.ExplainNode
+	0,  // 7: am.ExplainResponse.principal:type_name -> am.Principal
+	8,  // 8: am.ExplainResponse.root:type_name -> am.ExplainNode
+	16, // 9: am.ExpandResponse.usersets:type_name -> am.UserSet
+	16, // 10: am.Tuple.userSet:type_name -> am.UserSet
+	29, // 11: am.TupleSet.tuple_spec:type_name -> am.TupleSet.TupleSpec
+	30, // 12: am.TupleSet.object_spec:type_name -> am.TupleSet.ObjectSpec
+	31, // 13: am.TupleSet.userset_spec:type_name -> am.TupleSet.UserSetSpec
+	18, // 14: am.ReadRequest.tuple_sets:type_name -> am.TupleSet
+	17, // 15: am.ReadResponse.tuples:type_name -> am.Tuple
+	17, // 16: am.WriteRequest.add_tuples:type_name -> am.Tuple
+	17, // 17: am.WriteRequest.del_tuples:type_name -> am.Tuple
+	25, // 18: am.WatchResponse.updates:type_name -> am.Update
+	17, // 19: am.Update.tuple:type_name -> am.Tuple
+	26, // 20: am.NamespaceMeta.relations:type_name -> am.RelationMeta
+	27, // 21: am.ListNamespacesResponse.namespaces:type_name -> am.NamespaceMeta
+	16, // 22: am.TupleSet.TupleSpec.user_set:type_name -> am.UserSet
+	16, // 23: am.TupleSet.UserSetSpec.user_set:type_name -> am.UserSet
+	1,  // 24: am.CheckService.check:input_type -> am.CheckRequest
+	4,  // 25: am.CheckService.check_batch:input_type -> am.CheckBatchRequest
+	7,  // 26: am.CheckService.explain:input_type -> am.ExplainRequest
+	10, // 27: am.CheckService.content_change_check:input_type -> am.ContentChangeCheckRequest
+	12, // 28: am.CheckService.list:input_type -> am.ListRequest
+	12, // 29: am.CheckService.list_stream:input_type -> am.ListRequest
+	14, // 30: am.CheckService.expand:input_type -> am.ExpandRequest
+	19, // 31: am.CheckService.read:input_type -> am.ReadRequest
+	21, // 32: am.CheckService.write:input_type -> am.WriteRequest
+	23, // 33: am.CheckService.Watch:input_type -> am.WatchRequest
+	32, // 34: am.NamespaceService.ListNamespaces:input_type -> google.protobuf.Empty
+	2,  // 35: am.CheckService.check:output_type -> am.CheckResponse
+	6,  // 36: am.CheckService.check_batch:output_type -> am.CheckBatchResponse
+	9,  // 37: am.CheckService.explain:output_type -> am.ExplainResponse
+	11, // 38: am.CheckService.content_change_check:output_type -> am.ContentChangeCheckResponse
+	13, // 39: am.CheckService.list:output_type -> am.ListResponse
+	13, // 40: am.CheckService.list_stream:output_type -> am.ListResponse
+	15, // 41: am.CheckService.expand:output_type -> am.ExpandResponse
+	20, // 42: am.CheckService.read:output_type -> am.ReadResponse
+	22, // 43: am.CheckService.write:output_type -> am.WriteResponse
+	24, // 44: am.CheckService.Watch:output_type -> am.WatchResponse
+	28, // 45: am.NamespaceService.ListNamespaces:output_type -> am.ListNamespacesResponse
+	35, // [35:46] is the sub-list for method output_type
+	24, // [24:35] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
 }
 
 func init() { file_iam_proto_init() }
@@ -1857,24 +2474,27 @@ func file_iam_proto_init() {
 		return
 	}
 	file_iam_proto_msgTypes[2].OneofWrappers = []any{}
-	file_iam_proto_msgTypes[10].OneofWrappers = []any{
+	file_iam_proto_msgTypes[5].OneofWrappers = []any{}
+	file_iam_proto_msgTypes[8].OneofWrappers = []any{}
+	file_iam_proto_msgTypes[9].OneofWrappers = []any{}
+	file_iam_proto_msgTypes[17].OneofWrappers = []any{
 		(*Tuple_UserId)(nil),
 		(*Tuple_UserSet)(nil),
 		(*Tuple_Expires)(nil),
 	}
-	file_iam_proto_msgTypes[11].OneofWrappers = []any{
+	file_iam_proto_msgTypes[18].OneofWrappers = []any{
 		(*TupleSet_TupleSpec_)(nil),
 		(*TupleSet_ObjectSpec_)(nil),
 		(*TupleSet_UsersetSpec)(nil),
 	}
-	file_iam_proto_msgTypes[12].OneofWrappers = []any{}
-	file_iam_proto_msgTypes[14].OneofWrappers = []any{}
-	file_iam_proto_msgTypes[22].OneofWrappers = []any{
+	file_iam_proto_msgTypes[19].OneofWrappers = []any{}
+	file_iam_proto_msgTypes[21].OneofWrappers = []any{}
+	file_iam_proto_msgTypes[29].OneofWrappers = []any{
 		(*TupleSet_TupleSpec_UserId)(nil),
 		(*TupleSet_TupleSpec_UserSet)(nil),
 	}
-	file_iam_proto_msgTypes[23].OneofWrappers = []any{}
-	file_iam_proto_msgTypes[24].OneofWrappers = []any{
+	file_iam_proto_msgTypes[30].OneofWrappers = []any{}
+	file_iam_proto_msgTypes[31].OneofWrappers = []any{
 		(*TupleSet_UserSetSpec_UserId)(nil),
 		(*TupleSet_UserSetSpec_UserSet)(nil),
 	}
@@ -1884,7 +2504,7 @@ func file_iam_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_iam_proto_rawDesc), len(file_iam_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   25,
+			NumMessages:   32,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
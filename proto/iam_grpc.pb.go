@@ -21,8 +21,11 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	CheckService_Check_FullMethodName              = "/am.CheckService/check"
+	CheckService_CheckBatch_FullMethodName         = "/am.CheckService/check_batch"
+	CheckService_Explain_FullMethodName            = "/am.CheckService/explain"
 	CheckService_ContentChangeCheck_FullMethodName = "/am.CheckService/content_change_check"
 	CheckService_List_FullMethodName               = "/am.CheckService/list"
+	CheckService_ListStream_FullMethodName         = "/am.CheckService/list_stream"
 	CheckService_Expand_FullMethodName             = "/am.CheckService/expand"
 	CheckService_Read_FullMethodName               = "/am.CheckService/read"
 	CheckService_Write_FullMethodName              = "/am.CheckService/write"
@@ -34,8 +37,11 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CheckServiceClient interface {
 	Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error)
+	CheckBatch(ctx context.Context, in *CheckBatchRequest, opts ...grpc.CallOption) (*CheckBatchResponse, error)
+	Explain(ctx context.Context, in *ExplainRequest, opts ...grpc.CallOption) (*ExplainResponse, error)
 	ContentChangeCheck(ctx context.Context, in *ContentChangeCheckRequest, opts ...grpc.CallOption) (*ContentChangeCheckResponse, error)
 	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	ListStream(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListResponse], error)
 	Expand(ctx context.Context, in *ExpandRequest, opts ...grpc.CallOption) (*ExpandResponse, error)
 	Read(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*ReadResponse, error)
 	Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error)
@@ -60,6 +66,26 @@ func (c *checkServiceClient) Check(ctx context.Context, in *CheckRequest, opts .
 	return out, nil
 }
 
+func (c *checkServiceClient) CheckBatch(ctx context.Context, in *CheckBatchRequest, opts ...grpc.CallOption) (*CheckBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckBatchResponse)
+	err := c.cc.Invoke(ctx, CheckService_CheckBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *checkServiceClient) Explain(ctx context.Context, in *ExplainRequest, opts ...grpc.CallOption) (*ExplainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExplainResponse)
+	err := c.cc.Invoke(ctx, CheckService_Explain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *checkServiceClient) ContentChangeCheck(ctx context.Context, in *ContentChangeCheckRequest, opts ...grpc.CallOption) (*ContentChangeCheckResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ContentChangeCheckResponse)
@@ -80,6 +106,25 @@ func (c *checkServiceClient) List(ctx context.Context, in *ListRequest, opts ...
 	return out, nil
 }
 
+func (c *checkServiceClient) ListStream(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CheckService_ServiceDesc.Streams[0], CheckService_ListStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListRequest, ListResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CheckService_ListStreamClient = grpc.ServerStreamingClient[ListResponse]
+
 func (c *checkServiceClient) Expand(ctx context.Context, in *ExpandRequest, opts ...grpc.CallOption) (*ExpandResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ExpandResponse)
@@ -112,7 +157,7 @@ func (c *checkServiceClient) Write(ctx context.Context, in *WriteRequest, opts .
 
 func (c *checkServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &CheckService_ServiceDesc.Streams[0], CheckService_Watch_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &CheckService_ServiceDesc.Streams[1], CheckService_Watch_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -134,8 +179,11 @@ type CheckService_WatchClient = grpc.ServerStreamingClient[WatchResponse]
 // for forward compatibility.
 type CheckServiceServer interface {
 	Check(context.Context, *CheckRequest) (*CheckResponse, error)
+	CheckBatch(context.Context, *CheckBatchRequest) (*CheckBatchResponse, error)
+	Explain(context.Context, *ExplainRequest) (*ExplainResponse, error)
 	ContentChangeCheck(context.Context, *ContentChangeCheckRequest) (*ContentChangeCheckResponse, error)
 	List(context.Context, *ListRequest) (*ListResponse, error)
+	ListStream(*ListRequest, grpc.ServerStreamingServer[ListResponse]) error
 	Expand(context.Context, *ExpandRequest) (*ExpandResponse, error)
 	Read(context.Context, *ReadRequest) (*ReadResponse, error)
 	Write(context.Context, *WriteRequest) (*WriteResponse, error)
@@ -153,12 +201,21 @@ type UnimplementedCheckServiceServer struct{}
 func (UnimplementedCheckServiceServer) Check(context.Context, *CheckRequest) (*CheckResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
 }
+func (UnimplementedCheckServiceServer) CheckBatch(context.Context, *CheckBatchRequest) (*CheckBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckBatch not implemented")
+}
+func (UnimplementedCheckServiceServer) Explain(context.Context, *ExplainRequest) (*ExplainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Explain not implemented")
+}
 func (UnimplementedCheckServiceServer) ContentChangeCheck(context.Context, *ContentChangeCheckRequest) (*ContentChangeCheckResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ContentChangeCheck not implemented")
 }
 func (UnimplementedCheckServiceServer) List(context.Context, *ListRequest) (*ListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
 }
+func (UnimplementedCheckServiceServer) ListStream(*ListRequest, grpc.ServerStreamingServer[ListResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ListStream not implemented")
+}
 func (UnimplementedCheckServiceServer) Expand(context.Context, *ExpandRequest) (*ExpandResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Expand not implemented")
 }
@@ -210,6 +267,42 @@ func _CheckService_Check_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CheckService_CheckBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckServiceServer).CheckBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CheckService_CheckBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckServiceServer).CheckBatch(ctx, req.(*CheckBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CheckService_Explain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExplainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckServiceServer).Explain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CheckService_Explain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckServiceServer).Explain(ctx, req.(*ExplainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CheckService_ContentChangeCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ContentChangeCheckRequest)
 	if err := dec(in); err != nil {
@@ -246,6 +339,17 @@ func _CheckService_List_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CheckService_ListStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CheckServiceServer).ListStream(m, &grpc.GenericServerStream[ListRequest, ListResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CheckService_ListStreamServer = grpc.ServerStreamingServer[ListResponse]
+
 func _CheckService_Expand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ExpandRequest)
 	if err := dec(in); err != nil {
@@ -322,6 +426,14 @@ var CheckService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "check",
 			Handler:    _CheckService_Check_Handler,
 		},
+		{
+			MethodName: "check_batch",
+			Handler:    _CheckService_CheckBatch_Handler,
+		},
+		{
+			MethodName: "explain",
+			Handler:    _CheckService_Explain_Handler,
+		},
 		{
 			MethodName: "content_change_check",
 			Handler:    _CheckService_ContentChangeCheck_Handler,
@@ -344,6 +456,11 @@ var CheckService_ServiceDesc = grpc.ServiceDesc{
 		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "list_stream",
+			Handler:       _CheckService_ListStream_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "Watch",
 			Handler:       _CheckService_Watch_Handler,
@@ -0,0 +1,78 @@
+package nioclient
+
+// Paginated Read. Read holds every matching tuple in memory, which does not
+// scale to hot objects or service accounts with thousands of grants. ReadPage
+// fetches one page at a time and ReadAll iterates over all pages, every page
+// at the snapshot of the first.
+//
+// Servers without pagination ignore page_size and page_token and answer with
+// every tuple and no next token: ReadPage then returns a single (large) page
+// and ReadAll still yields every tuple.
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// DefaultReadPageSize is the page size ReadAll requests.
+const DefaultReadPageSize = 1000
+
+// ReadPageResult is one page of a paginated Read. NextCursor is passed to the
+// next ReadPage call; it is empty on the last page.
+type ReadPageResult struct {
+	Ts         Timestamp
+	Tuples     []Tuple
+	NextCursor string
+}
+
+// ReadPage returns up to pageSize stored tuples matching filters. Pass an
+// empty cursor for the first page, evaluated at a snapshot at least as fresh
+// as ts, and the previous page's Ts and NextCursor for the following pages.
+// pageSize <= 0 means DefaultReadPageSize.
+func (c *checkAPI) ReadPage(ctx context.Context, ts Timestamp, pageSize int, cursor string, filters ...ReadFilter) (ReadPageResult, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultReadPageSize
+	}
+	return c.readPage(ctx, ts, pageSize, cursor, filters)
+}
+
+// ReadAll iterates over every stored tuple matching filters, fetching
+// DefaultReadPageSize tuples per RPC. All pages are read at the snapshot of
+// the first page. A failing page yields a zero Tuple and the error, then ends
+// the iteration.
+//
+//	for t, err := range client.ReadAll(ctx, nioclient.FilterByUser(ns, userId, nil)) {
+//		if err != nil {
+//			return err
+//		}
+//		…
+//	}
+func (c *checkAPI) ReadAll(ctx context.Context, filters ...ReadFilter) iter.Seq2[Tuple, error] {
+	return func(yield func(Tuple, error) bool) {
+		ts, cursor := TimestampEmpty, ""
+		for {
+			page, err := c.readPage(ctx, ts, DefaultReadPageSize, cursor, filters)
+			if err != nil {
+				yield(Tuple{}, err)
+				return
+			}
+			for _, t := range page.Tuples {
+				if !yield(t, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			if page.NextCursor == cursor {
+				yield(Tuple{}, fmt.Errorf("read: server repeated page token %q", cursor))
+				return
+			}
+			if cursor == "" {
+				ts = page.Ts
+			}
+			cursor = page.NextCursor
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package nioclient
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+)
+
+// pagedRead serves n tuples doc:d1#viewer@u<i> in pages, ignoring paging
+// when the request has no page size.
+func pagedRead(t *testing.T, n int, snap string) func(*proto.ReadRequest) (*proto.ReadResponse, error) {
+	return func(in *proto.ReadRequest) (*proto.ReadResponse, error) {
+		start := 0
+		if in.PageToken != "" {
+			if in.GetTs() != snap {
+				t.Errorf("page %q read at ts %q, want pinned %q", in.PageToken, in.GetTs(), snap)
+			}
+			start, _ = strconv.Atoi(in.PageToken)
+		}
+		end := n
+		if in.PageSize > 0 && start+int(in.PageSize) < n {
+			end = start + int(in.PageSize)
+		}
+		res := &proto.ReadResponse{Ts: snap}
+		for i := start; i < end; i++ {
+			res.Tuples = append(res.Tuples, &proto.Tuple{
+				Ns: "doc", Obj: "d1", Rel: "viewer",
+				User: &proto.Tuple_UserId{UserId: fmt.Sprintf("u%d", i)},
+			})
+		}
+		if end < n && in.PageSize > 0 {
+			res.NextPageToken = strconv.Itoa(end)
+		}
+		return res, nil
+	}
+}
+
+func TestReadPageFollowsCursor(t *testing.T) {
+	fake := &fakeCheckService{read: pagedRead(t, 5, "snap")}
+	c := &checkAPI{grpcClient: fake}
+	filter := FilterByObject("doc", "d1", nil)
+
+	first, err := c.ReadPage(t.Context(), TimestampEmpty, 2, "", filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Tuples) != 2 || first.NextCursor != "2" {
+		t.Fatalf("first page = %d tuples, cursor %q", len(first.Tuples), first.NextCursor)
+	}
+	second, err := c.ReadPage(t.Context(), first.Ts, 2, first.NextCursor, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Tuples[0].UserId != "u2" {
+		t.Fatalf("second page starts at %s, want u2", second.Tuples[0].UserId)
+	}
+}
+
+func TestReadAllIteratesPinnedPages(t *testing.T) {
+	n := 2*DefaultReadPageSize + 3
+	fake := &fakeCheckService{read: pagedRead(t, n, "snap")}
+	c := &checkAPI{grpcClient: fake}
+
+	got := 0
+	for tuple, err := range c.ReadAll(t.Context(), FilterByObject("doc", "d1", nil)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := UserId(fmt.Sprintf("u%d", got)); tuple.UserId != want {
+			t.Fatalf("tuple %d = %s, want %s", got, tuple.UserId, want)
+		}
+		got++
+	}
+	if got != n || fake.count("read") != 3 {
+		t.Fatalf("got %d tuples in %d reads, want %d in 3", got, fake.count("read"), n)
+	}
+}
+
+func TestReadAllWithoutServerPaging(t *testing.T) {
+	serve := pagedRead(t, 3, "snap")
+	fake := &fakeCheckService{read: func(in *proto.ReadRequest) (*proto.ReadResponse, error) {
+		in.PageSize = 0 // an older server drops the unknown field
+		return serve(in)
+	}}
+	c := &checkAPI{grpcClient: fake}
+
+	got := 0
+	for _, err := range c.ReadAll(t.Context(), FilterByObject("doc", "d1", nil)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got++
+	}
+	if got != 3 || fake.count("read") != 1 {
+		t.Fatalf("got %d tuples in %d reads, want 3 in 1", got, fake.count("read"))
+	}
+}
+
+func TestReadAllStopsEarly(t *testing.T) {
+	fake := &fakeCheckService{read: pagedRead(t, 2*DefaultReadPageSize, "snap")}
+	c := &checkAPI{grpcClient: fake}
+	for range c.ReadAll(t.Context(), FilterByObject("doc", "d1", nil)) {
+		break
+	}
+	if fake.count("read") != 1 {
+		t.Fatalf("reads = %d after break, want 1", fake.count("read"))
+	}
+}
@@ -17,8 +17,11 @@ package nioclient
 
 import (
 	"context"
+	"net/http"
 	"slices"
+	"strconv"
 	"sync"
+	"time"
 
 	"golang.org/x/sync/singleflight"
 )
@@ -27,6 +30,16 @@ import (
 type wrapConfig struct {
 	requestMemo bool
 	memoObserve func(op string, hit bool)
+
+	cookieZookies      bool
+	cookieZookieMaxAge time.Duration
+
+	routeSamples  []routeSample
+	routeRegistry *RouteRegistry
+
+	credentials []Credential
+
+	policyObserve func(r *http.Request, d PolicyDecision)
 }
 
 // WrapOption configures Wrap.
@@ -52,6 +65,7 @@ func WithRequestMemoObserver(f func(op string, hit bool)) WrapOption {
 
 type checkFunc func(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId) (Principal, bool, error)
 type listFunc func(ctx context.Context, ns Ns, rel Rel, userId UserId) ([]string, error)
+type listLimitFunc func(ctx context.Context, ns Ns, rel Rel, userId UserId, limit int) ([]string, error)
 
 type checkMemoKey struct {
 	ns, obj, rel, userId string
@@ -74,23 +88,46 @@ func (k listMemoKey) string() string {
 	return "l:" + k.ns + "\x00" + k.rel + "\x00" + k.userId
 }
 
+// listMemoVal is a memoized list: the whole list, or only a prefix of it when
+// it came from a limited list.
+type listMemoVal struct {
+	objs     []string
+	complete bool
+}
+
+// covers reports whether v answers a list of limit objects (0 = all).
+func (v listMemoVal) covers(limit int) bool {
+	return v.complete || limit > 0 && len(v.objs) >= limit
+}
+
+// prefix returns a copy of at most limit objects (0 = all).
+func (v listMemoVal) prefix(limit int) []string {
+	if limit > 0 && len(v.objs) > limit {
+		return slices.Clone(v.objs[:limit])
+	}
+	return slices.Clone(v.objs) // callers must not mutate the cached slice
+}
+
 // requestMemo memoizes check and list decisions for the lifetime of one request.
 // The timestamp is fixed per request (encoded in the wrapped check/list funcs),
 // so it is not part of the keys.
 type requestMemo struct {
 	mu        sync.Mutex
 	checks    map[checkMemoKey]checkMemoVal
-	lists     map[listMemoKey][]string
+	lists     map[listMemoKey]listMemoVal
 	checkNext checkFunc
 	listNext  listFunc
 	flight    singleflight.Group
 	observe   func(op string, hit bool)
+
+	// listLimitNext answers limited lists; nil lists in full and truncates.
+	listLimitNext listLimitFunc
 }
 
 func newRequestMemo(check checkFunc, list listFunc, observe func(op string, hit bool)) *requestMemo {
 	return &requestMemo{
 		checks:    make(map[checkMemoKey]checkMemoVal),
-		lists:     make(map[listMemoKey][]string),
+		lists:     make(map[listMemoKey]listMemoVal),
 		checkNext: check,
 		listNext:  list,
 		observe:   observe,
@@ -142,37 +179,57 @@ func (m *requestMemo) check(ctx context.Context, ns Ns, obj Obj, rel Rel, userId
 }
 
 func (m *requestMemo) list(ctx context.Context, ns Ns, rel Rel, userId UserId) ([]string, error) {
+	return m.listLimit(ctx, ns, rel, userId, 0)
+}
+
+// listLimit memoizes limited lists as bounded prefixes: a cached list answers
+// every limit it has enough objects for, and a full list answers them all.
+// limit <= 0 lists all objects.
+func (m *requestMemo) listLimit(ctx context.Context, ns Ns, rel Rel, userId UserId, limit int) ([]string, error) {
+	limit = max(limit, 0)
 	key := listMemoKey{ns: string(ns), rel: string(rel), userId: string(userId)}
 
 	m.mu.Lock()
 	hit, ok := m.lists[key]
 	m.mu.Unlock()
-	if ok {
+	if ok && hit.covers(limit) {
 		m.report("list", true)
-		return slices.Clone(hit), nil // callers must not mutate the cached slice
+		return hit.prefix(limit), nil
 	}
 	m.report("list", false)
 
-	v, err, _ := m.flight.Do(key.string(), func() (interface{}, error) {
+	v, err, _ := m.flight.Do(key.string()+"\x00"+strconv.Itoa(limit), func() (interface{}, error) {
 		m.mu.Lock()
-		if hit, ok := m.lists[key]; ok {
+		if hit, ok := m.lists[key]; ok && hit.covers(limit) {
 			m.mu.Unlock()
 			return hit, nil
 		}
 		m.mu.Unlock()
 
-		objs, err := m.listNext(ctx, ns, rel, userId)
+		var objs []string
+		var err error
+		switch {
+		case limit == 0:
+			objs, err = m.listNext(ctx, ns, rel, userId)
+		case m.listLimitNext != nil:
+			objs, err = m.listLimitNext(ctx, ns, rel, userId, limit)
+		default:
+			objs, err = m.listNext(ctx, ns, rel, userId)
+		}
 		if err != nil {
 			return nil, err // never cache errors
 		}
-		stored := slices.Clone(objs)
+		// Fewer objects than asked for means the list ended.
+		val := listMemoVal{objs: slices.Clone(objs), complete: limit == 0 || len(objs) < limit || m.listLimitNext == nil}
 		m.mu.Lock()
-		m.lists[key] = stored
+		if cur, ok := m.lists[key]; !ok || !cur.complete && (val.complete || len(val.objs) > len(cur.objs)) {
+			m.lists[key] = val
+		}
 		m.mu.Unlock()
-		return stored, nil
+		return val, nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	return slices.Clone(v.([]string)), nil
+	return v.(listMemoVal).prefix(limit), nil
 }
@@ -0,0 +1,186 @@
+package nioclient
+
+// Retry and hedging for idempotent check RPCs (check, check_batch, list,
+// expand, read, ListNamespaces). A single UNAVAILABLE during a check-server
+// rollout is retried with exponential backoff and full jitter instead of
+// surfacing as a 500 from Wrap. Writes are retried only when they carry a
+// precondition zookie — without one a retry after a lost response could apply
+// the same mutation twice — and are never hedged.
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy configures retries of idempotent check RPCs. Install it with
+// WithRetryPolicy. Every attempt, including hedged ones, is reported through
+// WithObserveCheck / WithObserveList for check and list, except the request a
+// hedge cancels because the other one answered first.
+type RetryPolicy struct {
+	MaxAttempts    int           // total attempts including the first; <= 1 disables retries
+	InitialBackoff time.Duration // backoff before the second attempt
+	MaxBackoff     time.Duration // cap of the exponential backoff; 0 = uncapped
+	Multiplier     float64       // backoff growth per attempt; < 1 is treated as 1
+	RetryableCodes []codes.Code  // gRPC codes worth another attempt
+
+	// HedgeAfter sends a second, concurrent request when an attempt has not
+	// answered within this latency; the first success wins and the other is
+	// cancelled. 0 disables hedging.
+	HedgeAfter time.Duration
+
+	// OnRetry, when non-nil, is called before each retry with the RPC name
+	// ("check", "list", "read", …), the attempt that failed (1-based) and its
+	// error.
+	OnRetry func(op string, attempt int, err error)
+}
+
+// DefaultRetryPolicy returns 3 attempts, 50ms initial backoff doubling up to
+// 1s, retrying UNAVAILABLE only, without hedging.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		RetryableCodes: []codes.Code{codes.Unavailable},
+	}
+}
+
+// ClientOption configures the check RPC surface shared by New and
+// NewWithSession (the latter via WithClientOptions).
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	retry         *RetryPolicy
+	zookies       ZookieStore
+	schemaRefresh time.Duration // > 0 = WithSchemaValidation
+}
+
+// WithRetryPolicy installs p for idempotent RPCs. Without it every RPC is
+// attempted exactly once.
+func WithRetryPolicy(p RetryPolicy) ClientOption {
+	return func(o *clientOptions) {
+		o.retry = &p
+	}
+}
+
+// WithClientOptions applies ClientOptions to the check RPC surface of a
+// SessionClient.
+func WithClientOptions(opts ...ClientOption) SessionOption {
+	return func(o *sessionOptions) {
+		o.client = append(o.client, opts...)
+	}
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	return slices.Contains(p.RetryableCodes, status.Code(err))
+}
+
+// backoff returns the full-jitter sleep before attempt n+1 (n >= 1).
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	d := float64(p.InitialBackoff)
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 1
+	}
+	for i := 1; i < n; i++ {
+		d *= mult
+		if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+			d = float64(p.MaxBackoff)
+			break
+		}
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// retryCall runs call under p. A nil policy makes exactly one attempt.
+// hedge=false disables hedging regardless of the policy (writes).
+func retryCall[T any](ctx context.Context, p *RetryPolicy, op string, hedge bool, call func(ctx context.Context) (T, error)) (T, error) {
+	if p == nil {
+		return call(ctx)
+	}
+	for attempt := 1; ; attempt++ {
+		var res T
+		var err error
+		if hedge && p.HedgeAfter > 0 {
+			res, err = hedged(ctx, p.HedgeAfter, call)
+		} else {
+			res, err = call(ctx)
+		}
+		if err == nil || attempt >= p.MaxAttempts || !p.retryable(err) || ctx.Err() != nil {
+			return res, err
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(op, attempt, err)
+		}
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, err
+		case <-timer.C:
+		}
+	}
+}
+
+// errHedgeLost is the cancellation cause of the request that lost a hedge.
+var errHedgeLost = errors.New("hedge lost")
+
+// hedgeLost tells whether an attempt failed only because hedged cancelled it
+// after the other request answered. Observers skip such attempts: they are
+// not failures of the server.
+func hedgeLost(ctx context.Context, err error) bool {
+	return err != nil && errors.Is(context.Cause(ctx), errHedgeLost)
+}
+
+// hedged starts call, and a second call if the first has not answered after
+// delay. The first success wins; if both fail, the last error is returned.
+func hedged[T any](ctx context.Context, delay time.Duration, call func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(errHedgeLost) // stops the losing request
+
+	type result struct {
+		res T
+		err error
+	}
+	done := make(chan result, 2)
+	start := func() {
+		go func() {
+			res, err := call(ctx)
+			done <- result{res, err}
+		}()
+	}
+
+	start()
+	pending := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var last result
+	for {
+		select {
+		case <-timer.C:
+			start()
+			pending++
+		case r := <-done:
+			pending--
+			if r.err == nil {
+				return r.res, nil
+			}
+			last = r
+			if pending == 0 {
+				// Every started request failed; a failure before the hedge
+				// delay is left to the retry loop rather than hedged.
+				return last.res, last.err
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package nioclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func fastRetryPolicy() *RetryPolicy {
+	p := DefaultRetryPolicy()
+	p.InitialBackoff = time.Millisecond
+	p.MaxBackoff = 2 * time.Millisecond
+	return &p
+}
+
+func TestRetryCheckOnUnavailable(t *testing.T) {
+	var n int32
+	fake := &fakeCheckService{check: func(in *proto.CheckRequest) (*proto.CheckResponse, error) {
+		if atomic.AddInt32(&n, 1) < 3 {
+			return nil, status.Error(codes.Unavailable, "rollout")
+		}
+		return allowViewer(in)
+	}}
+	var observed, retries int32
+	p := fastRetryPolicy()
+	p.OnRetry = func(op string, _ int, _ error) {
+		if op != "check" {
+			t.Errorf("OnRetry op = %q, want check", op)
+		}
+		atomic.AddInt32(&retries, 1)
+	}
+	c := &checkAPI{grpcClient: fake, retry: p, observeCheck: func(Ns, Obj, Rel, UserId, time.Duration, bool, bool) {
+		atomic.AddInt32(&observed, 1)
+	}}
+
+	_, ok, err := c.Check(context.Background(), "doc", "1", "viewer", "u1")
+	if err != nil || !ok {
+		t.Fatalf("Check = %v, %v; want allowed after retries", ok, err)
+	}
+	if observed != 3 || retries != 2 {
+		t.Fatalf("observed = %d, retries = %d; want 3 attempts observed, 2 retries", observed, retries)
+	}
+}
+
+func TestRetryGivesUpAndKeepsKind(t *testing.T) {
+	fake := &fakeCheckService{check: func(*proto.CheckRequest) (*proto.CheckResponse, error) {
+		return nil, status.Error(codes.Unavailable, "down")
+	}}
+	c := &checkAPI{grpcClient: fake, retry: fastRetryPolicy()}
+
+	_, _, err := c.Check(context.Background(), "doc", "1", "viewer", "u1")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+	if got := fake.count("check"); got != 3 {
+		t.Fatalf("attempts = %d, want MaxAttempts 3", got)
+	}
+}
+
+func TestRetrySkipsNonRetryableCodes(t *testing.T) {
+	fake := &fakeCheckService{check: func(*proto.CheckRequest) (*proto.CheckResponse, error) {
+		return nil, status.Error(codes.InvalidArgument, "unknown relation")
+	}}
+	c := &checkAPI{grpcClient: fake, retry: fastRetryPolicy()}
+
+	if _, _, err := c.Check(context.Background(), "doc", "1", "nope", "u1"); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := fake.count("check"); got != 1 {
+		t.Fatalf("attempts = %d, want 1", got)
+	}
+}
+
+func TestRetryWriteOnlyWithPrecondition(t *testing.T) {
+	fake := &fakeCheckService{write: func(*proto.WriteRequest) (*proto.WriteResponse, error) {
+		return nil, status.Error(codes.Unavailable, "down")
+	}}
+	c := &checkAPI{grpcClient: fake, retry: fastRetryPolicy()}
+	add := []Tuple{{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u1"}}
+
+	if _, err := c.Write(context.Background(), add, nil, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := fake.count("write"); got != 1 {
+		t.Fatalf("unconditional write attempts = %d, want 1", got)
+	}
+
+	pre := Timestamp("AQAAAAAAAQ==")
+	if _, err := c.Write(context.Background(), add, nil, &pre); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := fake.count("write"); got != 1+3 {
+		t.Fatalf("conditional write attempts = %d, want 3 more", got-1)
+	}
+}
+
+func TestHedgeWinsOverSlowAttempt(t *testing.T) {
+	var n int32
+	fake := &fakeCheckService{check: func(in *proto.CheckRequest) (*proto.CheckResponse, error) {
+		if atomic.AddInt32(&n, 1) == 1 {
+			time.Sleep(200 * time.Millisecond) // the stuck first request
+		}
+		return allowViewer(in)
+	}}
+	p := fastRetryPolicy()
+	p.HedgeAfter = 10 * time.Millisecond
+	c := &checkAPI{grpcClient: fake, retry: p}
+
+	start := time.Now()
+	_, ok, err := c.Check(context.Background(), "doc", "1", "viewer", "u1")
+	if err != nil || !ok {
+		t.Fatalf("Check = %v, %v", ok, err)
+	}
+	if took := time.Since(start); took > 150*time.Millisecond {
+		t.Fatalf("hedged check took %v; the hedge should have answered", took)
+	}
+	if got := atomic.LoadInt32(&n); got != 2 {
+		t.Fatalf("requests = %d, want 2 (original + hedge)", got)
+	}
+}
+
+// blockingCheckService blocks the first check until its context ends, like a
+// stuck server the hedge overtakes.
+type blockingCheckService struct {
+	*fakeCheckService
+	n int32
+}
+
+func (f *blockingCheckService) Check(ctx context.Context, in *proto.CheckRequest, opts ...grpc.CallOption) (*proto.CheckResponse, error) {
+	if atomic.AddInt32(&f.n, 1) == 1 {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	return f.fakeCheckService.Check(ctx, in, opts...)
+}
+
+func TestHedgeLoserIsNotObservedAsError(t *testing.T) {
+	fake := &blockingCheckService{fakeCheckService: &fakeCheckService{check: allowViewer}}
+	p := fastRetryPolicy()
+	p.HedgeAfter = 10 * time.Millisecond
+	var mu sync.Mutex
+	var errorsSeen, observed int
+	c := &checkAPI{grpcClient: fake, retry: p, observeCheck: func(_ Ns, _ Obj, _ Rel, _ UserId, _ time.Duration, _ bool, isError bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed++
+		if isError {
+			errorsSeen++
+		}
+	}}
+
+	if _, ok, err := c.Check(context.Background(), "doc", "1", "viewer", "u1"); err != nil || !ok {
+		t.Fatalf("Check = %v, %v", ok, err)
+	}
+	// The cancelled loser returns right after the winner; give it time to
+	// (not) report.
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt32(&fake.n); got != 2 {
+		t.Fatalf("requests = %d, want 2 (original + hedge)", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if observed != 1 || errorsSeen != 0 {
+		t.Fatalf("observed = %d (errors %d), want only the winning attempt", observed, errorsSeen)
+	}
+}
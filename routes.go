@@ -0,0 +1,264 @@
+package nioclient
+
+// Boot-time verification of Wrap routes. A typo in a Resource.Requires
+// relation ("article.updte") otherwise only shows as a 403 on every request.
+// Every Wrap/WrapHTTP route is recorded in a RouteRegistry: under its
+// WithRouteSample name with that sample resource, else under the call site of
+// Wrap until its first request supplies a sample. VerifyRoutes asks each
+// sample what it requires per HTTP method and looks the ns/rel up in the
+// schema check has loaded.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// ErrNoRouteSample is the Err of a RouteMismatch for a route that has no
+// sample resource: it was wrapped without WithRouteSample and has not served
+// a request yet.
+var ErrNoRouteSample = errors.New("no sample resource")
+
+// DefaultVerifyMethods are the methods VerifyRoutes asks each sample resource
+// about when none are given.
+var DefaultVerifyMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// RouteSample is a route recorded by Wrap: a name for reports (typically
+// "METHOD /path" or the router pattern, else the file:line of the Wrap call)
+// and a resource as extract would return it, nil until one is known.
+type RouteSample struct {
+	Route    string
+	Resource Resource
+}
+
+// RouteRegistry collects the wrapped routes and their sample resources, one
+// entry per route name. The zero value is ready to use and safe for
+// concurrent use.
+type RouteRegistry struct {
+	mu     sync.Mutex
+	routes []RouteSample
+	index  map[string]int // route -> position in routes
+}
+
+// DefaultRouteRegistry is the registry Wrap records routes into unless
+// WithRouteRegistry selects another, and the one VerifyRoutes verifies.
+var DefaultRouteRegistry = &RouteRegistry{}
+
+// Register records a route's sample resource, replacing the sample of a
+// route registered before under the same name.
+func (reg *RouteRegistry) Register(route string, sample Resource) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if i, ok := reg.index[route]; ok {
+		reg.routes[i].Resource = sample
+		return
+	}
+	reg.add(route, sample)
+}
+
+// record registers route without a sample unless it is already known.
+func (reg *RouteRegistry) record(route string) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, ok := reg.index[route]; !ok {
+		reg.add(route, nil)
+	}
+}
+
+// learn sets the sample of route unless it already has one.
+func (reg *RouteRegistry) learn(route string, sample Resource) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if i, ok := reg.index[route]; ok && reg.routes[i].Resource == nil {
+		reg.routes[i].Resource = sample
+	}
+}
+
+func (reg *RouteRegistry) add(route string, sample Resource) {
+	if reg.index == nil {
+		reg.index = make(map[string]int)
+	}
+	reg.index[route] = len(reg.routes)
+	reg.routes = append(reg.routes, RouteSample{Route: route, Resource: sample})
+}
+
+// Routes returns the recorded routes in registration order.
+func (reg *RouteRegistry) Routes() []RouteSample {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return slices.Clone(reg.routes)
+}
+
+// Sample returns a WrapOption that records route with sample in reg when the
+// route is wrapped.
+func (reg *RouteRegistry) Sample(route string, sample Resource) WrapOption {
+	return func(c *wrapConfig) {
+		c.routeSamples = append(c.routeSamples, routeSample{registry: reg, route: route, sample: sample})
+	}
+}
+
+// WithRouteSample records route with a sample resource in the route's
+// registry (DefaultRouteRegistry unless WithRouteRegistry is given), for
+// VerifyRoutes. The sample only needs to answer Requires like the resources
+// extract returns; object ids are not checked.
+func WithRouteSample(route string, sample Resource) WrapOption {
+	return func(c *wrapConfig) {
+		c.routeSamples = append(c.routeSamples, routeSample{route: route, sample: sample})
+	}
+}
+
+// WithRouteRegistry records the route in reg instead of DefaultRouteRegistry,
+// e.g. to keep the routers of tests apart.
+func WithRouteRegistry(reg *RouteRegistry) WrapOption {
+	return func(c *wrapConfig) { c.routeRegistry = reg }
+}
+
+type routeSample struct {
+	registry *RouteRegistry // nil: the route's registry
+	route    string
+	sample   Resource
+}
+
+// callsite returns the file:line of the caller of Wrap or WrapHTTP, the name
+// of a route wrapped without a sample.
+func callsite() string {
+	_, file, line, ok := runtime.Caller(2)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// recordRoute registers the route of Wrap or WrapHTTP: under the names of its
+// samples, else under callsite until serve learns a sample from the first
+// extracted resource.
+func (wd *wrapped) recordRoute(callsite string) {
+	reg := wd.cfg.routeRegistry
+	if reg == nil {
+		reg = DefaultRouteRegistry
+	}
+	if len(wd.cfg.routeSamples) > 0 {
+		for _, rs := range wd.cfg.routeSamples {
+			if rs.registry != nil {
+				rs.registry.Register(rs.route, rs.sample)
+			} else {
+				reg.Register(rs.route, rs.sample)
+			}
+		}
+		return
+	}
+	if callsite == "" {
+		return
+	}
+	wd.route, wd.routes = callsite, reg
+	reg.record(callsite)
+}
+
+// learnRoute records resource as the sample of an unsampled route, once.
+func (wd *wrapped) learnRoute(resource Resource) {
+	if wd.routes != nil && wd.learned.CompareAndSwap(false, true) {
+		wd.routes.learn(wd.route, resource)
+	}
+}
+
+// NamespaceLister loads the namespace configs of check. Client and
+// SessionClient implement it.
+type NamespaceLister interface {
+	ListNamespaces(ctx context.Context) ([]NamespaceMeta, error)
+}
+
+// RouteMismatch is a route whose resource requires a namespace or relation
+// check has not loaded. Err matches ErrUnknownNamespace or ErrUnknownRelation,
+// or describes a panic of Requires.
+type RouteMismatch struct {
+	Route  string
+	Method string
+	Ns     Ns
+	Rel    Rel
+	Err    error
+}
+
+func (m RouteMismatch) Error() string {
+	return fmt.Sprintf("%s (%s): requires %s#%s: %v", m.Route, m.Method, m.Ns, m.Rel, m.Err)
+}
+
+// VerifyRoutes verifies the routes of DefaultRouteRegistry against the schema
+// of client; see RouteRegistry.Verify.
+func VerifyRoutes(ctx context.Context, client NamespaceLister, methods []string) ([]RouteMismatch, error) {
+	return DefaultRouteRegistry.Verify(ctx, client, methods)
+}
+
+// Verify calls Requires of every recorded sample for each method (nil:
+// DefaultVerifyMethods) and returns the ns/rel pairs not declared in
+// ListNamespaces, and likewise the Check leaves of a PolicyResource's policy.
+// Methods a resource answers with Impossible are skipped; a route without a
+// sample is reported once with ErrNoRouteSample. The error is non-nil only
+// when the schema could not be loaded.
+func (reg *RouteRegistry) Verify(ctx context.Context, client NamespaceLister, methods []string) ([]RouteMismatch, error) {
+	if methods == nil {
+		methods = DefaultVerifyMethods
+	}
+	metas, err := client.ListNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("verify routes: %w", err)
+	}
+	schema := NewSchema(metas)
+	var mismatches []RouteMismatch
+	for _, rs := range reg.Routes() {
+		if rs.Resource == nil {
+			mismatches = append(mismatches, RouteMismatch{Route: rs.Route, Err: ErrNoRouteSample})
+			continue
+		}
+		for _, method := range methods {
+			ns, _, rel, err := requiresOf(rs.Resource, method)
+			if err == nil && rel != Impossible {
+				err = schema.ValidateRelation(ns, rel)
+			}
+			if err != nil {
+				mismatches = append(mismatches, RouteMismatch{Route: rs.Route, Method: method, Ns: ns, Rel: rel, Err: err})
+			}
+			for _, leaf := range policyLeavesOf(rs.Resource, method) {
+				if err := schema.ValidateRelation(leaf.Ns, leaf.Rel); err != nil {
+					mismatches = append(mismatches, RouteMismatch{Route: rs.Route, Method: method, Ns: leaf.Ns, Rel: leaf.Rel, Err: err})
+				}
+			}
+		}
+	}
+	return mismatches, nil
+}
+
+// requiresOf calls r.Requires(method), turning a panic (e.g. on an
+// unsupported method) into an error.
+func requiresOf(r Resource, method string) (ns Ns, obj Obj, rel Rel, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("Requires panicked: %v", p)
+		}
+	}()
+	ns, obj, rel = r.Requires(method)
+	return ns, obj, rel, nil
+}
+
+// policyLeavesOf returns the Check leaves of r's policy for method, if r is a
+// PolicyResource. A Policy that panics yields no leaves.
+func policyLeavesOf(r Resource, method string) (leaves []CheckItem) {
+	pr, ok := r.(PolicyResource)
+	if !ok {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			leaves = nil
+		}
+	}()
+	if p := pr.Policy(method); p != nil {
+		leaves = p.leaves(nil)
+	}
+	return leaves
+}
@@ -0,0 +1,105 @@
+package nioclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// articleResource requires article.get on GET and a misspelled relation on
+// PUT; DELETE is not supported.
+type articleResource struct{}
+
+func (articleResource) Requires(method string) (Ns, Obj, Rel) {
+	switch method {
+	case http.MethodGet:
+		return "article", "a1", "article.get"
+	case http.MethodPut:
+		return "article", "a1", "article.updte"
+	}
+	return "article", "a1", Impossible
+}
+
+type staticNamespaces []NamespaceMeta
+
+func (s staticNamespaces) ListNamespaces(context.Context) ([]NamespaceMeta, error) {
+	return s, nil
+}
+
+func TestVerifyRoutesReportsMismatches(t *testing.T) {
+	reg := &RouteRegistry{}
+	Wrap(&resolvingWrapper{}, extractTest, okHandler, reg.Sample("/articles/:id", articleResource{}))
+	Wrap(&resolvingWrapper{}, extractTest, okHandler, reg.Sample("/comments/:id", testResource{}), WithRequestMemo())
+	schema := staticNamespaces{{Name: "article", Relations: []RelationMeta{
+		{Name: "article.get", Kind: KindComputed},
+		{Name: "article.update", Kind: KindComputed},
+	}}}
+
+	got, err := reg.Verify(t.Context(), schema, []string{http.MethodGet, http.MethodPut, http.MethodDelete})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("mismatches = %v, want only PUT /articles/:id", got)
+	}
+	m := got[0]
+	if m.Route != "/articles/:id" || m.Method != http.MethodPut || m.Rel != "article.updte" || !errors.Is(m.Err, ErrUnknownRelation) {
+		t.Fatalf("mismatch = %+v", m)
+	}
+}
+
+type panickingResource struct{}
+
+func (panickingResource) Requires(method string) (Ns, Obj, Rel) {
+	if method != http.MethodGet {
+		panic("unsupported method " + method)
+	}
+	return "ledger", "l1", "ledger.get"
+}
+
+func TestVerifyRoutesUnknownNamespaceAndPanic(t *testing.T) {
+	reg := &RouteRegistry{}
+	reg.Register("/ledgers/:id", panickingResource{})
+
+	got, err := reg.Verify(t.Context(), staticNamespaces{}, []string{http.MethodGet, http.MethodPost})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !errors.Is(got[0].Err, ErrUnknownNamespace) || got[1].Method != http.MethodPost {
+		t.Fatalf("mismatches = %v", got)
+	}
+}
+
+func TestRouteRegistryRecordsEveryRouteOnce(t *testing.T) {
+	reg := &RouteRegistry{}
+	build := func() (sampled, unsampled httprouter.Handle) {
+		sampled = Wrap(&resolvingWrapper{}, extractTest, okHandler, WithRouteRegistry(reg), WithRouteSample("/articles/:id", articleResource{}))
+		unsampled = Wrap(&resolvingWrapper{}, extractTest, okHandler, WithRouteRegistry(reg))
+		return sampled, unsampled
+	}
+	build()
+	_, unsampled := build() // a router built twice records no duplicates
+
+	routes := reg.Routes()
+	if len(routes) != 2 || routes[0].Route != "/articles/:id" || routes[1].Resource != nil {
+		t.Fatalf("routes = %+v, want the sampled route and one unsampled call site", routes)
+	}
+	schema := staticNamespaces{{Name: "article", Relations: []RelationMeta{{Name: "article.get", Kind: KindComputed}}}}
+	got, err := reg.Verify(t.Context(), schema, []string{http.MethodGet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Route != routes[1].Route || !errors.Is(got[0].Err, ErrNoRouteSample) {
+		t.Fatalf("mismatches = %v, want the unsampled route", got)
+	}
+
+	// The first request supplies the sample of the unsampled route.
+	unsampled(httptest.NewRecorder(), requestWithSession("tok"), nil)
+	if got, err := reg.Verify(t.Context(), schema, []string{http.MethodGet}); err != nil || len(got) != 0 {
+		t.Fatalf("mismatches after a request = %v, %v", got, err)
+	}
+}
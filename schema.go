@@ -0,0 +1,257 @@
+package nioclient
+
+// Schema is the namespace configuration check has loaded (ListNamespaces),
+// indexed for lookups. Tuples and check requests can be validated against it
+// before a round-trip, and a client can do so for every Check and Write with
+// WithSchemaValidation, which keeps a cached copy fresh.
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Rewrite kinds of RelationMeta.Kind.
+const (
+	KindThis     = "this"     // stored tuples only: a role
+	KindComputed = "computed" // computed from another relation of the object
+	KindTupleTo  = "tuple_to" // inherited through a linked object
+	KindUnion    = "union"    // union of rewrites, possibly including this
+)
+
+// Schema is an immutable view of the loaded namespace configs. Build it with
+// NewSchema or checkAPI.LoadSchema.
+type Schema struct {
+	namespaces []NamespaceMeta
+	kinds      map[Ns]map[Rel]string
+}
+
+// NewSchema indexes namespace metadata as returned by ListNamespaces.
+func NewSchema(namespaces []NamespaceMeta) *Schema {
+	s := &Schema{
+		namespaces: slices.Clone(namespaces),
+		kinds:      make(map[Ns]map[Rel]string, len(namespaces)),
+	}
+	slices.SortFunc(s.namespaces, func(a, b NamespaceMeta) int { return cmp.Compare(a.Name, b.Name) })
+	for _, ns := range s.namespaces {
+		rels := make(map[Rel]string, len(ns.Relations))
+		for _, r := range ns.Relations {
+			rels[Rel(r.Name)] = r.Kind
+		}
+		s.kinds[Ns(ns.Name)] = rels
+	}
+	return s
+}
+
+// LoadSchema fetches the namespace configs from check and indexes them.
+func (c *checkAPI) LoadSchema(ctx context.Context) (*Schema, error) {
+	metas, err := c.ListNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchema(metas), nil
+}
+
+// Namespaces returns the loaded namespace configs, sorted by name.
+func (s *Schema) Namespaces() []NamespaceMeta {
+	return slices.Clone(s.namespaces)
+}
+
+// HasNamespace reports whether ns is loaded.
+func (s *Schema) HasNamespace(ns Ns) bool {
+	_, ok := s.kinds[ns]
+	return ok
+}
+
+// HasRelation reports whether rel is declared in ns.
+func (s *Schema) HasRelation(ns Ns, rel Rel) bool {
+	_, ok := s.kinds[ns][rel]
+	return ok
+}
+
+// KindOf returns the rewrite kind of ⟨ns, rel⟩ (one of the Kind constants);
+// ok=false when the relation is not declared.
+func (s *Schema) KindOf(ns Ns, rel Rel) (kind string, ok bool) {
+	kind, ok = s.kinds[ns][rel]
+	return kind, ok
+}
+
+// Roles returns the relations of ns that are stored tuples only (KindThis),
+// in declaration order.
+func (s *Schema) Roles(ns Ns) []Rel {
+	return s.relations(ns, func(kind string) bool { return kind == KindThis })
+}
+
+// Permissions returns the relations of ns that are computed by rewrites
+// (every kind but KindThis), in declaration order.
+func (s *Schema) Permissions(ns Ns) []Rel {
+	return s.relations(ns, func(kind string) bool { return kind != KindThis })
+}
+
+func (s *Schema) relations(ns Ns, keep func(kind string) bool) []Rel {
+	i, ok := slices.BinarySearchFunc(s.namespaces, ns, func(m NamespaceMeta, ns Ns) int { return cmp.Compare(m.Name, string(ns)) })
+	if !ok {
+		return nil
+	}
+	var out []Rel
+	for _, r := range s.namespaces[i].Relations {
+		if keep(r.Kind) {
+			out = append(out, Rel(r.Name))
+		}
+	}
+	return out
+}
+
+// ValidateRelation returns an error matching ErrUnknownNamespace or
+// ErrUnknownRelation when ⟨ns, rel⟩ is not declared.
+func (s *Schema) ValidateRelation(ns Ns, rel Rel) error {
+	rels, ok := s.kinds[ns]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownNamespace, ns)
+	}
+	if _, ok := rels[rel]; !ok {
+		return fmt.Errorf("%w %q in namespace %q", ErrUnknownRelation, rel, ns)
+	}
+	return nil
+}
+
+// ValidateTuple checks the Tuple contract (see WriteBatch.Validate) and that
+// the tuple's namespace and relation are declared. A userset subject must name
+// a loaded namespace and a declared relation or RelUnspecified.
+func (s *Schema) ValidateTuple(t Tuple) error {
+	if err := validateTuple(t); err != nil {
+		return err
+	}
+	if err := s.ValidateRelation(t.Ns, t.Rel); err != nil {
+		return fmt.Errorf("%s: %w", t, err)
+	}
+	if us := t.UserSet; us != nil {
+		if us.Rel == RelUnspecified && s.HasNamespace(us.Ns) {
+			return nil
+		}
+		if err := s.ValidateRelation(us.Ns, us.Rel); err != nil {
+			return fmt.Errorf("%s: subject: %w", t, err)
+		}
+	}
+	return nil
+}
+
+// DefaultSchemaRefresh is how often a validating client reloads the schema
+// when WithSchemaValidation is given a non-positive interval.
+const DefaultSchemaRefresh = 5 * time.Minute
+
+// WithSchemaValidation validates every Check and Write against a cached
+// schema before the RPC, so unknown namespaces and relations fail fast with
+// ErrUnknownNamespace / ErrUnknownRelation. The schema is loaded on first use
+// and reloaded in the background once older than refresh (<= 0:
+// DefaultSchemaRefresh). While no schema could be loaded, calls are not
+// validated locally; check still rejects them.
+func WithSchemaValidation(refresh time.Duration) ClientOption {
+	if refresh <= 0 {
+		refresh = DefaultSchemaRefresh
+	}
+	return func(o *clientOptions) {
+		o.schemaRefresh = refresh
+	}
+}
+
+// schemaCache holds the schema of a validating client.
+type schemaCache struct {
+	refresh time.Duration
+	load    func(ctx context.Context) (*Schema, error)
+
+	mu        sync.Mutex
+	schema    *Schema
+	loadedAt  time.Time
+	loading   bool          // a load is in flight
+	firstLoad chan struct{} // closed when the first load attempt finished
+}
+
+func newSchemaCache(refresh time.Duration, load func(ctx context.Context) (*Schema, error)) *schemaCache {
+	return &schemaCache{refresh: refresh, load: load, firstLoad: make(chan struct{})}
+}
+
+// get returns the cached schema, or nil when none could be loaded. The first
+// call waits for the initial load (bounded by ctx); a stale schema is returned
+// immediately while a reload runs in the background.
+func (sc *schemaCache) get(ctx context.Context) *Schema {
+	sc.mu.Lock()
+	schema, stale := sc.schema, time.Since(sc.loadedAt) >= sc.refresh
+	if stale && !sc.loading {
+		sc.loading = true
+		go sc.reload()
+	}
+	sc.mu.Unlock()
+	if schema != nil {
+		return schema
+	}
+	select {
+	case <-sc.firstLoad:
+	case <-ctx.Done():
+		return nil
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.schema
+}
+
+// reload loads the schema without the caller's context, so a cancelled
+// request does not abort a load other calls wait for.
+func (sc *schemaCache) reload() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	schema, err := sc.load(ctx)
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.loading = false
+	sc.loadedAt = time.Now()
+	if err != nil {
+		log.Printf("schema validation: load schema: %v", err)
+	} else {
+		sc.schema = schema
+	}
+	select {
+	case <-sc.firstLoad:
+	default:
+		close(sc.firstLoad)
+	}
+}
+
+// validateCheck validates ⟨ns, rel⟩ against the cached schema of a validating
+// client; nil when validation is off or no schema is loaded.
+func (c *checkAPI) validateCheck(ctx context.Context, ns Ns, rel Rel) error {
+	if c.schema == nil {
+		return nil
+	}
+	s := c.schema.get(ctx)
+	if s == nil {
+		return nil
+	}
+	return s.ValidateRelation(ns, rel)
+}
+
+// validateWrite validates the tuples of a Write like validateCheck.
+func (c *checkAPI) validateWrite(ctx context.Context, add, del []Tuple) error {
+	if c.schema == nil {
+		return nil
+	}
+	s := c.schema.get(ctx)
+	if s == nil {
+		return nil
+	}
+	for i, t := range add {
+		if err := s.ValidateTuple(t); err != nil {
+			return fmt.Errorf("add[%d]: %w", i, err)
+		}
+	}
+	for i, t := range del {
+		if err := s.ValidateTuple(t); err != nil {
+			return fmt.Errorf("del[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
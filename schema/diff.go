@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	nioclient "github.com/ecociel/nioclient-go"
+)
+
+// DriftKind classifies a difference between namespace files and a server.
+type DriftKind string
+
+const (
+	DriftMissingNamespace DriftKind = "missing-namespace" // in the files, not loaded by the server
+	DriftExtraNamespace   DriftKind = "extra-namespace"   // loaded by the server, not in the files
+	DriftMissingRelation  DriftKind = "missing-relation"  // declared in the files, not by the server
+	DriftExtraRelation    DriftKind = "extra-relation"    // declared by the server, not in the files
+)
+
+// Drift is one difference found by Diff. Rel is empty for namespace drift.
+type Drift struct {
+	Kind DriftKind
+	Ns   string
+	Rel  string
+}
+
+func (d Drift) String() string {
+	if d.Rel == "" {
+		return fmt.Sprintf("%s: %s", d.Kind, d.Ns)
+	}
+	return fmt.Sprintf("%s: %s#%s", d.Kind, d.Ns, d.Rel)
+}
+
+// Diff compares the namespace files with the configs a server reports and
+// returns the namespaces and relations (roles and permissions) on one side
+// only, sorted by namespace and relation. Rewrite kinds are not compared: the
+// files do not determine them exactly (see Namespace.Meta).
+func Diff(local []Namespace, live []nioclient.NamespaceMeta) []Drift {
+	relations := func(metas []nioclient.NamespaceMeta) map[string]map[string]bool {
+		out := make(map[string]map[string]bool, len(metas))
+		for _, m := range metas {
+			rels := make(map[string]bool, len(m.Relations))
+			for _, r := range m.Relations {
+				rels[r.Name] = true
+			}
+			out[m.Name] = rels
+		}
+		return out
+	}
+	want, have := relations(Metas(local)), relations(live)
+
+	var drift []Drift
+	for ns, rels := range want {
+		haveRels, ok := have[ns]
+		if !ok {
+			drift = append(drift, Drift{Kind: DriftMissingNamespace, Ns: ns})
+			continue
+		}
+		for rel := range rels {
+			if !haveRels[rel] {
+				drift = append(drift, Drift{Kind: DriftMissingRelation, Ns: ns, Rel: rel})
+			}
+		}
+		for rel := range haveRels {
+			if !rels[rel] {
+				drift = append(drift, Drift{Kind: DriftExtraRelation, Ns: ns, Rel: rel})
+			}
+		}
+	}
+	for ns := range have {
+		if _, ok := want[ns]; !ok {
+			drift = append(drift, Drift{Kind: DriftExtraNamespace, Ns: ns})
+		}
+	}
+	slices.SortFunc(drift, func(a, b Drift) int {
+		return cmp.Or(cmp.Compare(a.Ns, b.Ns), cmp.Compare(a.Rel, b.Rel), cmp.Compare(a.Kind, b.Kind))
+	})
+	return drift
+}
+
+// DiffServer diffs the namespace files against ListNamespaces of client.
+func DiffServer(ctx context.Context, client nioclient.NamespaceLister, local []Namespace) ([]Drift, error) {
+	live, err := client.ListNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("diff namespaces: %w", err)
+	}
+	return Diff(local, live), nil
+}
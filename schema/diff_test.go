@@ -0,0 +1,39 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	nioclient "github.com/ecociel/nioclient-go"
+)
+
+type staticNamespaces []nioclient.NamespaceMeta
+
+func (s staticNamespaces) ListNamespaces(context.Context) ([]nioclient.NamespaceMeta, error) {
+	return s, nil
+}
+
+func TestDiffServer(t *testing.T) {
+	local := []Namespace{
+		{Name: "doc", Roles: []Role{{Name: "owner", Permissions: []string{"doc.get", "doc.delete"}}}},
+		{Name: "folder", Roles: []Role{{Name: "viewer"}}},
+	}
+	live := staticNamespaces{
+		{Name: "doc", Relations: []nioclient.RelationMeta{
+			{Name: "owner", Kind: nioclient.KindThis},
+			{Name: "doc.get", Kind: nioclient.KindUnion}, // kind differs: not drift
+			{Name: "doc.update", Kind: nioclient.KindComputed},
+		}},
+		{Name: "wiki"},
+	}
+	drift, err := DiffServer(t.Context(), live, local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(drift)
+	want := "[missing-relation: doc#doc.delete extra-relation: doc#doc.update missing-namespace: folder extra-namespace: wiki]"
+	if got != want {
+		t.Fatalf("drift = %s\nwant    %s", got, want)
+	}
+}
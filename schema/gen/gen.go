@@ -0,0 +1,297 @@
+// Package gen generates Go code from namespace configs: typed constants for
+// namespaces and relations, and a Resource skeleton per namespace. cmd/nio-gen
+// is its command line.
+package gen
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"go/format"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	nioclient "github.com/ecociel/nioclient-go"
+)
+
+// DefaultWords spells the name segments of the nioclient namespaces that are
+// several words, so that the generated constants match the ones nioclient
+// declares (NsServiceAccount, RelServiceAccountCreate).
+var DefaultWords = map[string]string{
+	"serviceaccount": "ServiceAccount",
+}
+
+// Options configures Constants and Resources.
+type Options struct {
+	// Package is the package name of the generated file.
+	Package string
+	// Words spells name segments made of several words, e.g.
+	// "userprofile": "UserProfile", in addition to DefaultWords. Other
+	// segments are capitalized.
+	Words map[string]string
+}
+
+// Constants writes a Go file declaring a typed constant per namespace
+// (NsProject) and per relation name (RelProjectGet). A relation declared in
+// several namespaces gets one constant. Names that do not yield an
+// identifier, such as "...", are skipped; two names yielding the same
+// identifier are an error.
+func Constants(w io.Writer, namespaces []nioclient.NamespaceMeta, opts Options) error {
+	m, err := newModel(namespaces, opts)
+	if err != nil {
+		return err
+	}
+	return render(w, constantsTemplate, m)
+}
+
+// Resources writes a Go file with a Resource struct per namespace whose
+// Requires maps HTTP methods to the namespace's permissions by their last
+// dotted segment (get: GET and HEAD, create: POST, update: PUT and PATCH,
+// delete: DELETE) and everything else to Impossible. When several permissions
+// fit a method and none is the only one named after the namespace
+// ("project.get" in project), the method is left as a TODO listing them. It
+// is a skeleton to edit; it expects the constants of Constants in the same
+// package.
+func Resources(w io.Writer, namespaces []nioclient.NamespaceMeta, opts Options) error {
+	m, err := newModel(namespaces, opts)
+	if err != nil {
+		return err
+	}
+	return render(w, resourcesTemplate, m)
+}
+
+type genModel struct {
+	Package    string
+	Namespaces []genNamespace
+	Relations  []genConst
+}
+
+// UsesHTTP reports whether a generated Requires switches on http.Method*.
+func (m genModel) UsesHTTP() bool {
+	return slices.ContainsFunc(m.Namespaces, genNamespace.HasCases)
+}
+
+type genConst struct {
+	Ident, Value string
+}
+
+type genNamespace struct {
+	genConst
+	Type    string
+	Methods []genMethod
+}
+
+// HasCases reports whether Requires maps at least one method to a relation.
+func (n genNamespace) HasCases() bool {
+	return slices.ContainsFunc(n.Methods, func(m genMethod) bool { return m.RelIdent != "" })
+}
+
+type genMethod struct {
+	Methods  string // e.g. "http.MethodGet, http.MethodHead"
+	RelIdent string
+	// Candidates lists the permissions fitting Methods when RelIdent could
+	// not be chosen.
+	Candidates string
+}
+
+// methodsByVerb maps a permission's last segment to the methods it guards.
+var methodsByVerb = []struct {
+	verb    string
+	methods string
+}{
+	{"get", "http.MethodGet, http.MethodHead"},
+	{"create", "http.MethodPost"},
+	{"update", "http.MethodPut, http.MethodPatch"},
+	{"delete", "http.MethodDelete"},
+}
+
+func newModel(namespaces []nioclient.NamespaceMeta, opts Options) (genModel, error) {
+	m := genModel{Package: opts.Package}
+	words := maps.Clone(DefaultWords)
+	maps.Copy(words, opts.Words)
+	identifier := func(name string) string { return identifier(name, words) }
+
+	idents := make(map[string]string) // ident -> name, to detect clashes
+	declare := func(prefix, name string) (string, bool, error) {
+		ident := identifier(name)
+		if ident == "" {
+			return "", false, nil
+		}
+		ident = prefix + ident
+		if prev, ok := idents[ident]; ok {
+			if prev == prefix+name {
+				return ident, false, nil
+			}
+			return "", false, fmt.Errorf("%q and %q both generate %s", strings.TrimPrefix(prev, prefix), name, ident)
+		}
+		idents[ident] = prefix + name
+		return ident, true, nil
+	}
+
+	for _, ns := range namespaces {
+		nsIdent, _, err := declare("Ns", ns.Name)
+		if err != nil {
+			return genModel{}, err
+		}
+		if nsIdent == "" {
+			continue
+		}
+		gn := genNamespace{
+			genConst: genConst{Ident: nsIdent, Value: ns.Name},
+			Type:     identifier(ns.Name) + "Resource",
+		}
+		byVerb := make(map[string][]string) // verb -> permissions
+		for _, r := range ns.Relations {
+			relIdent, isNew, err := declare("Rel", r.Name)
+			if err != nil {
+				return genModel{}, err
+			}
+			if relIdent == "" {
+				continue
+			}
+			if isNew {
+				m.Relations = append(m.Relations, genConst{Ident: relIdent, Value: r.Name})
+			}
+			if r.Kind == nioclient.KindThis {
+				continue
+			}
+			verb := r.Name[strings.LastIndexByte(r.Name, '.')+1:]
+			byVerb[verb] = append(byVerb[verb], r.Name)
+		}
+		for _, v := range methodsByVerb {
+			candidates := byVerb[v.verb]
+			if len(candidates) == 0 {
+				continue
+			}
+			gm := genMethod{Methods: v.methods}
+			if rel, ok := choose(ns.Name, candidates); ok {
+				gm.RelIdent = "Rel" + identifier(rel)
+			} else {
+				gm.Candidates = strings.Join(candidates, ", ")
+			}
+			gn.Methods = append(gn.Methods, gm)
+		}
+		m.Namespaces = append(m.Namespaces, gn)
+	}
+	slices.SortFunc(m.Namespaces, func(a, b genNamespace) int { return cmp.Compare(a.Ident, b.Ident) })
+	slices.SortFunc(m.Relations, func(a, b genConst) int { return cmp.Compare(a.Ident, b.Ident) })
+	return m, nil
+}
+
+// choose picks the Requires default among the permissions of namespace ns
+// fitting one verb: the only one, or the only one named after the namespace
+// ("project.get" in project). ok is false when neither exists.
+func choose(ns string, candidates []string) (rel string, ok bool) {
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	own := slices.DeleteFunc(slices.Clone(candidates), func(c string) bool { return !strings.HasPrefix(c, ns+".") })
+	if len(own) == 1 {
+		return own[0], true
+	}
+	return "", false
+}
+
+// identifier turns a relation or namespace name into an exported Go
+// identifier: each segment between non-alphanumerics is spelled as in words,
+// else capitalized, so "serviceaccount.createToken" becomes
+// ServiceAccountCreateToken. Returns "" when the name has no letters or starts
+// with a digit.
+func identifier(name string, words map[string]string) string {
+	var b strings.Builder
+	for _, seg := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if w, ok := words[seg]; ok {
+			b.WriteString(w)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(seg)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(seg[size:])
+	}
+	ident := b.String()
+	if ident == "" || !unicode.IsLetter([]rune(ident)[0]) {
+		return ""
+	}
+	return ident
+}
+
+func render(w io.Writer, t *template.Template, m genModel) error {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, m); err != nil {
+		return err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("format generated code: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+var constantsTemplate = template.Must(template.New("constants").Parse(`// Code generated by nio-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import nioclient "github.com/ecociel/nioclient-go"
+
+// Namespaces.
+const (
+{{- range .Namespaces}}
+	{{.Ident}} = nioclient.Ns({{printf "%q" .Value}})
+{{- end}}
+)
+
+// Relations of all namespaces.
+const (
+{{- range .Relations}}
+	{{.Ident}} = nioclient.Rel({{printf "%q" .Value}})
+{{- end}}
+)
+`))
+
+var resourcesTemplate = template.Must(template.New("resources").Parse(`// Generated by nio-gen as a starting point; edit freely.
+
+package {{.Package}}
+
+import (
+{{- if .UsesHTTP}}
+	"net/http"
+{{end}}
+	nioclient "github.com/ecociel/nioclient-go"
+)
+{{range .Namespaces}}
+// {{.Type}} is an object of the {{.Value}} namespace.
+type {{.Type}} struct {
+	Obj nioclient.Obj
+}
+
+// Requires returns the relation a request with method needs on the object.
+func (r *{{.Type}}) Requires(method string) (nioclient.Ns, nioclient.Obj, nioclient.Rel) {
+{{- if .HasCases}}
+	switch method {
+{{- $ns := .Ident}}
+{{- range .Methods}}
+{{- if .RelIdent}}
+	case {{.Methods}}:
+		return {{$ns}}, r.Obj, {{.RelIdent}}
+{{- else}}
+	// TODO: {{.Methods}}: one of {{.Candidates}}.
+{{- end}}
+{{- end}}
+	}
+{{- else}}
+{{- range .Methods}}
+	// TODO: {{.Methods}}: one of {{.Candidates}}.
+{{- end}}
+	// TODO: map methods to relations of {{.Value}}.
+{{- end}}
+	return {{.Ident}}, r.Obj, nioclient.Impossible
+}
+{{end}}`))
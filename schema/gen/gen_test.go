@@ -0,0 +1,180 @@
+package gen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	nioclient "github.com/ecociel/nioclient-go"
+	"github.com/ecociel/nioclient-go/schema"
+)
+
+func composeMetas(t *testing.T) []nioclient.NamespaceMeta {
+	namespaces, err := schema.ParseCompose("../../docker-compose.yml", "namespaces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema.Metas(namespaces)
+}
+
+// constants returns the Ns(...) and Rel(...) constants of a Go file by value.
+func constants(t *testing.T, filename string, src any) map[string]string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), filename, src, 0)
+	if err != nil {
+		t.Fatalf("%s does not parse: %v\n%s", filename, err, src)
+	}
+	byValue := make(map[string]string)
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || len(spec.Names) != 1 || len(spec.Values) != 1 {
+			return true
+		}
+		call, ok := spec.Values[0].(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		var kind string
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			kind = fun.Name
+		case *ast.SelectorExpr:
+			kind = fun.Sel.Name
+		}
+		if kind != "Ns" && kind != "Rel" {
+			return true
+		}
+		value, _ := strconv.Unquote(lit.Value)
+		byValue[kind+" "+value] = spec.Names[0].Name
+		return true
+	})
+	return byValue
+}
+
+func TestGenerateConstants(t *testing.T) {
+	var out bytes.Buffer
+	if err := Constants(&out, composeMetas(t), Options{Package: "authz"}); err != nil {
+		t.Fatal(err)
+	}
+	src := out.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "relations.go", src, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+	for _, want := range []string{
+		"// Code generated by nio-gen. DO NOT EDIT.",
+		`NsServiceAccount = nioclient.Ns("serviceaccount")`,
+		`RelServiceAccountCreateToken = nioclient.Rel("serviceaccount.createToken")`,
+		`RelProjectGet                = nioclient.Rel("project.get")`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("missing %q in\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, `"..."`) {
+		t.Errorf("... relation generated:\n%s", src)
+	}
+	if n := strings.Count(src, `nioclient.Rel("admin")`); n != 1 {
+		t.Errorf("admin declared %d times, want once across namespaces", n)
+	}
+}
+
+// TestGenerateConstantsMatchClient generates the constants of the
+// docker-compose namespaces and diffs them against the ones client.go
+// declares: every name both declare must be spelled the same.
+func TestGenerateConstantsMatchClient(t *testing.T) {
+	var out bytes.Buffer
+	if err := Constants(&out, composeMetas(t), Options{Package: "authz"}); err != nil {
+		t.Fatal(err)
+	}
+	generated := constants(t, "relations.go", out.Bytes())
+	client := constants(t, "../../client.go", nil)
+	shared := 0
+	for value, want := range client {
+		got, ok := generated[value]
+		if !ok {
+			continue
+		}
+		shared++
+		if got != want {
+			t.Errorf("%s: generated %s, client.go declares %s", value, got, want)
+		}
+	}
+	if shared < 10 {
+		t.Fatalf("only %d constants shared with client.go; fixture out of date?", shared)
+	}
+}
+
+func TestGenerateWords(t *testing.T) {
+	metas := []nioclient.NamespaceMeta{{Name: "userprofile", Relations: []nioclient.RelationMeta{
+		{Name: "userprofile.get", Kind: nioclient.KindComputed},
+	}}}
+	var out bytes.Buffer
+	if err := Constants(&out, metas, Options{Package: "authz", Words: map[string]string{"userprofile": "UserProfile"}}); err != nil {
+		t.Fatal(err)
+	}
+	if src := out.String(); !strings.Contains(src, "NsUserProfile ") || !strings.Contains(src, "RelUserProfileGet ") {
+		t.Fatalf("words not applied:\n%s", src)
+	}
+}
+
+func TestGenerateResourcesAmbiguousVerb(t *testing.T) {
+	metas := []nioclient.NamespaceMeta{{Name: "doc", Relations: []nioclient.RelationMeta{
+		{Name: "folder.get", Kind: nioclient.KindComputed},
+		{Name: "share.get", Kind: nioclient.KindComputed},
+		{Name: "doc.delete", Kind: nioclient.KindComputed},
+	}}}
+	var out bytes.Buffer
+	if err := Resources(&out, metas, Options{Package: "authz"}); err != nil {
+		t.Fatal(err)
+	}
+	src := out.String()
+	for _, want := range []string{
+		"// TODO: http.MethodGet, http.MethodHead: one of folder.get, share.get.",
+		"case http.MethodDelete:\n\t\treturn NsDoc, r.Obj, RelDocDelete",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("missing %q in\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateResources(t *testing.T) {
+	var out bytes.Buffer
+	if err := Resources(&out, composeMetas(t), Options{Package: "authz"}); err != nil {
+		t.Fatal(err)
+	}
+	src := out.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "resources.go", src, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+	for _, want := range []string{
+		"func (r *ProjectResource) Requires(method string) (nioclient.Ns, nioclient.Obj, nioclient.Rel) {",
+		"case http.MethodGet, http.MethodHead:\n\t\treturn NsProject, r.Obj, RelProjectGet",
+		"case http.MethodDelete:\n\t\treturn NsProject, r.Obj, RelProjectDelete",
+		"// TODO: http.MethodGet, http.MethodHead: one of serviceaccount.get, serviceaccount.key.get, iam.get.",
+		"// TODO: map methods to relations of token.",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("missing %q in\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateRejectsClashingNames(t *testing.T) {
+	metas := []nioclient.NamespaceMeta{{Name: "doc", Relations: []nioclient.RelationMeta{
+		{Name: "doc.get", Kind: nioclient.KindComputed},
+		{Name: "doc_get", Kind: nioclient.KindComputed},
+	}}}
+	err := Constants(&bytes.Buffer{}, metas, Options{Package: "authz"})
+	if err == nil || !strings.Contains(err.Error(), "RelDocGet") {
+		t.Fatalf("err = %v, want clash on RelDocGet", err)
+	}
+}
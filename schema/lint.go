@@ -0,0 +1,176 @@
+package schema
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Rule names a lint check.
+type Rule string
+
+// Lint rules.
+const (
+	RuleDuplicateNamespace  Rule = "duplicate-namespace"  // two documents with one name
+	RuleDuplicateRole       Rule = "duplicate-role"       // a role declared twice in a namespace
+	RuleUndefinedInherit    Rule = "undefined-inherit"    // inherit names no role of the namespace
+	RuleUngrantedPermission Rule = "ungranted-permission" // a required relation no role grants
+	RuleUndefinedPolicy     Rule = "undefined-policy"     // a policy grants an undeclared role
+	RuleUnreachableRole     Rule = "unreachable-role"     // no policy grants the role, directly or inherited
+)
+
+// Severity of a Finding. Errors make check reject or misevaluate the config;
+// warnings are likely mistakes.
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Finding is one lint result. Rel is the role or permission concerned, empty
+// for namespace-level findings.
+type Finding struct {
+	Rule     Rule
+	Severity Severity
+	Ns       string
+	Rel      string
+	Message  string
+}
+
+func (f Finding) String() string {
+	where := f.Ns
+	if f.Rel != "" {
+		where += "#" + f.Rel
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Severity, where, f.Message, f.Rule)
+}
+
+// Relation is a relation of a namespace, such as one an application checks.
+type Relation struct {
+	Ns  string
+	Rel string
+}
+
+// LintOptions enables the checks that need more than the namespace files.
+type LintOptions struct {
+	// Policies are the bootstrap grants. When non-nil, policies granting an
+	// undeclared role are errors and roles that no policy grants — directly
+	// or, for roles with inherit, through the same role elsewhere — are
+	// warnings. Roles used as an inherit link are written by applications and
+	// exempt.
+	Policies []Policy
+	// Required are the relations the application checks (e.g. the Requires of
+	// its routes); those no role declares or grants are errors.
+	Required []Relation
+}
+
+// Lint checks namespace configs for mistakes check would not report. Findings
+// are sorted by namespace, relation and rule.
+func Lint(namespaces []Namespace, opts LintOptions) []Finding {
+	var findings []Finding
+	report := func(rule Rule, sev Severity, ns, rel, format string, args ...any) {
+		findings = append(findings, Finding{Rule: rule, Severity: sev, Ns: ns, Rel: rel, Message: fmt.Sprintf(format, args...)})
+	}
+
+	byName := make(map[string]Namespace, len(namespaces))
+	links := make(map[Relation]bool) // inherit targets
+	for _, ns := range namespaces {
+		if _, dup := byName[ns.Name]; dup {
+			report(RuleDuplicateNamespace, SeverityError, ns.Name, "", "namespace declared more than once")
+			continue
+		}
+		byName[ns.Name] = ns
+		roles := make(map[string]bool, len(ns.Roles))
+		for _, r := range ns.Roles {
+			if roles[r.Name] {
+				report(RuleDuplicateRole, SeverityError, ns.Name, r.Name, "role declared more than once")
+			}
+			roles[r.Name] = true
+		}
+		for _, r := range ns.Roles {
+			if r.Inherit == "" {
+				continue
+			}
+			links[Relation{ns.Name, r.Inherit}] = true
+			if !roles[r.Inherit] {
+				report(RuleUndefinedInherit, SeverityError, ns.Name, r.Name, "inherits through %q, which is not a role of %s", r.Inherit, ns.Name)
+			}
+		}
+	}
+
+	for _, req := range opts.Required {
+		ns, ok := byName[req.Ns]
+		switch {
+		case !ok:
+			report(RuleUngrantedPermission, SeverityError, req.Ns, req.Rel, "required, but namespace %s is not declared", req.Ns)
+		case !ns.grants(req.Rel):
+			report(RuleUngrantedPermission, SeverityError, req.Ns, req.Rel, "required, but no role of %s grants it", req.Ns)
+		}
+	}
+
+	if opts.Policies != nil {
+		reachable := make(map[Relation]bool)
+		for _, p := range opts.Policies {
+			ns, ok := byName[p.Ns]
+			if !ok || !ns.hasRole(p.Rel) {
+				report(RuleUndefinedPolicy, SeverityError, p.Ns, p.Rel, "policy on %s grants a role that is not declared", p.Obj)
+				continue
+			}
+			reachable[Relation{p.Ns, p.Rel}] = true
+		}
+		// A role with inherit is reachable once the same role is reachable in
+		// any namespace: the link may point there.
+		reachableRole := func(name string) bool {
+			for r := range reachable {
+				if r.Rel == name {
+					return true
+				}
+			}
+			return false
+		}
+		for changed := true; changed; {
+			changed = false
+			for _, ns := range byName {
+				for _, r := range ns.Roles {
+					rel := Relation{ns.Name, r.Name}
+					if r.Inherit != "" && !reachable[rel] && reachableRole(r.Name) {
+						reachable[rel] = true
+						changed = true
+					}
+				}
+			}
+		}
+		for _, ns := range byName {
+			for _, r := range ns.Roles {
+				rel := Relation{ns.Name, r.Name}
+				if !reachable[rel] && !links[rel] && r.Name != "..." {
+					report(RuleUnreachableRole, SeverityWarning, ns.Name, r.Name, "no policy grants this role")
+					reachable[rel] = true // report duplicates once
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Or(cmp.Compare(a.Ns, b.Ns), cmp.Compare(a.Rel, b.Rel), cmp.Compare(a.Rule, b.Rule))
+	})
+	return findings
+}
+
+func (n Namespace) hasRole(name string) bool {
+	return slices.ContainsFunc(n.Roles, func(r Role) bool { return r.Name == name })
+}
+
+// grants reports whether rel is a role of n or a permission one of its roles
+// lists.
+func (n Namespace) grants(rel string) bool {
+	return n.hasRole(rel) || slices.ContainsFunc(n.Roles, func(r Role) bool { return slices.Contains(r.Permissions, rel) })
+}
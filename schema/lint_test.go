@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+const lintFixture = `
+name: folder
+roles:
+  - name: parent
+  - name: viewer
+    inherit: parent
+    permissions: [folder.get]
+---
+name: doc
+roles:
+  - name: parent
+  - name: owner
+    permissions: [doc.get, doc.delete]
+  - name: viewer
+    inherit: parnet
+    permissions: [doc.get]
+  - name: owner
+`
+
+func TestLint(t *testing.T) {
+	namespaces, err := Parse(strings.NewReader(lintFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies := []Policy{
+		{Ns: "folder", Obj: "f1", Rel: "viewer", Users: []string{"alice"}},
+		{Ns: "doc", Obj: "d1", Rel: "editor", Users: []string{"bob"}},
+	}
+	findings := Lint(namespaces, LintOptions{
+		Policies: policies,
+		Required: []Relation{{"doc", "doc.get"}, {"doc", "doc.update"}, {"wiki", "wiki.get"}},
+	})
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.String())
+	}
+	want := []string{
+		`error: doc#doc.update: required, but no role of doc grants it (ungranted-permission)`,
+		`error: doc#editor: policy on d1 grants a role that is not declared (undefined-policy)`,
+		`error: doc#owner: role declared more than once (duplicate-role)`,
+		`warning: doc#owner: no policy grants this role (unreachable-role)`,
+		`warning: doc#parent: no policy grants this role (unreachable-role)`,
+		`error: doc#viewer: inherits through "parnet", which is not a role of doc (undefined-inherit)`,
+		`error: wiki#wiki.get: required, but namespace wiki is not declared (ungranted-permission)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintComposeIsClean(t *testing.T) {
+	namespaces, err := ParseCompose("../docker-compose.yml", "namespaces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies, err := ParseComposePolicies("../docker-compose.yml", "policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range Lint(namespaces, LintOptions{Policies: policies}) {
+		if f.Severity == SeverityError {
+			t.Errorf("%s", f)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"errors"
+	"io"
+)
+
+// Policy is one bootstrap policy document (POLICIES_PATH of nio-client):
+// the users granted rel on ⟨ns, obj⟩.
+//
+//	ns: project
+//	obj: p42
+//	rel: owner
+//	users:
+//	  - local:admin@local.local:123456
+type Policy struct {
+	Ns    string   `yaml:"ns"`
+	Obj   string   `yaml:"obj"`
+	Rel   string   `yaml:"rel"`
+	Users []string `yaml:"users,omitempty"`
+}
+
+// ParsePolicies reads every policy document of r. Empty documents are skipped.
+func ParsePolicies(r io.Reader) ([]Policy, error) {
+	return decodeDocuments(r, "policy", func(p Policy) error {
+		if p.Ns == "" || p.Obj == "" || p.Rel == "" {
+			return errors.New("ns, obj and rel are required")
+		}
+		return nil
+	})
+}
+
+// ParsePolicyFile reads the policy documents of the file at path.
+func ParsePolicyFile(path string) ([]Policy, error) {
+	return parseFile(path, ParsePolicies)
+}
+
+// ParseComposePolicies reads the policy documents inlined in a docker-compose
+// file as the content of configs.<config> (e.g. "policies").
+func ParseComposePolicies(path, config string) ([]Policy, error) {
+	return parseCompose(path, config, ParsePolicies)
+}
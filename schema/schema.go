@@ -0,0 +1,173 @@
+// Package schema reads the namespace configuration files check loads
+// (NAMESPACES_PATH): multi-document YAML with one namespace per document.
+//
+//	name: project
+//	roles:
+//	  - name: owner
+//	    permissions:
+//	      - project.get
+//	      - project.delete
+//	  - name: viewer
+//	    inherit: parent
+//	    permissions:
+//	      - project.get
+//
+// Roles carry stored tuples; permissions are computed as the union of the roles
+// listing them. A role with inherit is also granted through the object linked
+// by that relation.
+//
+// Besides parsing namespace and policy documents, the package lints them
+// (Lint) and diffs them against the configs a running check reports (Diff,
+// DiffServer). Package schema/gen generates Go code from them.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	nioclient "github.com/ecociel/nioclient-go"
+	"gopkg.in/yaml.v3"
+)
+
+// Namespace is one namespace document.
+type Namespace struct {
+	Name  string `yaml:"name"`
+	Roles []Role `yaml:"roles,omitempty"`
+}
+
+// Role is a relation granted by stored tuples, with the permissions it
+// implies.
+type Role struct {
+	Name        string   `yaml:"name"`
+	Inherit     string   `yaml:"inherit,omitempty"`
+	Permissions []string `yaml:"permissions,omitempty"`
+}
+
+// Parse reads every namespace document of r. Empty documents are skipped.
+func Parse(r io.Reader) ([]Namespace, error) {
+	return decodeDocuments(r, "namespace", func(ns Namespace) error {
+		if ns.Name == "" {
+			return errors.New("name is required")
+		}
+		return nil
+	})
+}
+
+// ParseFile reads the namespace documents of the file at path.
+func ParseFile(path string) ([]Namespace, error) {
+	return parseFile(path, Parse)
+}
+
+// ParseCompose reads the namespace documents inlined in a docker-compose file
+// as the content of configs.<config> (e.g. "namespaces").
+func ParseCompose(path, config string) ([]Namespace, error) {
+	return parseCompose(path, config, Parse)
+}
+
+// decodeDocuments decodes every non-empty document of r into a T, rejecting
+// unknown fields and documents failing validate.
+func decodeDocuments[T any](r io.Reader, kind string, validate func(T) error) ([]T, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	var out []T
+	for i := 0; ; i++ {
+		var doc *T
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err == nil && doc != nil {
+			err = validate(*doc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s document %d: %w", kind, i, err)
+		}
+		if doc != nil {
+			out = append(out, *doc)
+		}
+	}
+}
+
+func parseFile[T any](path string, parse func(io.Reader) ([]T, error)) ([]T, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	docs, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return docs, nil
+}
+
+func parseCompose[T any](path, config string, parse func(io.Reader) ([]T, error)) ([]T, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var compose struct {
+		Configs map[string]struct {
+			Content string `yaml:"content"`
+		} `yaml:"configs"`
+	}
+	if err := yaml.Unmarshal(b, &compose); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c, ok := compose.Configs[config]
+	if !ok {
+		return nil, fmt.Errorf("%s: no config %q", path, config)
+	}
+	docs, err := parse(strings.NewReader(c.Content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: configs.%s: %w", path, config, err)
+	}
+	return docs, nil
+}
+
+// Meta converts the namespace to the metadata ListNamespaces reports: roles
+// first, in file order, then each permission once, in order of first mention.
+// Kinds approximate check's: a role is this (union when it inherits); a
+// permission is computed when a single role grants it, else union.
+func (n Namespace) Meta() nioclient.NamespaceMeta {
+	meta := nioclient.NamespaceMeta{Name: n.Name}
+	grantedBy := make(map[string]int)
+	var permissions []string
+	for _, r := range n.Roles {
+		kind := nioclient.KindThis
+		if r.Inherit != "" {
+			kind = nioclient.KindUnion
+		}
+		meta.Relations = append(meta.Relations, nioclient.RelationMeta{Name: r.Name, Kind: kind})
+		for _, p := range r.Permissions {
+			if grantedBy[p] == 0 {
+				permissions = append(permissions, p)
+			}
+			grantedBy[p]++
+		}
+	}
+	for _, p := range permissions {
+		if slices.ContainsFunc(meta.Relations, func(r nioclient.RelationMeta) bool { return r.Name == p }) {
+			continue // a role listed as a permission of another role
+		}
+		kind := nioclient.KindUnion
+		if grantedBy[p] == 1 {
+			kind = nioclient.KindComputed
+		}
+		meta.Relations = append(meta.Relations, nioclient.RelationMeta{Name: p, Kind: kind})
+	}
+	return meta
+}
+
+// Metas converts every namespace with Meta.
+func Metas(namespaces []Namespace) []nioclient.NamespaceMeta {
+	out := make([]nioclient.NamespaceMeta, 0, len(namespaces))
+	for _, n := range namespaces {
+		out = append(out, n.Meta())
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	nioclient "github.com/ecociel/nioclient-go"
+)
+
+func TestParseComposeNamespaces(t *testing.T) {
+	namespaces, err := ParseCompose("../docker-compose.yml", "namespaces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, n := range namespaces {
+		names = append(names, n.Name)
+	}
+	if got := strings.Join(names, ","); got != "personal,token,root,serviceaccount,project" {
+		t.Fatalf("namespaces = %s", got)
+	}
+	sa := namespaces[3]
+	if len(sa.Roles) != 2 || sa.Roles[1].Inherit != "parent" || len(sa.Roles[1].Permissions) != 7 {
+		t.Fatalf("serviceaccount = %+v", sa)
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	_, err := Parse(strings.NewReader("name: doc\n---\nname: folder\nrole:\n  - name: owner\n"))
+	if err == nil || !strings.Contains(err.Error(), "document 1") {
+		t.Fatalf("err = %v, want unknown field in document 1", err)
+	}
+	if _, err := Parse(strings.NewReader("roles: []\n")); err == nil {
+		t.Fatal("document without name accepted")
+	}
+}
+
+func TestNamespaceMeta(t *testing.T) {
+	ns := Namespace{Name: "project", Roles: []Role{
+		{Name: "parent"},
+		{Name: "owner", Inherit: "parent", Permissions: []string{"project.get", "project.delete"}},
+		{Name: "viewer", Permissions: []string{"project.get"}},
+	}}
+	want := []nioclient.RelationMeta{
+		{Name: "parent", Kind: nioclient.KindThis},
+		{Name: "owner", Kind: nioclient.KindUnion},
+		{Name: "viewer", Kind: nioclient.KindThis},
+		{Name: "project.get", Kind: nioclient.KindUnion},
+		{Name: "project.delete", Kind: nioclient.KindComputed},
+	}
+	got := ns.Meta()
+	if got.Name != "project" || len(got.Relations) != len(want) {
+		t.Fatalf("meta = %+v", got)
+	}
+	for i := range want {
+		if got.Relations[i] != want[i] {
+			t.Errorf("relations[%d] = %+v, want %+v", i, got.Relations[i], want[i])
+		}
+	}
+}
+
+func TestParseComposePolicies(t *testing.T) {
+	policies, err := ParseComposePolicies("../docker-compose.yml", "policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 5 {
+		t.Fatalf("policies = %+v", policies)
+	}
+	if p := policies[4]; p.Ns != "project" || p.Obj != "p65" || p.Rel != "viewer" || len(p.Users) != 3 {
+		t.Fatalf("last policy = %+v", p)
+	}
+	if _, err := ParsePolicies(strings.NewReader("ns: doc\nrel: owner\n")); err == nil {
+		t.Fatal("policy without obj accepted")
+	}
+}
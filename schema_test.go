@@ -0,0 +1,170 @@
+package nioclient
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+)
+
+func testSchema() *Schema {
+	return NewSchema([]NamespaceMeta{
+		{Name: "project", Relations: []RelationMeta{
+			{Name: "owner", Kind: KindThis},
+			{Name: "parent", Kind: KindThis},
+			{Name: "update", Kind: KindUnion},
+			{Name: "get", Kind: KindComputed},
+		}},
+		{Name: "group", Relations: []RelationMeta{{Name: "member", Kind: KindThis}}},
+	})
+}
+
+func TestSchemaLookups(t *testing.T) {
+	s := testSchema()
+	if !s.HasNamespace("group") || s.HasNamespace("folder") {
+		t.Error("HasNamespace")
+	}
+	if !s.HasRelation("project", "update") || s.HasRelation("project", "member") {
+		t.Error("HasRelation")
+	}
+	if kind, ok := s.KindOf("project", "get"); !ok || kind != KindComputed {
+		t.Errorf("KindOf = %q, %v", kind, ok)
+	}
+	if got := s.Roles("project"); !slices.Equal(got, []Rel{"owner", "parent"}) {
+		t.Errorf("Roles = %v", got)
+	}
+	if got := s.Permissions("project"); !slices.Equal(got, []Rel{"update", "get"}) {
+		t.Errorf("Permissions = %v", got)
+	}
+	if got := s.Roles("folder"); got != nil {
+		t.Errorf("Roles(unknown) = %v", got)
+	}
+}
+
+func TestSchemaValidateTuple(t *testing.T) {
+	s := testSchema()
+	tests := []struct {
+		tuple string
+		want  error
+	}{
+		{"project:p1#owner@alice", nil},
+		{"project:p1#update@group:eng#member", nil},
+		{"project:p1#parent@project:p0#...", nil},
+		{"folder:f1#owner@alice", ErrUnknownNamespace},
+		{"project:p1#viewer@alice", ErrUnknownRelation},
+		{"project:p1#owner@folder:f1#member", ErrUnknownNamespace},
+		{"project:p1#owner@group:eng#admin", ErrUnknownRelation},
+	}
+	for _, tt := range tests {
+		tuple, err := ParseTuple(tt.tuple)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.ValidateTuple(tuple); !errors.Is(err, tt.want) || (tt.want == nil) != (err == nil) {
+			t.Errorf("ValidateTuple(%s) = %v, want %v", tt.tuple, err, tt.want)
+		}
+	}
+	if err := s.ValidateTuple(Tuple{Ns: "project", Obj: "p1", Rel: "owner"}); !errors.Is(err, ErrInvalidTuple) {
+		t.Errorf("ValidateTuple(no subject) = %v, want ErrInvalidTuple", err)
+	}
+}
+
+func TestSchemaValidationFailsFast(t *testing.T) {
+	fake := &fakeCheckService{
+		check: func(*proto.CheckRequest) (*proto.CheckResponse, error) {
+			return &proto.CheckResponse{Ok: true, Principal: &proto.Principal{Id: "alice"}}, nil
+		},
+		write: func(*proto.WriteRequest) (*proto.WriteResponse, error) {
+			return &proto.WriteResponse{Ts: "commit"}, nil
+		},
+	}
+	ns := &fakeNamespaceService{namespaces: []*proto.NamespaceMeta{
+		{Name: "project", Relations: []*proto.RelationMeta{{Name: "owner", Kind: KindThis}}},
+	}}
+	c := &checkAPI{grpcClient: fake, nsClient: ns}
+	c.schema = newSchemaCache(time.Hour, c.LoadSchema)
+
+	if _, _, err := c.Check(t.Context(), "project", "p1", "owner", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.Check(t.Context(), "project", "p1", "delete", "alice"); !errors.Is(err, ErrUnknownRelation) {
+		t.Fatalf("check unknown relation = %v", err)
+	}
+	_, err := c.Write(t.Context(), []Tuple{{Ns: "folder", Obj: "f1", Rel: "owner", UserId: "alice"}}, nil, nil)
+	if !errors.Is(err, ErrUnknownNamespace) {
+		t.Fatalf("write unknown namespace = %v", err)
+	}
+	if fake.count("check") != 1 || fake.count("write") != 0 {
+		t.Fatalf("calls: check %d, write %d; want invalid calls rejected locally", fake.count("check"), fake.count("write"))
+	}
+}
+
+func TestSchemaCacheRefreshesInBackground(t *testing.T) {
+	var loads atomic.Int32
+	release := make(chan struct{}, 1)
+	sc := newSchemaCache(time.Millisecond, func(context.Context) (*Schema, error) {
+		n := loads.Add(1)
+		if n == 1 {
+			return nil, errors.New("unavailable")
+		}
+		if n > 2 {
+			<-release
+		}
+		return NewSchema([]NamespaceMeta{{Name: "group"}}), nil
+	})
+
+	// A failed first load disables validation instead of failing calls.
+	if s := sc.get(t.Context()); s != nil {
+		t.Fatalf("schema after failed load = %v, want nil", s)
+	}
+	time.Sleep(2 * time.Millisecond)
+	var s *Schema
+	for s == nil {
+		s = sc.get(t.Context())
+	}
+	if !s.HasNamespace("group") {
+		t.Fatal("reloaded schema missing group")
+	}
+	// A stale schema is served while the reload blocks.
+	time.Sleep(2 * time.Millisecond)
+	if sc.get(t.Context()) != s {
+		t.Fatal("stale schema not served during reload")
+	}
+	release <- struct{}{}
+}
+
+func TestSchemaValidationCheckBatch(t *testing.T) {
+	var sent []string
+	fake := &fakeCheckService{checkBatch: func(in *proto.CheckBatchRequest) (*proto.CheckBatchResponse, error) {
+		res := &proto.CheckBatchResponse{Ts: "snap"}
+		for _, it := range in.Items {
+			sent = append(sent, it.Rel)
+			res.Results = append(res.Results, &proto.CheckBatchResult{Ok: true, Principal: &proto.Principal{Id: it.UserId}})
+		}
+		return res, nil
+	}}
+	ns := &fakeNamespaceService{namespaces: []*proto.NamespaceMeta{
+		{Name: "project", Relations: []*proto.RelationMeta{{Name: "owner", Kind: KindThis}}},
+	}}
+	c := &checkAPI{grpcClient: fake, nsClient: ns}
+	c.schema = newSchemaCache(time.Hour, c.LoadSchema)
+
+	res, err := c.CheckBatch(t.Context(), []CheckItem{
+		{Ns: "project", Obj: "p1", Rel: "owner", UserId: "alice"},
+		{Ns: "project", Obj: "p1", Rel: "delete", UserId: "alice"},
+		{Ns: "folder", Obj: "f1", Rel: "owner", UserId: "alice"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Results[0].Ok || !errors.Is(res.Results[1].Err, ErrUnknownRelation) || !errors.Is(res.Results[2].Err, ErrUnknownNamespace) {
+		t.Fatalf("results = %+v", res.Results)
+	}
+	if !slices.Equal(sent, []string{"owner"}) {
+		t.Fatalf("sent = %v, want only the valid item", sent)
+	}
+}
@@ -0,0 +1,243 @@
+// Package seed applies nio-client policy files (ns / obj / rel / users, see
+// schema.Policy) to check through Write, so the same file bootstraps dev, CI
+// and staging without restarting nio-client.
+//
+// Apply is idempotent: it reads the current subjects of every seeded
+// ⟨ns, obj, rel⟩ at one snapshot and writes only the difference, in chunks,
+// each chunk conditioned on the previous write (the first on the read
+// snapshot). A seeded tuple stored with an expiry counts as differing: it is
+// rewritten permanent by deleting and adding it in the same write, which
+// check applies deletes first. Running it again after success writes nothing.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	nioclient "github.com/ecociel/nioclient-go"
+	"github.com/ecociel/nioclient-go/schema"
+)
+
+// readFiltersPerCall bounds the ⟨ns, obj, rel⟩ filters of one Read.
+const readFiltersPerCall = 100
+
+// Client is the part of nioclient.Client that Apply uses.
+type Client interface {
+	ReadPage(ctx context.Context, ts nioclient.Timestamp, pageSize int, cursor string, filters ...nioclient.ReadFilter) (nioclient.ReadPageResult, error)
+	Write(ctx context.Context, add, del []nioclient.Tuple, precondition *nioclient.Timestamp) (nioclient.Timestamp, error)
+}
+
+// Options configures Apply.
+type Options struct {
+	// DryRun computes the changes without writing them.
+	DryRun bool
+	// Prune also deletes the subjects of a seeded ⟨ns, obj, rel⟩ that the
+	// policies do not list, making the file authoritative for those
+	// relations. Relations the file does not mention are never touched.
+	Prune bool
+	// ChunkSize is the number of tuples per Write
+	// (<= 0: nioclient.DefaultWriteChunkSize).
+	ChunkSize int
+}
+
+// Result is the outcome of Apply. Ts is the commit zookie of the last write,
+// or the read snapshot when nothing was written (or on a dry run); pass it to
+// later reads to observe the seed. Touch lists the seeded tuples that were
+// stored with an expiry (as stored) and are rewritten without one.
+type Result struct {
+	Ts     nioclient.Timestamp
+	Add    []nioclient.Tuple
+	Touch  []nioclient.Tuple
+	Delete []nioclient.Tuple
+	Writes int
+}
+
+// Tuples converts policies to the tuples they grant, without duplicates. A
+// user containing # is a userset (group:eng#member), anything else a user id.
+func Tuples(policies []schema.Policy) ([]nioclient.Tuple, error) {
+	var out []nioclient.Tuple
+	seen := make(map[string]bool)
+	for i, p := range policies {
+		for _, u := range p.Users {
+			t := nioclient.Tuple{Ns: nioclient.Ns(p.Ns), Obj: nioclient.Obj(p.Obj), Rel: nioclient.Rel(p.Rel)}
+			if strings.Contains(u, "#") {
+				us, err := nioclient.ParseUserSet(u)
+				if err != nil {
+					return nil, fmt.Errorf("policy %d: %w", i, err)
+				}
+				t.UserSet = &us
+			} else {
+				t.UserId = nioclient.UserId(u)
+			}
+			if k := t.String(); !seen[k] {
+				seen[k] = true
+				out = append(out, t)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Apply writes the tuples of policies that are not stored yet or stored with
+// an expiry (and, with opts.Prune, deletes the unlisted ones). On a failed
+// write the result holds the changes committed so far and Ts their zookie;
+// ErrPreconditionFailed means the relations changed concurrently and Apply
+// can simply run again.
+func Apply(ctx context.Context, client Client, policies []schema.Policy, opts Options) (Result, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = nioclient.DefaultWriteChunkSize
+	}
+	want, err := Tuples(policies)
+	if err != nil {
+		return Result{}, fmt.Errorf("seed: %w", err)
+	}
+	ts, stored, err := readStored(ctx, client, policies)
+	if err != nil {
+		return Result{}, fmt.Errorf("seed: %w", err)
+	}
+
+	plan := Result{Ts: ts}
+	wanted := make(map[string]bool, len(want))
+	for _, t := range want {
+		wanted[t.String()] = true
+		s, ok := stored[t.String()]
+		switch {
+		case !ok:
+			plan.Add = append(plan.Add, t)
+		case s.Expires != nil:
+			plan.Touch = append(plan.Touch, s)
+		}
+	}
+	if opts.Prune {
+		for k, t := range stored {
+			if !wanted[k] {
+				plan.Delete = append(plan.Delete, t)
+			}
+		}
+		slices.SortFunc(plan.Delete, func(a, b nioclient.Tuple) int { return strings.Compare(a.String(), b.String()) })
+	}
+	if opts.DryRun {
+		return plan, nil
+	}
+
+	// A touch is a delete and an add of one tuple; both go in the same write.
+	type change struct {
+		tuple    nioclient.Tuple
+		del, add bool
+	}
+	changes := make([]change, 0, len(plan.Add)+len(plan.Touch)+len(plan.Delete))
+	for _, t := range plan.Delete {
+		changes = append(changes, change{tuple: t, del: true})
+	}
+	for _, t := range plan.Touch {
+		changes = append(changes, change{tuple: t, del: true, add: true})
+	}
+	for _, t := range plan.Add {
+		changes = append(changes, change{tuple: t, add: true})
+	}
+	res := Result{Ts: ts}
+	write := func(chunk []change) error {
+		var add, del []nioclient.Tuple
+		for _, c := range chunk {
+			t := c.tuple
+			t.Expires = nil // seeded tuples are permanent; deletes match without expiry
+			if c.del {
+				del = append(del, t)
+			}
+			if c.add {
+				add = append(add, t)
+			}
+		}
+		precondition := res.Ts
+		next, err := client.Write(ctx, add, del, &precondition)
+		if err != nil {
+			done := len(res.Add) + len(res.Touch) + len(res.Delete)
+			return fmt.Errorf("seed: %d of %d changes written: %w", done, len(changes), err)
+		}
+		res.Ts = next
+		for _, c := range chunk {
+			switch {
+			case c.del && c.add:
+				res.Touch = append(res.Touch, c.tuple)
+			case c.del:
+				res.Delete = append(res.Delete, c.tuple)
+			default:
+				res.Add = append(res.Add, c.tuple)
+			}
+		}
+		res.Writes++
+		return nil
+	}
+	var chunk []change
+	size := 0
+	for _, c := range changes {
+		n := 1
+		if c.del && c.add {
+			n = 2
+		}
+		if size > 0 && size+n > opts.ChunkSize {
+			if err := write(chunk); err != nil {
+				return res, err
+			}
+			chunk, size = nil, 0
+		}
+		chunk = append(chunk, c)
+		size += n
+	}
+	if len(chunk) > 0 {
+		if err := write(chunk); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// readStored reads the stored tuples of every ⟨ns, obj, rel⟩ the policies
+// mention, at the snapshot of the first read. Tuples are keyed by their text
+// form without expiry and keep their Expires.
+func readStored(ctx context.Context, client Client, policies []schema.Policy) (nioclient.Timestamp, map[string]nioclient.Tuple, error) {
+	var filters []nioclient.ReadFilter
+	seen := make(map[nioclient.UserSet]bool)
+	for _, p := range policies {
+		us := nioclient.UserSet{Ns: nioclient.Ns(p.Ns), Obj: nioclient.Obj(p.Obj), Rel: nioclient.Rel(p.Rel)}
+		if !seen[us] {
+			seen[us] = true
+			filters = append(filters, nioclient.FilterByObject(us.Ns, us.Obj, &us.Rel))
+		}
+	}
+
+	ts := nioclient.TimestampEmpty
+	pinned := false
+	stored := make(map[string]nioclient.Tuple)
+	for group := range slices.Chunk(filters, readFiltersPerCall) {
+		cursor := ""
+		for {
+			page, err := client.ReadPage(ctx, ts, 0, cursor, group...)
+			if err != nil {
+				return "", nil, err
+			}
+			if !pinned {
+				ts, pinned = page.Ts, true
+			}
+			for _, t := range page.Tuples {
+				if !seen[nioclient.UserSet{Ns: t.Ns, Obj: t.Obj, Rel: t.Rel}] {
+					continue // never prune outside the seeded relations
+				}
+				key := t
+				key.Expires = nil
+				stored[key.String()] = t
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			if page.NextCursor == cursor {
+				return "", nil, errors.New("read: server repeated page token")
+			}
+			cursor = page.NextCursor
+		}
+	}
+	return ts, stored, nil
+}
@@ -0,0 +1,174 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	nioclient "github.com/ecociel/nioclient-go"
+	"github.com/ecociel/nioclient-go/schema"
+)
+
+// memoryClient stores tuples by text form without expiry, as check keys
+// them, and applies the deletes of a write before its adds. Every write bumps
+// the zookie and must be conditioned on the current one.
+type memoryClient struct {
+	tuples map[string]nioclient.Tuple
+	ts     int
+	writes [][2]int // adds, deletes per write
+}
+
+func newMemoryClient(t *testing.T, tuples ...string) *memoryClient {
+	c := &memoryClient{tuples: make(map[string]nioclient.Tuple)}
+	for _, s := range tuples {
+		tuple, err := nioclient.ParseTuple(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.tuples[key(tuple)] = tuple
+	}
+	return c
+}
+
+func key(t nioclient.Tuple) string {
+	t.Expires = nil
+	return t.String()
+}
+
+func (c *memoryClient) zookie() nioclient.Timestamp {
+	return nioclient.Timestamp(fmt.Sprint("ts", c.ts))
+}
+
+// ReadPage answers with every stored tuple in one page; Apply must ignore
+// the tuples outside the filtered relations.
+func (c *memoryClient) ReadPage(_ context.Context, _ nioclient.Timestamp, _ int, _ string, filters ...nioclient.ReadFilter) (nioclient.ReadPageResult, error) {
+	res := nioclient.ReadPageResult{Ts: c.zookie()}
+	for _, t := range c.tuples {
+		res.Tuples = append(res.Tuples, t)
+	}
+	return res, nil
+}
+
+func (c *memoryClient) Write(_ context.Context, add, del []nioclient.Tuple, precondition *nioclient.Timestamp) (nioclient.Timestamp, error) {
+	if precondition == nil || *precondition != c.zookie() {
+		return "", fmt.Errorf("precondition %v: %w", precondition, nioclient.ErrPreconditionFailed)
+	}
+	for _, t := range del {
+		delete(c.tuples, key(t))
+	}
+	for _, t := range add {
+		if _, ok := c.tuples[key(t)]; ok {
+			return "", fmt.Errorf("add %s: already stored", t)
+		}
+		c.tuples[key(t)] = t
+	}
+	c.ts++
+	c.writes = append(c.writes, [2]int{len(add), len(del)})
+	return c.zookie(), nil
+}
+
+func (c *memoryClient) stored() []string {
+	var out []string
+	for _, t := range c.tuples {
+		out = append(out, t.String())
+	}
+	slices.Sort(out)
+	return out
+}
+
+var testPolicies = []schema.Policy{
+	{Ns: "project", Obj: "p42", Rel: "owner", Users: []string{"alice"}},
+	{Ns: "project", Obj: "p42", Rel: "viewer", Users: []string{"bob", "group:eng#member", "carol", "dave"}},
+}
+
+func TestApplyWritesOnlyMissingTuples(t *testing.T) {
+	c := newMemoryClient(t, "project:p42#owner@alice", "project:p42#viewer@mallory")
+
+	res, err := Apply(t.Context(), c, testPolicies, Options{ChunkSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Add) != 4 || len(res.Delete) != 0 || res.Writes != 2 || res.Ts != "ts2" {
+		t.Fatalf("res = %+v", res)
+	}
+	if want := "project:p42#viewer@group:eng#member"; !slices.Contains(c.stored(), want) {
+		t.Fatalf("stored = %v, want userset subject %s", c.stored(), want)
+	}
+
+	again, err := Apply(t.Context(), c, testPolicies, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Add) != 0 || again.Writes != 0 || again.Ts != "ts2" {
+		t.Fatalf("second apply = %+v, want no writes", again)
+	}
+}
+
+func TestApplyDryRunAndPrune(t *testing.T) {
+	c := newMemoryClient(t, "project:p42#viewer@bob", "project:p42#viewer@mallory", "project:p1#viewer@mallory")
+
+	plan, err := Apply(t.Context(), c, testPolicies, Options{DryRun: true, Prune: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Add) != 4 || len(plan.Delete) != 1 || plan.Delete[0].String() != "project:p42#viewer@mallory" {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if len(c.writes) != 0 {
+		t.Fatalf("dry run wrote %v", c.writes)
+	}
+
+	res, err := Apply(t.Context(), c, testPolicies, Options{Prune: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Writes != 1 || len(res.Delete) != 1 {
+		t.Fatalf("res = %+v", res)
+	}
+	if got := strings.Join(c.stored(), " "); strings.Contains(got, "p42#viewer@mallory") || !strings.Contains(got, "p1#viewer@mallory") {
+		t.Fatalf("stored = %s, want mallory pruned on p42 only", got)
+	}
+}
+
+func TestTuplesDedupes(t *testing.T) {
+	tuples, err := Tuples(append(testPolicies, schema.Policy{Ns: "project", Obj: "p42", Rel: "owner", Users: []string{"alice"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tuples) != 5 {
+		t.Fatalf("tuples = %v", tuples)
+	}
+}
+
+func TestApplyTouchesExpiringGrants(t *testing.T) {
+	c := newMemoryClient(t,
+		"project:p42#owner@alice[expires=2020-01-15T12:00:00Z]",
+		"project:p42#viewer@bob[expires=2030-01-15T12:00:00Z]",
+	)
+
+	res, err := Apply(t.Context(), c, testPolicies, Options{ChunkSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Touch) != 2 || len(res.Add) != 3 || res.Writes != 4 {
+		t.Fatalf("res = %+v, want alice and bob touched", res)
+	}
+	if c.writes[0] != [2]int{1, 1} {
+		t.Fatalf("first write = %v, want one touch as a delete and an add", c.writes[0])
+	}
+	for _, s := range c.stored() {
+		if strings.Contains(s, "expires") {
+			t.Fatalf("stored = %v, want every seeded grant permanent", c.stored())
+		}
+	}
+
+	again, err := Apply(t.Context(), c, testPolicies, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Writes != 0 || len(again.Touch) != 0 {
+		t.Fatalf("second apply = %+v, want no writes", again)
+	}
+}
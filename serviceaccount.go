@@ -0,0 +1,460 @@
+package nioclient
+
+// Service account authentication for machine-to-machine calls. A service
+// account (NsServiceAccount) owns keys; a caller proves possession of a key
+// either with a short-lived signed JWT assertion sent as a bearer token, or by
+// HMAC-signing each request. ServiceAccountVerifier resolves both to the
+// principal ServiceAccountUserId(account), after which Wrap runs the normal
+// Requires check. ServiceAccountSigner produces them on the client side.
+//
+// Assertions are not tracked after verification, so one can be replayed
+// until it expires (MaxAssertionLifetime); signed requests can be replayed
+// within the allowed clock skew. Send both over TLS only.
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxAssertionLifetime bounds exp - iat of a service account assertion.
+	MaxAssertionLifetime = time.Hour
+	// DefaultServiceAccountSkew is the clock skew tolerated between signer and
+	// verifier.
+	DefaultServiceAccountSkew = 5 * time.Minute
+	// AssertionType is the JWT typ header of service account assertions. The
+	// verifier claims a bearer JWT only with this typ or a kid naming one of
+	// its keys, leaving other JWTs (OIDC, …) to FromBearer.
+	AssertionType = "serviceaccount+jwt"
+	// HMACScheme is the Authorization scheme of HMAC-signed requests:
+	//
+	//	Authorization: NIO-HMAC-SHA256 KeyId=<id>, Timestamp=<unix>, Signature=<base64url>
+	HMACScheme = "NIO-HMAC-SHA256"
+
+	// maxSignedBody bounds the body an HMAC-signed request may carry.
+	maxSignedBody = 10 << 20
+)
+
+// ServiceAccountUserId returns the principal of a service account, the user id
+// its grants are written for: serviceaccount:<account>.
+func ServiceAccountUserId(account Obj) UserId {
+	return UserId(string(NsServiceAccount) + ":" + string(account))
+}
+
+// ServiceAccountKey is a key of a service account. Secret verifies HS256
+// assertions and HMAC-signed requests; PublicKey (*ecdsa.PublicKey on P-256 or
+// ed25519.PublicKey) verifies ES256 and EdDSA assertions.
+type ServiceAccountKey struct {
+	ID        string
+	Account   Obj
+	Secret    []byte
+	PublicKey crypto.PublicKey
+	// Expires is the end of the key's validity; zero never expires.
+	Expires time.Time
+}
+
+// ServiceAccountKeys looks up keys by id. found=false with a nil error means
+// the key is unknown or revoked.
+type ServiceAccountKeys interface {
+	ServiceAccountKey(ctx context.Context, id string) (key ServiceAccountKey, found bool, err error)
+}
+
+// StaticServiceAccountKeys is a fixed set of keys by id, e.g. from config.
+type StaticServiceAccountKeys map[string]ServiceAccountKey
+
+func (s StaticServiceAccountKeys) ServiceAccountKey(_ context.Context, id string) (ServiceAccountKey, bool, error) {
+	key, ok := s[id]
+	return key, ok, nil
+}
+
+// ServiceAccountVerifier verifies service account credentials issued for
+// audience (typically the service's base URL).
+type ServiceAccountVerifier struct {
+	keys     ServiceAccountKeys
+	audience string
+	skew     time.Duration
+}
+
+// NewServiceAccountVerifier returns a verifier of the keys for audience.
+func NewServiceAccountVerifier(keys ServiceAccountKeys, audience string) *ServiceAccountVerifier {
+	return &ServiceAccountVerifier{keys: keys, audience: audience, skew: DefaultServiceAccountSkew}
+}
+
+// Credentials returns the credentials of v for WithCredentials: JWT bearer
+// assertions and HMAC-signed requests. Put them before FromBearer, which would
+// otherwise claim the assertions; bearer tokens that are not assertions (see
+// AssertionType) are left to it.
+//
+//	nioclient.WithCredentials(append(verifier.Credentials(), nioclient.FromCookie("session"))...)
+func (v *ServiceAccountVerifier) Credentials() []Credential {
+	return []Credential{
+		{
+			Extract:   v.extractAssertion,
+			Resolver:  TokenResolverFunc(v.resolveAssertion),
+			Challenge: "Bearer",
+		},
+		{
+			Extract:   v.extractSignedRequest,
+			Resolver:  TokenResolverFunc(v.resolveSignedRequest),
+			Challenge: HMACScheme,
+		},
+	}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ,omitempty"`
+}
+
+type jwtClaims struct {
+	Iss string `json:"iss"`
+	Sub string `json:"sub"`
+	Aud string `json:"aud"`
+	Iat int64  `json:"iat"`
+	Exp int64  `json:"exp"`
+}
+
+// extractAssertion claims a bearer JWT whose typ is AssertionType or whose
+// kid names a key of v (a failing key lookup claims it too, so resolve reports
+// the failure).
+func (v *ServiceAccountVerifier) extractAssertion(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.Count(token, ".") != 2 {
+		return "", false
+	}
+	var header jwtHeader
+	if decodeSegment(token[:strings.IndexByte(token, '.')], &header) != nil {
+		return "", false
+	}
+	if strings.EqualFold(header.Typ, AssertionType) {
+		return token, true
+	}
+	if header.Kid == "" {
+		return "", false
+	}
+	_, found, err := v.keys.ServiceAccountKey(r.Context(), header.Kid)
+	return token, found || err != nil
+}
+
+// resolveAssertion verifies a JWT assertion. An invalid or expired assertion
+// is not found; only key lookup failures are errors.
+func (v *ServiceAccountVerifier) resolveAssertion(ctx context.Context, token string) (UserId, bool, error) {
+	parts := strings.Split(token, ".")
+	var header jwtHeader
+	var claims jwtClaims
+	if len(parts) != 3 || decodeSegment(parts[0], &header) != nil || decodeSegment(parts[1], &claims) != nil {
+		return "", false, nil
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", false, nil
+	}
+	key, ok, err := v.key(ctx, header.Kid)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	if !verifyJWS(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig) {
+		return "", false, nil
+	}
+	now := time.Now()
+	switch {
+	case claims.Sub != string(key.Account), claims.Aud != v.audience:
+		return "", false, nil
+	case claims.Exp <= claims.Iat, time.Duration(claims.Exp-claims.Iat)*time.Second > MaxAssertionLifetime:
+		return "", false, nil
+	case now.Add(v.skew).Unix() < claims.Iat, now.Add(-v.skew).Unix() >= claims.Exp:
+		return "", false, nil
+	}
+	return ServiceAccountUserId(key.Account), true, nil
+}
+
+// extractSignedRequest returns the token "keyId timestamp signature digest"
+// of an HMAC-signed request, digest being the SHA-256 of its string to sign.
+// The body is read and replaced.
+func (v *ServiceAccountVerifier) extractSignedRequest(r *http.Request) (string, bool) {
+	scheme, params, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, HMACScheme) {
+		return "", false
+	}
+	fields := make(map[string]string)
+	for _, p := range strings.Split(params, ",") {
+		k, val, _ := strings.Cut(strings.TrimSpace(p), "=")
+		fields[k] = val
+	}
+	keyId, ts, sig := fields["KeyId"], fields["Timestamp"], fields["Signature"]
+	if keyId == "" || ts == "" || sig == "" || strings.ContainsAny(keyId+ts+sig, " ") {
+		return "", false
+	}
+	body, err := readBody(r)
+	if err != nil {
+		return "", false
+	}
+	digest := sha256.Sum256([]byte(stringToSign(v.audience, ts, r.Method, r.URL.RequestURI(), body)))
+	return strings.Join([]string{keyId, ts, sig, hex.EncodeToString(digest[:])}, " "), true
+}
+
+func (v *ServiceAccountVerifier) resolveSignedRequest(ctx context.Context, token string) (UserId, bool, error) {
+	parts := strings.Split(token, " ")
+	if len(parts) != 4 {
+		return "", false, nil
+	}
+	keyId, ts, sig, digest := parts[0], parts[1], parts[2], parts[3]
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", false, nil
+	}
+	if d := time.Since(time.Unix(unix, 0)); d > v.skew || d < -v.skew {
+		return "", false, nil
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", false, nil
+	}
+	key, ok, err := v.key(ctx, keyId)
+	if err != nil || !ok || len(key.Secret) == 0 {
+		return "", false, err
+	}
+	if !hmac.Equal(mac, hmacSum(key.Secret, digest)) {
+		return "", false, nil
+	}
+	return ServiceAccountUserId(key.Account), true, nil
+}
+
+// key looks up an unexpired key.
+func (v *ServiceAccountVerifier) key(ctx context.Context, id string) (ServiceAccountKey, bool, error) {
+	if id == "" {
+		return ServiceAccountKey{}, false, nil
+	}
+	key, ok, err := v.keys.ServiceAccountKey(ctx, id)
+	if err != nil {
+		return ServiceAccountKey{}, false, fmt.Errorf("service account key %s: %w", id, err)
+	}
+	if !ok || (!key.Expires.IsZero() && time.Now().After(key.Expires)) {
+		return ServiceAccountKey{}, false, nil
+	}
+	return key, true, nil
+}
+
+// ServiceAccountSigner authenticates requests of a service account with one
+// of its keys: with a JWT assertion (HS256 with Key.Secret, or ES256/EdDSA
+// with PrivateKey) or, with HMAC set, by signing each request with
+// Key.Secret.
+type ServiceAccountSigner struct {
+	Key        ServiceAccountKey
+	PrivateKey crypto.Signer
+	// Audience must match the verifier's audience.
+	Audience string
+	HMAC     bool
+	// Lifetime of assertions (<= 0 or above MaxAssertionLifetime:
+	// MaxAssertionLifetime).
+	Lifetime time.Duration
+}
+
+// Assertion returns a signed JWT assertion valid from now for Lifetime.
+func (s *ServiceAccountSigner) Assertion() (string, error) {
+	lifetime := s.Lifetime
+	if lifetime <= 0 || lifetime > MaxAssertionLifetime {
+		lifetime = MaxAssertionLifetime
+	}
+	alg := "HS256"
+	switch k := s.PrivateKey.(type) {
+	case nil:
+		if len(s.Key.Secret) == 0 {
+			return "", errors.New("assertion: key has neither secret nor private key")
+		}
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return "", errors.New("assertion: ES256 requires a P-256 key")
+		}
+		alg = "ES256"
+	case ed25519.PrivateKey:
+		alg = "EdDSA"
+	default:
+		return "", fmt.Errorf("assertion: unsupported private key %T", k)
+	}
+	now := time.Now()
+	header, err := encodeSegment(jwtHeader{Alg: alg, Kid: s.Key.ID, Typ: AssertionType})
+	if err != nil {
+		return "", fmt.Errorf("assertion: %w", err)
+	}
+	claims, err := encodeSegment(jwtClaims{
+		Iss: string(s.Key.Account),
+		Sub: string(s.Key.Account),
+		Aud: s.Audience,
+		Iat: now.Unix(),
+		Exp: now.Add(lifetime).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("assertion: %w", err)
+	}
+	signed := header + "." + claims
+	sig, err := s.signJWS(alg, []byte(signed))
+	if err != nil {
+		return "", fmt.Errorf("assertion: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Sign sets the Authorization header of r: a fresh assertion, or the HMAC
+// signature of r (reading and replacing its body).
+func (s *ServiceAccountSigner) Sign(r *http.Request) error {
+	if !s.HMAC {
+		token, err := s.Assertion()
+		if err != nil {
+			return err
+		}
+		r.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+	if len(s.Key.Secret) == 0 {
+		return errors.New("sign: HMAC requires the key secret")
+	}
+	body, err := readBody(r)
+	if err != nil {
+		return fmt.Errorf("sign: %w", err)
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	digest := sha256.Sum256([]byte(stringToSign(s.Audience, ts, r.Method, r.URL.RequestURI(), body)))
+	sig := base64.RawURLEncoding.EncodeToString(hmacSum(s.Key.Secret, hex.EncodeToString(digest[:])))
+	r.Header.Set("Authorization", fmt.Sprintf("%s KeyId=%s, Timestamp=%s, Signature=%s", HMACScheme, s.Key.ID, ts, sig))
+	return nil
+}
+
+// Transport returns a RoundTripper signing every request with s before
+// passing it to base (nil: http.DefaultTransport).
+//
+//	client := &http.Client{Transport: signer.Transport(nil)}
+func (s *ServiceAccountSigner) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return signingTransport{signer: s, base: base}
+}
+
+type signingTransport struct {
+	signer *ServiceAccountSigner
+	base   http.RoundTripper
+}
+
+func (t signingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the caller's request.
+	signed := r.Clone(r.Context())
+	if err := t.signer.Sign(signed); err != nil {
+		if r.Body != nil {
+			_ = r.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(signed)
+}
+
+func (s *ServiceAccountSigner) signJWS(alg string, signed []byte) ([]byte, error) {
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, s.Key.Secret)
+		mac.Write(signed)
+		return mac.Sum(nil), nil
+	case "ES256":
+		digest := sha256.Sum256(signed)
+		r, ss, err := ecdsa.Sign(rand.Reader, s.PrivateKey.(*ecdsa.PrivateKey), digest[:])
+		if err != nil {
+			return nil, err
+		}
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		ss.FillBytes(sig[32:])
+		return sig, nil
+	default: // EdDSA
+		return ed25519.Sign(s.PrivateKey.(ed25519.PrivateKey), signed), nil
+	}
+}
+
+// verifyJWS verifies sig with the key matching alg; an alg the key cannot
+// verify fails (no "none", no HS256 with a public key, ES256 only on P-256).
+func verifyJWS(alg string, key ServiceAccountKey, signed, sig []byte) bool {
+	switch alg {
+	case "HS256":
+		if len(key.Secret) == 0 {
+			return false
+		}
+		mac := hmac.New(sha256.New, key.Secret)
+		mac.Write(signed)
+		return hmac.Equal(sig, mac.Sum(nil))
+	case "ES256":
+		pub, ok := key.PublicKey.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() || len(sig) != 64 {
+			return false
+		}
+		digest := sha256.Sum256(signed)
+		return ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+	case "EdDSA":
+		pub, ok := key.PublicKey.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, sig)
+	}
+	return false
+}
+
+func stringToSign(audience, ts, method, uri string, body []byte) string {
+	bodySum := sha256.Sum256(body)
+	return strings.Join([]string{HMACScheme, ts, audience, method, uri, hex.EncodeToString(bodySum[:])}, "\n")
+}
+
+func hmacSum(secret []byte, digest string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(digest))
+	return mac.Sum(nil)
+}
+
+// readBody reads r's body (at most maxSignedBody bytes) and replaces it so
+// it can be read again.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBody+1))
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxSignedBody {
+		return nil, fmt.Errorf("body exceeds %d bytes", maxSignedBody)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func encodeSegment(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeSegment(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,218 @@
+package nioclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAudience = "https://api.example.com"
+
+// serviceAccountServer serves a Wrap-protected echo of the principal and the
+// request body, accepting only service account credentials.
+func serviceAccountServer(t *testing.T, keys StaticServiceAccountKeys) (*httptest.Server, *resolvingWrapper) {
+	t.Helper()
+	w := &resolvingWrapper{}
+	verifier := NewServiceAccountVerifier(keys, testAudience)
+	srv := httptest.NewServer(WrapHTTP(w, func(http.ResponseWriter, *http.Request) (Resource, error) {
+		return testResource{}, nil
+	}, func(rw http.ResponseWriter, r *http.Request, _ Resource, u User) error {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = rw.Write([]byte(u.Principal() + ":" + string(body)))
+		return nil
+	}, WithCredentials(verifier.Credentials()...)))
+	t.Cleanup(srv.Close)
+	return srv, w
+}
+
+func post(t *testing.T, client *http.Client, url, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func TestServiceAccountSignerRoundTrip(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	keys := StaticServiceAccountKeys{
+		"k-hs": {ID: "k-hs", Account: "ci", Secret: secret},
+		"k-es": {ID: "k-es", Account: "ci", PublicKey: &ecKey.PublicKey},
+		"k-ed": {ID: "k-ed", Account: "ci", PublicKey: edPub},
+	}
+	srv, w := serviceAccountServer(t, keys)
+
+	for _, tt := range []struct {
+		name   string
+		signer *ServiceAccountSigner
+	}{
+		{"HS256", &ServiceAccountSigner{Key: keys["k-hs"], Audience: testAudience}},
+		{"ES256", &ServiceAccountSigner{Key: keys["k-es"], PrivateKey: ecKey, Audience: testAudience}},
+		{"EdDSA", &ServiceAccountSigner{Key: keys["k-ed"], PrivateKey: edKey, Audience: testAudience}},
+		{"HMAC", &ServiceAccountSigner{Key: keys["k-hs"], Audience: testAudience, HMAC: true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: tt.signer.Transport(nil)}
+			code, body := post(t, client, srv.URL+"/articles/1?x=1", "payload")
+			if code != http.StatusOK || body != "serviceaccount:ci:payload" {
+				t.Fatalf("status = %d, body = %q", code, body)
+			}
+			if w.lastCheckUserId != ServiceAccountUserId("ci") {
+				t.Fatalf("check subject = %q", w.lastCheckUserId)
+			}
+		})
+	}
+}
+
+func TestServiceAccountRejects(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	keys := StaticServiceAccountKeys{
+		"k":       {ID: "k", Account: "ci", Secret: secret},
+		"expired": {ID: "expired", Account: "ci", Secret: secret, Expires: time.Now().Add(-time.Minute)},
+	}
+	srv, w := serviceAccountServer(t, keys)
+
+	for _, tt := range []struct {
+		name   string
+		signer *ServiceAccountSigner
+	}{
+		{"wrong audience", &ServiceAccountSigner{Key: keys["k"], Audience: "https://other.example.com"}},
+		{"wrong secret", &ServiceAccountSigner{Key: ServiceAccountKey{ID: "k", Account: "ci", Secret: []byte("guess")}, Audience: testAudience}},
+		{"other account", &ServiceAccountSigner{Key: ServiceAccountKey{ID: "k", Account: "admin", Secret: secret}, Audience: testAudience}},
+		{"expired key", &ServiceAccountSigner{Key: keys["expired"], Audience: testAudience}},
+		{"HMAC wrong audience", &ServiceAccountSigner{Key: keys["k"], Audience: "https://other.example.com", HMAC: true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: tt.signer.Transport(nil)}
+			if code, body := post(t, client, srv.URL+"/articles/1", "payload"); code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, body = %q; want 401", code, body)
+			}
+		})
+	}
+	if w.checkCalls != 0 {
+		t.Fatalf("checkCalls = %d, want 0", w.checkCalls)
+	}
+}
+
+func TestServiceAccountHMACCoversBody(t *testing.T) {
+	key := ServiceAccountKey{ID: "k", Account: "ci", Secret: []byte("0123456789abcdef0123456789abcdef")}
+	srv, _ := serviceAccountServer(t, StaticServiceAccountKeys{"k": key})
+	signer := &ServiceAccountSigner{Key: key, Audience: testAudience, HMAC: true}
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/articles/1", strings.NewReader("original"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signer.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	req.Body = io.NopCloser(strings.NewReader("tampered"))
+	req.ContentLength = int64(len("tampered"))
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401 for a tampered body", resp.StatusCode)
+	}
+}
+
+func TestServiceAccountLeavesOtherJWTsToFromBearer(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	keys := StaticServiceAccountKeys{"k": {ID: "k", Account: "ci", Secret: secret}}
+	verifier := NewServiceAccountVerifier(keys, testAudience)
+	w := &resolvingWrapper{resolvePrincipal: "oidc-user"}
+	srv := httptest.NewServer(WrapHTTP(w, func(http.ResponseWriter, *http.Request) (Resource, error) {
+		return testResource{}, nil
+	}, func(rw http.ResponseWriter, _ *http.Request, _ Resource, u User) error {
+		_, _ = rw.Write([]byte(u.Principal()))
+		return nil
+	}, WithCredentials(append(verifier.Credentials(), FromBearer())...)))
+	defer srv.Close()
+
+	jwt := func(header string) string {
+		enc := base64.RawURLEncoding.EncodeToString
+		return enc([]byte(header)) + "." + enc([]byte(`{"iss":"https://accounts.example.com","sub":"alice"}`)) + "." + enc([]byte("sig"))
+	}
+	for _, tt := range []struct {
+		name     string
+		header   string
+		wantCode int
+		wantBody string
+	}{
+		{"OIDC token", `{"alg":"RS256","kid":"idp-2024","typ":"JWT"}`, http.StatusOK, "oidc-user"},
+		{"assertion with unknown key", `{"alg":"HS256","kid":"gone","typ":"` + AssertionType + `"}`, http.StatusUnauthorized, ""},
+		{"forged assertion of a known key", `{"alg":"HS256","kid":"k","typ":"JWT"}`, http.StatusUnauthorized, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/articles/1", nil)
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+jwt(tt.header))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantCode || tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Fatalf("status = %d, body = %q", resp.StatusCode, body)
+			}
+		})
+	}
+}
+
+func TestServiceAccountES256RequiresP256(t *testing.T) {
+	// A P-224 signature fits ES256's 64 bytes and is valid on its own curve.
+	key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := []byte("header.claims")
+	digest := sha256.Sum256(signed)
+	r, ss, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	ss.FillBytes(sig[32:])
+	if verifyJWS("ES256", ServiceAccountKey{PublicKey: &key.PublicKey}, signed, sig) {
+		t.Fatal("ES256 verified with a P-224 key")
+	}
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &ServiceAccountSigner{Key: ServiceAccountKey{ID: "k", Account: "ci"}, PrivateKey: p384, Audience: testAudience}
+	if _, err := signer.Assertion(); err == nil {
+		t.Fatal("signed an ES256 assertion with a P-384 key")
+	}
+}
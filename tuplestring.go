@@ -0,0 +1,220 @@
+package nioclient
+
+// Canonical Zanzibar text form of tuples and usersets:
+//
+//	doc:readme#viewer@user:123
+//	doc:readme#viewer@group:eng#member
+//	doc:readme#viewer@user:123[expires=2030-01-15T12:00:00Z]
+//
+// The reserved characters \ : # @ [ ] are escaped with a backslash inside
+// ns, obj and rel. A user id only escapes \ # [ ] — the subject is everything
+// after the first unescaped @, so ids such as local:admin@local.local:123456
+// stay readable. A subject with an unescaped # is a userset. Parse(String(x))
+// returns x for every well-formed tuple and userset.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	reservedTriple = `\:#@[]`
+	reservedUserId = `\#[]`
+	expiresPrefix  = "expires="
+)
+
+// String returns the canonical text form ns:obj#rel.
+func (s UserSet) String() string {
+	return formatTriple(s.Ns, s.Obj, s.Rel)
+}
+
+// String returns the canonical text form ns:obj#rel@subject with an optional
+// [expires=RFC3339] suffix.
+func (t Tuple) String() string {
+	var b strings.Builder
+	b.WriteString(formatTriple(t.Ns, t.Obj, t.Rel))
+	b.WriteByte('@')
+	if t.UserSet != nil {
+		b.WriteString(t.UserSet.String())
+	} else {
+		b.WriteString(escape(string(t.UserId), reservedUserId))
+	}
+	if t.Expires != nil {
+		b.WriteString("[" + expiresPrefix + t.Expires.UTC().Format(time.RFC3339) + "]")
+	}
+	return b.String()
+}
+
+// ParseUserSet parses the canonical form ns:obj#rel.
+func ParseUserSet(s string) (UserSet, error) {
+	ns, obj, rel, err := parseTriple(s)
+	if err != nil {
+		return UserSet{}, fmt.Errorf("parse userset %q: %w", s, err)
+	}
+	return UserSet{Ns: ns, Obj: obj, Rel: rel}, nil
+}
+
+// ParseTuple parses the canonical form ns:obj#rel@subject[expires=RFC3339],
+// where subject is a user id or a userset ns:obj#rel.
+func ParseTuple(s string) (Tuple, error) {
+	t, err := parseTuple(s)
+	if err != nil {
+		return Tuple{}, fmt.Errorf("parse tuple %q: %w", s, err)
+	}
+	return t, nil
+}
+
+func parseTuple(s string) (Tuple, error) {
+	var t Tuple
+	body := s
+	if len(s) > 0 && lastUnescaped(s, ']') == len(s)-1 {
+		open := lastUnescaped(s, '[')
+		if open < 0 {
+			return Tuple{}, fmt.Errorf("%w: unbalanced ]", ErrInvalidTuple)
+		}
+		cond := s[open+1 : len(s)-1]
+		if !strings.HasPrefix(cond, expiresPrefix) {
+			return Tuple{}, fmt.Errorf("%w: unknown condition [%s]", ErrInvalidTuple, cond)
+		}
+		exp, err := time.Parse(time.RFC3339, strings.TrimPrefix(cond, expiresPrefix))
+		if err != nil {
+			return Tuple{}, fmt.Errorf("%w: expires: %v", ErrInvalidTuple, err)
+		}
+		exp = exp.UTC()
+		t.Expires = &exp
+		body = s[:open]
+	}
+
+	at := indexUnescaped(body, '@')
+	if at < 0 {
+		return Tuple{}, fmt.Errorf("%w: missing @subject", ErrInvalidTuple)
+	}
+	var err error
+	if t.Ns, t.Obj, t.Rel, err = parseTriple(body[:at]); err != nil {
+		return Tuple{}, err
+	}
+	subject := body[at+1:]
+	if indexUnescaped(subject, '#') >= 0 {
+		us, err := ParseUserSet(subject)
+		if err != nil {
+			return Tuple{}, err
+		}
+		t.UserSet = &us
+		return t, nil
+	}
+	userId, err := unescape(subject, reservedUserId)
+	if err != nil {
+		return Tuple{}, err
+	}
+	if userId == "" {
+		return Tuple{}, fmt.Errorf("%w: empty subject", ErrInvalidTuple)
+	}
+	t.UserId = UserId(userId)
+	return t, nil
+}
+
+// checkString formats the check of userId on ⟨ns, obj, rel⟩ as the tuple it
+// asks for, for errors and logs.
+func checkString(ns Ns, obj Obj, rel Rel, userId UserId) string {
+	return Tuple{Ns: ns, Obj: obj, Rel: rel, UserId: userId}.String()
+}
+
+// listString formats a list of the objects userId has rel on as ns:*#rel@userId.
+func listString(ns Ns, rel Rel, userId UserId) string {
+	return checkString(ns, "*", rel, userId)
+}
+
+func formatTriple(ns Ns, obj Obj, rel Rel) string {
+	return escape(string(ns), reservedTriple) + ":" + escape(string(obj), reservedTriple) + "#" + escape(string(rel), reservedTriple)
+}
+
+func parseTriple(s string) (Ns, Obj, Rel, error) {
+	colon := indexUnescaped(s, ':')
+	if colon < 0 {
+		return "", "", "", fmt.Errorf("%w: missing ns:", ErrInvalidTuple)
+	}
+	hash := indexUnescaped(s[colon+1:], '#')
+	if hash < 0 {
+		return "", "", "", fmt.Errorf("%w: missing #rel", ErrInvalidTuple)
+	}
+	hash += colon + 1
+	parts := [3]string{s[:colon], s[colon+1 : hash], s[hash+1:]}
+	for i, p := range parts {
+		v, err := unescape(p, reservedTriple)
+		if err != nil {
+			return "", "", "", err
+		}
+		if v == "" {
+			return "", "", "", fmt.Errorf("%w: empty %s", ErrInvalidTuple, [3]string{"ns", "obj", "rel"}[i])
+		}
+		parts[i] = v
+	}
+	return Ns(parts[0]), Obj(parts[1]), Rel(parts[2]), nil
+}
+
+func escape(s, reserved string) string {
+	if !strings.ContainsAny(s, reserved) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(reserved, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// unescape removes escapes and rejects unescaped reserved characters, so a
+// component that parses is exactly what escape produced.
+func unescape(s, reserved string) (string, error) {
+	if !strings.ContainsAny(s, reserved) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 == len(s) || strings.IndexByte(reserved, s[i+1]) < 0 {
+				return "", fmt.Errorf("%w: bad escape at %d", ErrInvalidTuple, i)
+			}
+			i++
+			b.WriteByte(s[i])
+		case strings.IndexByte(reserved, c) >= 0:
+			return "", fmt.Errorf("%w: unescaped %q at %d", ErrInvalidTuple, c, i)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// indexUnescaped returns the index of the first c not preceded by an escape.
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+	return -1
+}
+
+// lastUnescaped returns the index of the last unescaped c.
+func lastUnescaped(s string, c byte) int {
+	last := -1
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case c:
+			last = i
+		}
+	}
+	return last
+}
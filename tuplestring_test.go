@@ -0,0 +1,103 @@
+package nioclient
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTupleStringCanonicalForm(t *testing.T) {
+	exp := time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		tuple Tuple
+		want  string
+	}{
+		{Tuple{Ns: "doc", Obj: "readme", Rel: "viewer", UserId: "user:123"}, "doc:readme#viewer@user:123"},
+		{Tuple{Ns: "doc", Obj: "readme", Rel: "viewer", UserSet: &UserSet{Ns: "group", Obj: "eng", Rel: "member"}}, "doc:readme#viewer@group:eng#member"},
+		{Tuple{Ns: "doc", Obj: "readme", Rel: "viewer", UserId: "user:123", Expires: &exp}, "doc:readme#viewer@user:123[expires=2030-01-15T12:00:00Z]"},
+		{Tuple{Ns: "root", Obj: "root", Rel: "admin", UserId: "local:admin@local.local:123456"}, "root:root#admin@local:admin@local.local:123456"},
+		{Tuple{Ns: "doc", Obj: "a:b#c", Rel: "viewer", UserId: "x#y[z]"}, `doc:a\:b\#c#viewer@x\#y\[z\]`},
+		{Tuple{Ns: "doc", Obj: "1", Rel: RelParent, UserSet: &UserSet{Ns: "folder", Obj: "f", Rel: RelUnspecified}}, "doc:1#parent@folder:f#..."},
+	}
+	for _, tc := range cases {
+		if got := tc.tuple.String(); got != tc.want {
+			t.Errorf("String() = %q, want %q", got, tc.want)
+		}
+		back, err := ParseTuple(tc.want)
+		if err != nil {
+			t.Errorf("ParseTuple(%q): %v", tc.want, err)
+			continue
+		}
+		if back.String() != tc.want {
+			t.Errorf("round trip %q -> %q", tc.want, back.String())
+		}
+	}
+}
+
+func TestParseTupleFields(t *testing.T) {
+	got, err := ParseTuple("doc:readme#viewer@group:eng#member[expires=2030-01-15T13:00:00+01:00]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Ns != "doc" || got.Obj != "readme" || got.Rel != "viewer" || got.UserId != "" {
+		t.Fatalf("got %+v", got)
+	}
+	if got.UserSet == nil || *got.UserSet != (UserSet{Ns: "group", Obj: "eng", Rel: "member"}) {
+		t.Fatalf("userset = %+v", got.UserSet)
+	}
+	if got.Expires == nil || got.Expires.Unix() != time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC).Unix() || got.Expires.Location() != time.UTC {
+		t.Fatalf("expires = %v, want 12:00 UTC", got.Expires)
+	}
+}
+
+func TestParseTupleErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"doc:readme#viewer",          // no subject
+		"doc:readme@user",            // no rel
+		"docreadme#viewer@user",      // no ns
+		"doc:readme#viewer@",         // empty subject
+		"doc:readme#viewer@u[ttl=1]", // unknown condition
+		"doc:readme#viewer@u[expires=tomorrow]",
+		`doc:read\me#viewer@u`, // bad escape
+		"doc:a:b#viewer@u",     // unescaped reserved char in obj
+		"doc:readme#viewer@u]",
+	} {
+		if _, err := ParseTuple(s); !errors.Is(err, ErrInvalidTuple) {
+			t.Errorf("ParseTuple(%q) = %v, want ErrInvalidTuple", s, err)
+		}
+	}
+}
+
+func TestParseUserSet(t *testing.T) {
+	us, err := ParseUserSet("group:eng#member")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if us != (UserSet{Ns: "group", Obj: "eng", Rel: "member"}) || us.String() != "group:eng#member" {
+		t.Fatalf("got %+v / %q", us, us.String())
+	}
+	if _, err := ParseUserSet("group:eng"); err == nil {
+		t.Fatal("expected error without #rel")
+	}
+}
+
+func TestRPCErrorsUseCanonicalForm(t *testing.T) {
+	fake := &fakeCheckService{}
+	fake.check = func(*proto.CheckRequest) (*proto.CheckResponse, error) {
+		return nil, status.Error(codes.Internal, "boom")
+	}
+	c := &checkAPI{grpcClient: fake}
+	_, _, err := c.Check(t.Context(), "doc", "readme", "viewer", "user:1")
+	if err == nil || !strings.HasPrefix(err.Error(), "check doc:readme#viewer@user:1: ") {
+		t.Fatalf("err = %v", err)
+	}
+	if got := listString("doc", "viewer", "user:1"); got != "doc:*#viewer@user:1" {
+		t.Fatalf("listString = %q", got)
+	}
+}
@@ -0,0 +1,77 @@
+package nioclient
+
+// Optimistic-concurrency read-modify-write of stored tuples: read at a
+// snapshot, let the caller compute the diff, write with that snapshot as the
+// precondition zookie, and start over when a concurrent write got in first.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// DefaultUpdateAttempts is the UpdateTuples attempt limit when
+// UpdateOptions.MaxAttempts is not set.
+const DefaultUpdateAttempts = 5
+
+// UpdateOptions tunes UpdateTuples. The zero value is usable.
+type UpdateOptions struct {
+	MaxAttempts int           // read-modify-write rounds; <= 0 = DefaultUpdateAttempts
+	Backoff     time.Duration // upper bound of the jittered pause between rounds; 0 = none
+}
+
+// UpdateMutator computes the change to apply given the tuples currently
+// stored under the filters. It may be called several times — once per attempt
+// — and must not have side effects beyond computing add and del. Returning
+// no tuples skips the write.
+type UpdateMutator func(current []Tuple) (add, del []Tuple, err error)
+
+// UpdateTuples reads the tuples matching filters, calls mutate, and writes the
+// result with the read snapshot as precondition. When the write fails with
+// ErrPreconditionFailed (something changed since the read) the whole round is
+// repeated, up to opts.MaxAttempts. Returns the commit zookie, or the read
+// snapshot when mutate requested no change. Errors from mutate are returned
+// unchanged without retrying.
+func (c *checkAPI) UpdateTuples(ctx context.Context, filters []ReadFilter, mutate UpdateMutator, opts UpdateOptions) (Timestamp, error) {
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultUpdateAttempts
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 && opts.Backoff > 0 {
+			timer := time.NewTimer(time.Duration(rand.Int63n(int64(opts.Backoff) + 1)))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return "", ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		var res ReadResult
+		res, err = c.Read(ctx, filters...)
+		if err != nil {
+			return "", fmt.Errorf("update tuples: %w", err)
+		}
+		add, del, mErr := mutate(res.Tuples)
+		if mErr != nil {
+			return "", mErr
+		}
+		if len(add) == 0 && len(del) == 0 {
+			return res.Ts, nil
+		}
+		precondition := res.Ts
+		var ts Timestamp
+		ts, err = c.Write(ctx, add, del, &precondition)
+		if err == nil {
+			return ts, nil
+		}
+		if !errors.Is(err, ErrPreconditionFailed) {
+			return "", fmt.Errorf("update tuples: %w", err)
+		}
+	}
+	return "", fmt.Errorf("update tuples: gave up after %d attempts: %w", attempts, err)
+}
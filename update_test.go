@@ -0,0 +1,109 @@
+package nioclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// versionedStore is a fake check whose snapshot advances on every read; writes
+// succeed only once conflicts reaches zero.
+type versionedStore struct {
+	version   int
+	conflicts int
+}
+
+func (s *versionedStore) fake() *fakeCheckService {
+	return &fakeCheckService{
+		read: func(*proto.ReadRequest) (*proto.ReadResponse, error) {
+			s.version++
+			return &proto.ReadResponse{
+				Ts: fmt.Sprintf("v%d", s.version),
+				Tuples: []*proto.Tuple{{
+					Ns: "doc", Obj: "1", Rel: "viewer", User: &proto.Tuple_UserId{UserId: "old"},
+				}},
+			}, nil
+		},
+		write: func(in *proto.WriteRequest) (*proto.WriteResponse, error) {
+			if in.GetTs() != fmt.Sprintf("v%d", s.version) {
+				return nil, status.Error(codes.Internal, "precondition is not the last read snapshot")
+			}
+			if s.conflicts > 0 {
+				s.conflicts--
+				return nil, status.Error(codes.FailedPrecondition, "stale precondition")
+			}
+			return &proto.WriteResponse{Ts: "commit"}, nil
+		},
+	}
+}
+
+func replaceViewer(current []Tuple) (add, del []Tuple, err error) {
+	next := Tuple{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "new"}
+	return []Tuple{next}, current, nil
+}
+
+func TestUpdateTuplesRetriesOnConflict(t *testing.T) {
+	store := &versionedStore{conflicts: 2}
+	c := &checkAPI{grpcClient: store.fake()}
+	calls := 0
+	mutate := func(current []Tuple) ([]Tuple, []Tuple, error) {
+		calls++
+		return replaceViewer(current)
+	}
+
+	ts, err := c.UpdateTuples(context.Background(), []ReadFilter{FilterByObject("doc", "1", nil)}, mutate, UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts != "commit" {
+		t.Fatalf("ts = %q, want the commit zookie", ts)
+	}
+	if calls != 3 {
+		t.Fatalf("mutator calls = %d, want 3 (two conflicts + success)", calls)
+	}
+}
+
+func TestUpdateTuplesGivesUp(t *testing.T) {
+	store := &versionedStore{conflicts: 10}
+	c := &checkAPI{grpcClient: store.fake()}
+
+	_, err := c.UpdateTuples(context.Background(), []ReadFilter{FilterByObject("doc", "1", nil)}, replaceViewer, UpdateOptions{MaxAttempts: 2})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("err = %v, want ErrPreconditionFailed", err)
+	}
+	if store.version != 2 {
+		t.Fatalf("reads = %d, want 2", store.version)
+	}
+}
+
+func TestUpdateTuplesNoChangeSkipsWrite(t *testing.T) {
+	store := &versionedStore{}
+	fake := store.fake()
+	c := &checkAPI{grpcClient: fake}
+
+	ts, err := c.UpdateTuples(context.Background(), []ReadFilter{FilterByObject("doc", "1", nil)},
+		func([]Tuple) ([]Tuple, []Tuple, error) { return nil, nil, nil }, UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts != "v1" || fake.count("write") != 0 {
+		t.Fatalf("ts = %q, writes = %d; want read snapshot and no write", ts, fake.count("write"))
+	}
+}
+
+func TestUpdateTuplesMutatorErrorStops(t *testing.T) {
+	store := &versionedStore{}
+	c := &checkAPI{grpcClient: store.fake()}
+	boom := errors.New("boom")
+
+	_, err := c.UpdateTuples(context.Background(), []ReadFilter{FilterByObject("doc", "1", nil)},
+		func([]Tuple) ([]Tuple, []Tuple, error) { return nil, nil, boom }, UpdateOptions{})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want mutator error", err)
+	}
+}
@@ -12,13 +12,26 @@ type User interface {
 	IsAuthenticated() bool
 }
 
+// ListLimiter is implemented by the Users of Wrap, WrapHTTP and Middleware:
+// ListLimit is List returning at most limit objects; the server stops listing
+// early where it can.
+//
+//	if ll, ok := u.(nioclient.ListLimiter); ok {
+//		objs, err = ll.ListLimit("doc", "doc.get", 20)
+//	}
+type ListLimiter interface {
+	ListLimit(ns string, rel string, limit int) ([]string, error)
+}
+
 type user struct {
 	ns        Ns
 	obj       Obj
 	principal Principal
+	userId    UserId // the resolved credential; empty when anonymous
 	ctx       context.Context
 	check     func(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId) (principal Principal, ok bool, err error)
 	list      func(ctx context.Context, ns Ns, rel Rel, userId UserId) ([]string, error)
+	listLimit func(ctx context.Context, ns Ns, rel Rel, userId UserId, limit int) ([]string, error)
 }
 
 func (u *user) Principal() string {
@@ -54,7 +67,7 @@ func (u *user) HasRel(args ...string) (bool, error) {
 	}
 	_, ok, err := u.check(u.ctx, ns, obj, rel, UserId(u.principal))
 	if err != nil {
-		return false, fmt.Errorf("user check: %s %s %s: %w", ns, obj, rel, err)
+		return false, fmt.Errorf("user check: %s: %w", checkString(ns, obj, rel, UserId(u.principal)), err)
 	}
 	return ok, nil
 }
@@ -62,7 +75,15 @@ func (u *user) HasRel(args ...string) (bool, error) {
 func (u *user) List(ns string, rel string) ([]string, error) {
 	objs, err := u.list(u.ctx, Ns(ns), Rel(rel), UserId(u.principal))
 	if err != nil {
-		return nil, fmt.Errorf("list: %s %s: %w", ns, rel, err)
+		return nil, fmt.Errorf("list: %s: %w", UserSet{Ns: Ns(ns), Obj: "*", Rel: Rel(rel)}, err)
+	}
+	return objs, nil
+}
+
+func (u *user) ListLimit(ns string, rel string, limit int) ([]string, error) {
+	objs, err := u.listLimit(u.ctx, Ns(ns), Rel(rel), UserId(u.principal), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list: %s: %w", UserSet{Ns: Ns(ns), Obj: "*", Rel: Rel(rel)}, err)
 	}
 	return objs, nil
 }
@@ -0,0 +1,210 @@
+package nioclient
+
+// WhoCan answers "who can rel on ns:obj, and why": ExpandRecursive finds the
+// effective principals, reads attribute each of them to the stored tuple that
+// grants it and to the tuples linking every userset on the way, and
+// ListNamespaces labels every step with the rewrite of its relation. All RPCs
+// run at the snapshot of the first expand.
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// AccessStep is one userset on the way from the queried userset to a grant.
+type AccessStep struct {
+	UserSet UserSet
+	// Kind is the rewrite of the relation (this | computed | tuple_to | union);
+	// empty when the schema does not declare it.
+	Kind string
+	// Via is the stored tuple that adds this userset to the previous step: a
+	// userset subject, or the tupleset tuple of a tuple_to. Nil for the first
+	// step and for computed rewrites.
+	Via *Tuple
+}
+
+// AccessPath is one way a principal is granted access: the chain of usersets
+// from the queried one, and the stored tuple granting the principal on the
+// last of them. Grant is nil when the last userset yields the principal
+// through a rewrite rather than a stored tuple of its own.
+type AccessPath struct {
+	Steps []AccessStep
+	Grant *Tuple
+}
+
+// PrincipalAccess lists every path granting one principal.
+type PrincipalAccess struct {
+	UserId UserId
+	Paths  []AccessPath
+}
+
+// WhoCanResult is the outcome of WhoCan. Principals are sorted by UserId and
+// exclude the allUsers / authenticatedUsers markers, which set AllUsers and
+// AuthenticatedUsers and are listed with their paths in Public. Complete is
+// false when the expansion stopped at DefaultExpandDepth.
+type WhoCanResult struct {
+	Ts                 Timestamp
+	Principals         []PrincipalAccess
+	AllUsers           bool
+	AuthenticatedUsers bool
+	Public             []PrincipalAccess
+	Complete           bool
+}
+
+// WhoCan lists every principal that has rel on ⟨ns, obj⟩ and the paths that
+// grant it.
+func (c *checkAPI) WhoCan(ctx context.Context, ns Ns, obj Obj, rel Rel) (WhoCanResult, error) {
+	metas, err := c.ListNamespaces(ctx)
+	if err != nil {
+		return WhoCanResult{}, err
+	}
+	kinds := make(map[UserSet]string) // keyed by ⟨ns, "", rel⟩
+	for _, m := range metas {
+		for _, r := range m.Relations {
+			kinds[UserSet{Ns: Ns(m.Name), Rel: Rel(r.Name)}] = r.Kind
+		}
+	}
+	tree, err := c.ExpandRecursive(ctx, ns, obj, rel, ExpandOptions{})
+	if err != nil {
+		return WhoCanResult{}, err
+	}
+
+	w := &whoCan{c: c, ts: tree.Ts, grants: make(map[UserSet]map[UserId]*Tuple), links: make(map[[2]UserSet]*Tuple)}
+	if err := w.fetch(ctx, tree.Root); err != nil {
+		return WhoCanResult{}, err
+	}
+
+	byUser := make(map[UserId][]AccessPath)
+	var walk func(n *ExpandNode, steps []AccessStep)
+	walk = func(n *ExpandNode, steps []AccessStep) {
+		step := AccessStep{UserSet: n.UserSet, Kind: kinds[UserSet{Ns: n.UserSet.Ns, Rel: n.UserSet.Rel}]}
+		if len(steps) > 0 {
+			step.Via = w.links[[2]UserSet{steps[len(steps)-1].UserSet, n.UserSet}]
+		}
+		steps = append(slices.Clip(steps), step)
+		for _, id := range n.UserIds {
+			uid := UserId(id)
+			byUser[uid] = append(byUser[uid], AccessPath{Steps: steps, Grant: w.grants[n.UserSet][uid]})
+		}
+		for _, child := range n.Children {
+			if !child.Cycle && !child.Truncated {
+				walk(child, steps)
+			}
+		}
+	}
+	walk(tree.Root, nil)
+
+	res := WhoCanResult{Ts: tree.Ts, Complete: tree.Complete}
+	for uid, paths := range byUser {
+		access := PrincipalAccess{UserId: uid, Paths: paths}
+		switch uid {
+		case UserIdAllUsers:
+			res.AllUsers = true
+			res.Public = append(res.Public, access)
+		case UserIdAuthenticatedUsers:
+			res.AuthenticatedUsers = true
+			res.Public = append(res.Public, access)
+		default:
+			res.Principals = append(res.Principals, access)
+		}
+	}
+	byId := func(a, b PrincipalAccess) int { return strings.Compare(string(a.UserId), string(b.UserId)) }
+	slices.SortFunc(res.Principals, byId)
+	slices.SortFunc(res.Public, byId)
+	return res, nil
+}
+
+// whoCan holds the pinned reads attributing an ExpandTree.
+type whoCan struct {
+	c  *checkAPI
+	ts Timestamp
+
+	mu     sync.Mutex
+	grants map[UserSet]map[UserId]*Tuple // direct user tuples per userset
+	links  map[[2]UserSet]*Tuple         // ⟨parent, child⟩ → linking tuple
+}
+
+// fetch reads the direct grants of every userset with user ids and the link
+// of every parent → child edge of the tree, DefaultExpandConcurrency at a time.
+func (w *whoCan) fetch(ctx context.Context, root *ExpandNode) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(DefaultExpandConcurrency)
+	seenSets := make(map[UserSet]bool)
+	seenEdges := make(map[[2]UserSet]bool)
+	var visit func(n *ExpandNode)
+	visit = func(n *ExpandNode) {
+		if len(n.UserIds) > 0 && !seenSets[n.UserSet] {
+			seenSets[n.UserSet] = true
+			us := n.UserSet
+			g.Go(func() error { return w.fetchGrants(ctx, us) })
+		}
+		for _, child := range n.Children {
+			if child.Cycle || child.Truncated {
+				continue
+			}
+			edge := [2]UserSet{n.UserSet, child.UserSet}
+			if !seenEdges[edge] {
+				seenEdges[edge] = true
+				g.Go(func() error { return w.fetchLink(ctx, edge[0], edge[1]) })
+			}
+			visit(child)
+		}
+	}
+	visit(root)
+	return g.Wait()
+}
+
+func (w *whoCan) fetchGrants(ctx context.Context, us UserSet) error {
+	rel := us.Rel
+	res, err := w.c.ReadWithTimestamp(ctx, w.ts, FilterByObject(us.Ns, us.Obj, &rel))
+	if err != nil {
+		return err
+	}
+	grants := make(map[UserId]*Tuple)
+	for i := range res.Tuples {
+		if t := &res.Tuples[i]; t.UserSet == nil {
+			grants[t.UserId] = t
+		}
+	}
+	w.mu.Lock()
+	w.grants[us] = grants
+	w.mu.Unlock()
+	return nil
+}
+
+// fetchLink finds the tuple adding child to parent: parent#rel@child as a
+// userset subject, else a tupleset tuple parent#…@child-object#... of a
+// tuple_to rewrite.
+func (w *whoCan) fetchLink(ctx context.Context, parent, child UserSet) error {
+	rel := parent.Rel
+	res, err := w.c.ReadWithTimestamp(ctx, w.ts, FilterByUserSet(parent.Ns, child, &rel))
+	if err != nil {
+		return err
+	}
+	link := findObjTuple(res.Tuples, parent.Obj)
+	if link == nil {
+		pointer := UserSet{Ns: child.Ns, Obj: child.Obj, Rel: RelUnspecified}
+		res, err := w.c.ReadWithTimestamp(ctx, w.ts, FilterByUserSet(parent.Ns, pointer, nil))
+		if err != nil {
+			return err
+		}
+		link = findObjTuple(res.Tuples, parent.Obj)
+	}
+	w.mu.Lock()
+	w.links[[2]UserSet{parent, child}] = link
+	w.mu.Unlock()
+	return nil
+}
+
+func findObjTuple(tuples []Tuple, obj Obj) *Tuple {
+	for i := range tuples {
+		if tuples[i].Obj == obj {
+			return &tuples[i]
+		}
+	}
+	return nil
+}
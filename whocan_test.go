@@ -0,0 +1,87 @@
+package nioclient
+
+import (
+	"testing"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+)
+
+// whoCanFixture: project:p42#update = this ∪ owner ∪ group members ∪
+// parent→update. alice is granted directly and through the parent folder.
+func whoCanFixture(t *testing.T) *checkAPI {
+	expand := map[string]*proto.ExpandResponse{
+		"project:p42#update": {UserIds: []string{"alice"}, Usersets: []*proto.UserSet{
+			{Ns: "project", Obj: "p42", Rel: "owner"},
+			{Ns: "group", Obj: "eng", Rel: "member"},
+			{Ns: "folder", Obj: "f1", Rel: "update"}}},
+		"project:p42#owner": {UserIds: []string{"bob"}},
+		"group:eng#member":  {UserIds: []string{"carol", "authenticatedUsers"}},
+		"folder:f1#update":  {UserIds: []string{"alice"}},
+	}
+	fake := &fakeCheckService{
+		expand: func(in *proto.ExpandRequest) (*proto.ExpandResponse, error) {
+			res := expand[in.Ns+":"+in.Obj+"#"+in.Rel]
+			return &proto.ExpandResponse{Ts: "snap", UserIds: res.UserIds, Usersets: res.Usersets}, nil
+		},
+		read: readTuples(t, "snap",
+			"project:p42#update@alice",
+			"project:p42#owner@bob",
+			"project:p42#update@group:eng#member",
+			"project:p42#parent@folder:f1#...",
+			"group:eng#member@carol",
+			"group:eng#member@authenticatedUsers",
+			"folder:f1#update@alice",
+		),
+	}
+	ns := &fakeNamespaceService{namespaces: []*proto.NamespaceMeta{
+		{Name: "project", Relations: []*proto.RelationMeta{{Name: "update", Kind: "union"}, {Name: "owner", Kind: "this"}}},
+		{Name: "group", Relations: []*proto.RelationMeta{{Name: "member", Kind: "this"}}},
+		{Name: "folder", Relations: []*proto.RelationMeta{{Name: "update", Kind: "this"}}},
+	}}
+	return &checkAPI{grpcClient: fake, nsClient: ns}
+}
+
+func TestWhoCan(t *testing.T) {
+	c := whoCanFixture(t)
+	res, err := c.WhoCan(t.Context(), "project", "p42", "update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AllUsers || !res.AuthenticatedUsers || !res.Complete || res.Ts != "snap" {
+		t.Fatalf("flags = %+v", res)
+	}
+	if len(res.Principals) != 3 {
+		t.Fatalf("principals = %+v, want alice, bob, carol", res.Principals)
+	}
+	alice, bob, carol := res.Principals[0], res.Principals[1], res.Principals[2]
+	if alice.UserId != "alice" || bob.UserId != "bob" || carol.UserId != "carol" {
+		t.Fatalf("principals not sorted: %+v", res.Principals)
+	}
+
+	// alice: a direct tuple, and through the parent folder's tuple_to link.
+	if len(alice.Paths) != 2 {
+		t.Fatalf("alice paths = %+v", alice.Paths)
+	}
+	if direct := alice.Paths[0]; len(direct.Steps) != 1 || direct.Grant.String() != "project:p42#update@alice" {
+		t.Errorf("alice direct path = %+v", direct)
+	}
+	viaFolder := alice.Paths[1]
+	if got := viaFolder.Steps[1].Via.String(); got != "project:p42#parent@folder:f1#..." {
+		t.Errorf("alice folder link = %s", got)
+	}
+	if viaFolder.Grant.String() != "folder:f1#update@alice" {
+		t.Errorf("alice folder grant = %v", viaFolder.Grant)
+	}
+
+	// bob: computed owner rewrite, no linking tuple.
+	if step := bob.Paths[0].Steps[1]; step.Via != nil || step.Kind != "this" || step.UserSet.Rel != "owner" {
+		t.Errorf("bob step = %+v", step)
+	}
+	// carol: through the group userset subject.
+	if got := carol.Paths[0].Steps[1].Via.String(); got != "project:p42#update@group:eng#member" {
+		t.Errorf("carol link = %s", got)
+	}
+	if len(res.Public) != 1 || res.Public[0].Paths[0].Grant.String() != "group:eng#member@authenticatedUsers" {
+		t.Errorf("public = %+v", res.Public)
+	}
+}
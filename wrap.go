@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -97,7 +98,13 @@ func mapErrorAndRespond(err error, w http.ResponseWriter, req *http.Request) (er
 		return ""
 	}
 
-	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	// Check RPC failures: the status is derived from the failure kind; the
+	// body stays generic so server internals do not leak to the client.
+	status := HTTPStatus(err)
+	http.Error(w, http.StatusText(status), status)
+	if status < http.StatusInternalServerError {
+		return ""
+	}
 	errMsg = fmt.Sprintf("%v", err)
 	return errMsg
 }
@@ -117,7 +124,9 @@ type Wrapper interface {
 	Prefix() string
 	// ResolveToken maps a raw session token to the principal UserId to pass to
 	// check. found=false with a nil error means the token is
-	// unknown/expired/revoked; Wrap then redirects to signin with zero check RPCs.
+	// unknown/expired/revoked; Wrap then redirects to signin (401 for API
+	// clients) with zero check RPCs. WithCredentials can resolve other
+	// credentials with their own TokenResolver.
 	ResolveToken(ctx context.Context, token string) (userId UserId, found bool, err error)
 	Check(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId) (principal Principal, ok bool, err error)
 	CheckWithTimestamp(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId, ts Timestamp) (principal Principal, ok bool, err error)
@@ -126,109 +135,204 @@ type Wrapper interface {
 
 const Impossible = Rel("impossible")
 
+// limitLister is implemented by wrappers that can stop a list early, such as
+// SessionClient.
+type limitLister interface {
+	ListLimit(ctx context.Context, ns Ns, rel Rel, userId UserId, ts Timestamp, limit int) (ListResult, error)
+}
+
+// listLimitOf returns the User.ListLimit backend for wrapper: its ListLimit
+// when it has one, else its full List truncated to limit.
+func listLimitOf(wrapper Wrapper) listLimitFunc {
+	if ll, ok := wrapper.(limitLister); ok {
+		return func(ctx context.Context, ns Ns, rel Rel, userId UserId, limit int) ([]string, error) {
+			res, err := ll.ListLimit(ctx, ns, rel, userId, TimestampEmpty, limit)
+			return res.Objs, err
+		}
+	}
+	return func(ctx context.Context, ns Ns, rel Rel, userId UserId, limit int) ([]string, error) {
+		objs, err := wrapper.List(ctx, ns, rel, userId)
+		if limit > 0 && len(objs) > limit {
+			objs = objs[:limit]
+		}
+		return objs, err
+	}
+}
+
+// Wrap returns an httprouter handle that resolves the session, checks the
+// permission the extracted resource requires for the request method and then
+// calls hdl. WrapHTTP is the same for net/http.
 func Wrap(wrapper Wrapper, extract func(http.ResponseWriter, *http.Request, httprouter.Params) (Resource, error), hdl HandlerFunc, opts ...WrapOption) httprouter.Handle {
-	var cfg wrapConfig
+	wd := newWrapped(wrapper, opts)
+	wd.recordRoute(callsite())
+	return httprouter.Handle(func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		wd.serve(rw, r, func(rw http.ResponseWriter, r *http.Request) (Resource, error) {
+			return extract(rw, r, p)
+		}, func(w http.ResponseWriter, r *http.Request, resource Resource, u User) error {
+			return hdl(w, r, p, resource, u)
+		})
+	})
+}
+
+// wrapped is the router-independent core of Wrap and WrapHTTP.
+type wrapped struct {
+	wrapper Wrapper
+	cfg     wrapConfig
+
+	// route is the callsite name of an unsampled route in routes; learned is
+	// set once serve recorded its sample.
+	route   string
+	routes  *RouteRegistry
+	learned atomic.Bool
+}
+
+func newWrapped(wrapper Wrapper, opts []WrapOption) *wrapped {
+	wd := &wrapped{wrapper: wrapper}
 	for _, o := range opts {
-		o(&cfg)
+		o(&wd.cfg)
 	}
-	return httprouter.Handle(func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	if len(wd.cfg.credentials) == 0 {
+		wd.cfg.credentials = []Credential{FromCookie(SessionCookie)}
+	}
+	return wd
+}
 
-		resource, err := extract(rw, r, p)
-		if err != nil {
-			errMsg := errorHandlerFunc(notFound(err), rw, r)
-			if errMsg != "" {
-				log.Printf("%s %s: error=%s (extract)", r.Method, r.RequestURI, errMsg)
-			}
-			return
-		}
-		// Request handled by extract?
-		if resource == nil {
-			return
-		}
-		ns, obj, rel := resource.Requires(r.Method)
-		//fmt.Printf("Requires: %s,%s,%s (%s)\n", ns, obj, rel, r.URL) // TODO remove
-
-		user := user{
-			ns:        ns,
-			obj:       obj,
-			principal: Anonymous,
-			ctx:       r.Context(),
-			check:     wrapper.Check,
-			list:      wrapper.List,
+func (wd *wrapped) serve(rw http.ResponseWriter, r *http.Request, extract HTTPExtractFunc, hdl HTTPHandlerFunc) {
+	r = wd.withZookies(rw, r)
+
+	resource, err := extract(rw, r)
+	if err != nil {
+		errMsg := errorHandlerFunc(notFound(err), rw, r)
+		if errMsg != "" {
+			log.Printf("%s %s: error=%s (extract)", r.Method, r.RequestURI, errMsg)
 		}
+		return
+	}
+	// Request handled by extract?
+	if resource == nil {
+		return
+	}
+	wd.learnRoute(resource)
+	ns, obj, rel := resource.Requires(r.Method)
+	//fmt.Printf("Requires: %s,%s,%s (%s)\n", ns, obj, rel, r.URL) // TODO remove
 
-		sessionCookie, err := r.Cookie("session")
-		if errors.Is(err, http.ErrNoCookie) {
-			if _, ok := resource.(publicResource); ok {
-				//log.Printf("%s %s: no session cookie but public resource", r.Method, r.RequestURI)
-				err = hdl(rw, r, p, resource, &user)
-				if err != nil {
-					if errMsg := errorHandlerFunc(err, rw, r); errMsg != "" {
-						log.Printf("%s %s: error=%s (extract)", r.Method, r.RequestURI, errMsg)
-					}
+	userId, present, found, browser, err := wd.authenticate(r)
+	if !present {
+		if _, ok := resource.(publicResource); ok {
+			//log.Printf("%s %s: no credential but public resource", r.Method, r.RequestURI)
+			user := wd.newUser(r, ns, obj, Anonymous)
+			err = hdl(rw, r.WithContext(ContextWithUser(r.Context(), user)), resource, user)
+			if err != nil {
+				if errMsg := errorHandlerFunc(err, rw, r); errMsg != "" {
+					log.Printf("%s %s: error=%s (extract)", r.Method, r.RequestURI, errMsg)
 				}
-				return
-			}
-			back := url.QueryEscape(r.RequestURI)
-			uri := fmt.Sprintf("%s/signin?back=%s", wrapper.Prefix(), back)
-			http.Redirect(rw, r, uri, http.StatusSeeOther)
-			return
-		}
-		token := sessionCookie.Value
-
-		// Resolve the opaque session token to a principal via am.SessionService
-		// (issue #243/#245) — the raw token never reaches check. An
-		// unknown/expired/revoked token redirects to signin with zero check RPCs.
-		userId, found, err := wrapper.ResolveToken(r.Context(), token)
-		if err != nil {
-			if errMsg := errorHandlerFunc(fmt.Errorf("resolve: %w", err), rw, r); errMsg != "" {
-				log.Printf("%s %s: error=%s (resolve)", r.Method, r.RequestURI, errMsg)
 			}
 			return
 		}
-		if !found {
-			back := url.QueryEscape(r.RequestURI)
-			uri := fmt.Sprintf("%s/signin?back=%s", wrapper.Prefix(), back)
-			http.Redirect(rw, r, uri, http.StatusSeeOther)
-			return
+		wd.unauthenticated(rw, r, true)
+		return
+	}
+
+	// The credential is resolved to a principal (for the session cookie via
+	// am.SessionService, issue #243/#245) — the raw token never reaches check.
+	// An unknown/expired/revoked credential redirects to signin (401 for API
+	// clients) with zero check RPCs.
+	if err != nil {
+		if errMsg := errorHandlerFunc(fmt.Errorf("resolve: %w", err), rw, r); errMsg != "" {
+			log.Printf("%s %s: error=%s (resolve)", r.Method, r.RequestURI, errMsg)
 		}
+		return
+	}
+	if !found {
+		wd.unauthenticated(rw, r, browser)
+		return
+	}
 
-		// If we have a check-timestamp hint, overwrite the checkfunc
-		checkTimestampCookie, err := r.Cookie("check_ts")
-		if err == nil {
-			checkTimestamp := Timestamp(checkTimestampCookie.Value)
-			if checkTimestampCookie.Value == "" {
-				checkTimestamp = TimestampEmpty
-			}
-			user.check = func(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId) (principal Principal, ok bool, err error) {
-				return wrapper.CheckWithTimestamp(ctx, ns, obj, rel, userId, checkTimestamp)
-			}
+	user := wd.newUser(r, ns, obj, Anonymous)
+	user.userId = userId
+	Observe(rw, r, func(w http.ResponseWriter) error {
+		if ok, err := wd.authorize(w, r, user, resource, rel, userId); !ok {
+			return err
 		}
+		return hdl(w, r.WithContext(ContextWithUser(r.Context(), user)), resource, user)
+	})
+}
+
+// withZookies installs the request-scoped cookie zookie store when enabled.
+func (wd *wrapped) withZookies(rw http.ResponseWriter, r *http.Request) *http.Request {
+	if !wd.cfg.cookieZookies {
+		return r
+	}
+	store := NewCookieZookieStore(rw, r, wd.cfg.cookieZookieMaxAge)
+	return r.WithContext(ContextWithZookieStore(r.Context(), store))
+}
+
+// newUser builds the User of a request on ⟨ns, obj⟩: its checks honour the
+// check_ts cookie and are memoized when the route enables the memo.
+func (wd *wrapped) newUser(r *http.Request, ns Ns, obj Obj, principal Principal) *user {
+	wrapper := wd.wrapper
+	user := &user{
+		ns:        ns,
+		obj:       obj,
+		principal: principal,
+		ctx:       r.Context(),
+		check:     wrapper.Check,
+		list:      wrapper.List,
+		listLimit: listLimitOf(wrapper),
+	}
 
-		// Request-scoped memoization: dedupe identical check/list calls within
-		// this request.
-		if cfg.requestMemo {
-			memo := newRequestMemo(user.check, user.list, cfg.memoObserve)
-			user.check = memo.check
-			user.list = memo.list
+	// If we have a check-timestamp hint, overwrite the checkfunc
+	if checkTimestamp, ok := checkTimestampOf(r); ok {
+		user.check = func(ctx context.Context, ns Ns, obj Obj, rel Rel, userId UserId) (principal Principal, ok bool, err error) {
+			return wrapper.CheckWithTimestamp(ctx, ns, obj, rel, userId, checkTimestamp)
 		}
+	}
 
-		Observe(rw, r, func(w http.ResponseWriter) error {
-			principal, ok, err := user.check(r.Context(), ns, obj, rel, userId)
-			if err != nil {
-				return fmt.Errorf("check: %w", err)
-			}
-			if !ok {
-				// TODO use a 404 ?
-				w.WriteHeader(http.StatusForbidden)
-				_, _ = w.Write([]byte("Forbidden"))
-				return nil
-			}
+	// Request-scoped memoization: dedupe identical check/list calls within
+	// this request.
+	if wd.cfg.requestMemo {
+		memo := newRequestMemo(user.check, user.list, wd.cfg.memoObserve)
+		memo.listLimitNext = user.listLimit
+		user.check = memo.check
+		user.list = memo.list
+		user.listLimit = memo.listLimit
+	}
+	return user
+}
 
-			user.principal = principal
-			return hdl(w, r, p, resource, &user)
-		})
-	})
+// checkTimestampOf returns the zookie of the check_ts cookie of r.
+func checkTimestampOf(r *http.Request) (Timestamp, bool) {
+	c, err := r.Cookie(CheckTimestampCookie)
+	if err != nil {
+		return TimestampEmpty, false
+	}
+	if c.Value == "" {
+		return TimestampEmpty, true
+	}
+	return Timestamp(c.Value), true
+}
+
+// gate checks rel on the user's object for userId. When granted it sets the
+// user's principal and returns true; otherwise it has responded 403.
+func gate(w http.ResponseWriter, user *user, rel Rel, userId UserId) (bool, error) {
+	principal, ok, err := user.check(user.ctx, user.ns, user.obj, rel, userId)
+	if err != nil {
+		return false, fmt.Errorf("check: %w", err)
+	}
+	if !ok {
+		// TODO use a 404 ?
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("Forbidden"))
+		return false, nil
+	}
+	user.principal = principal
+	return true, nil
+}
+
+func redirectToSignin(wrapper Wrapper, rw http.ResponseWriter, r *http.Request) {
+	back := url.QueryEscape(r.RequestURI)
+	uri := fmt.Sprintf("%s/signin?back=%s", wrapper.Prefix(), back)
+	http.Redirect(rw, r, uri, http.StatusSeeOther)
 }
 
 //func validateCookieValueAndSetTimestamp(timestampCookieVal string, nowUtcMillis string) Timestamp {
@@ -293,7 +397,7 @@ func Wrap(wrapper Wrapper, extract func(http.ResponseWriter, *http.Request, http
 //				list:      nil, //wrapper.List,
 //			}
 //
-//			return hdl(w, r, p, resource, &user)
+//			return hdl(w, r, resource, &user)
 //		})
 //	})
 //}
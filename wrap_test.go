@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,6 +14,8 @@ import (
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // resolvingWrapper implements the Wrapper contract for the middleware tests.
@@ -146,6 +149,10 @@ func TestSessionClientImplementsWrapper(t *testing.T) {
 	var _ Wrapper = (*SessionClient)(nil)
 }
 
+func TestWrapUserIsListLimiter(t *testing.T) {
+	var _ ListLimiter = (*user)(nil)
+}
+
 // memoProbeHandler runs the gate rel again (memo hit) plus a second rel twice.
 func memoProbeHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params, _ Resource, u User) error {
 	_, _ = u.HasRel("article.get")  // same as the route's gate rel -> memo hit
@@ -350,3 +357,54 @@ func TestSetErrorHandlerIsInvokedByWrap(t *testing.T) {
 		t.Fatalf("body = %q, want custom:boom", body)
 	}
 }
+
+// unavailableWrapper fails every check as a check-server outage would.
+type unavailableWrapper struct {
+	resolvingWrapper
+}
+
+func (w *unavailableWrapper) Check(_ context.Context, _ Ns, _ Obj, _ Rel, _ UserId) (Principal, bool, error) {
+	return "", false, rpcError(status.Error(codes.Unavailable, "connection refused"))
+}
+
+func TestWrapCheckUnavailableReturns503(t *testing.T) {
+	w := &unavailableWrapper{resolvingWrapper{resolvePrincipal: "P"}}
+	h := Wrap(w, extractTest, okHandler)
+
+	rr := httptest.NewRecorder()
+	h(rr, requestWithSession("tok"), nil)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503; body=%q", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "connection refused") {
+		t.Fatalf("body leaks the RPC error: %q", rr.Body.String())
+	}
+}
+
+func TestRequestMemoListLimitMemoizesPrefix(t *testing.T) {
+	var limits []int
+	limited := func(_ context.Context, _ Ns, _ Rel, _ UserId, limit int) ([]string, error) {
+		limits = append(limits, limit)
+		return []string{"a", "b", "c", "d", "e"}[:min(limit, 5)], nil
+	}
+	full := func(_ context.Context, _ Ns, _ Rel, _ UserId) ([]string, error) {
+		limits = append(limits, 0)
+		return []string{"a", "b", "c", "d", "e"}, nil
+	}
+	m := newRequestMemo(nil, full, nil)
+	m.listLimitNext = limited
+	ctx := context.Background()
+
+	m.listLimit(ctx, "n", "r", "u", 3) // miss
+	if got, _ := m.listLimit(ctx, "n", "r", "u", 2); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("prefix = %v", got) // hit: covered by the 3-prefix
+	}
+	m.listLimit(ctx, "n", "r", "u", 4)  // miss: longer than the cached prefix
+	m.list(ctx, "n", "r", "u")          // miss: prefix is not the whole list
+	m.listLimit(ctx, "n", "r", "u", 10) // hit: the full list covers every limit
+
+	if !slices.Equal(limits, []int{3, 4, 0}) {
+		t.Fatalf("underlying calls = %v, want [3 4 0]", limits)
+	}
+}
@@ -0,0 +1,48 @@
+package nioclient
+
+// net/http adapter for Wrap. Services routing with the Go 1.22 http.ServeMux
+// patterns read path wildcards with r.PathValue instead of httprouter.Params;
+// WrapHTTP serves them with the same core as Wrap (session resolution,
+// check_ts, WrapOptions, error mapping).
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HTTPHandlerFunc is HandlerFunc for WrapHTTP: path values are read from the
+// request with r.PathValue.
+type HTTPHandlerFunc func(http.ResponseWriter, *http.Request, Resource, User) error
+
+// HTTPExtractFunc extracts the Resource of a request for WrapHTTP. An error
+// responds 404; a nil Resource with a nil error means extract has already
+// responded.
+type HTTPExtractFunc func(http.ResponseWriter, *http.Request) (Resource, error)
+
+// WrapHTTP is Wrap for net/http:
+//
+//	mux.Handle("GET /articles/{id}", nioclient.WrapHTTP(client,
+//		nioclient.ExtractPathValue("id", func(id string) (nioclient.Resource, error) {
+//			return &ArticleResource{ID: id}, nil
+//		}),
+//		getArticle))
+func WrapHTTP(wrapper Wrapper, extract HTTPExtractFunc, hdl HTTPHandlerFunc, opts ...WrapOption) http.Handler {
+	wd := newWrapped(wrapper, opts)
+	wd.recordRoute(callsite())
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		wd.serve(rw, r, extract, hdl)
+	})
+}
+
+// ExtractPathValue returns an HTTPExtractFunc building the resource from the
+// path wildcard name of the matched ServeMux pattern. An empty value is an
+// error (a 404), like the errors of resource.
+func ExtractPathValue(name string, resource func(value string) (Resource, error)) HTTPExtractFunc {
+	return func(_ http.ResponseWriter, r *http.Request) (Resource, error) {
+		value := r.PathValue(name)
+		if value == "" {
+			return nil, fmt.Errorf("path value %q: missing in %s", name, r.URL.Path)
+		}
+		return resource(value)
+	}
+}
@@ -0,0 +1,69 @@
+package nioclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type pathResource struct{ id string }
+
+func (r *pathResource) Requires(string) (Ns, Obj, Rel) {
+	return "article", Obj(r.id), "article.get"
+}
+
+var extractArticleID = ExtractPathValue("id", func(id string) (Resource, error) {
+	return &pathResource{id: id}, nil
+})
+
+func TestWrapHTTPServeMuxPathValue(t *testing.T) {
+	w := &resolvingWrapper{resolvePrincipal: "P"}
+	mux := http.NewServeMux()
+	mux.Handle("GET /articles/{id}", WrapHTTP(w, extractArticleID, func(rw http.ResponseWriter, _ *http.Request, res Resource, u User) error {
+		_, _ = u.HasRel("article.get") // memo hit
+		_, _ = rw.Write([]byte(res.(*pathResource).id + ":" + string(u.Principal())))
+		return nil
+	}, WithRequestMemo()))
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, requestWithSession("tok"))
+
+	if rr.Code != http.StatusOK || rr.Body.String() != "1:P" {
+		t.Fatalf("status = %d, body = %q", rr.Code, rr.Body.String())
+	}
+	if w.checkCalls != 1 {
+		t.Fatalf("checkCalls = %d, want 1 (gate, then memo hit)", w.checkCalls)
+	}
+}
+
+func TestWrapHTTPRedirectsWithoutSession(t *testing.T) {
+	w := &resolvingWrapper{prefix: "/app"}
+	mux := http.NewServeMux()
+	mux.Handle("GET /articles/{id}", WrapHTTP(w, extractArticleID, func(http.ResponseWriter, *http.Request, Resource, User) error {
+		t.Fatal("handler called without session")
+		return nil
+	}))
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, requestWithSession(""))
+
+	if rr.Code != http.StatusSeeOther || !strings.HasPrefix(rr.Header().Get("Location"), "/app/signin?back=") {
+		t.Fatalf("status = %d, Location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+}
+
+func TestExtractPathValueMissingIs404(t *testing.T) {
+	w := &resolvingWrapper{resolvePrincipal: "P"}
+	h := WrapHTTP(w, extractArticleID, func(http.ResponseWriter, *http.Request, Resource, User) error {
+		t.Fatal("handler called without path value")
+		return nil
+	})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, requestWithSession("tok")) // not routed by a mux: no {id}
+
+	if rr.Code != http.StatusNotFound || w.checkCalls != 0 {
+		t.Fatalf("status = %d, checkCalls = %d; want 404 without check", rr.Code, w.checkCalls)
+	}
+}
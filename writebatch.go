@@ -0,0 +1,327 @@
+package nioclient
+
+// WriteBatch is a builder for Write that validates tuples client-side (instead
+// of failing in tupleToProto at send time), catches duplicates, and can split
+// very large batches into several writes when the caller explicitly gives up
+// atomicity.
+//
+// Server contract: check applies the deletes of a write before its adds, at
+// one commit zookie. Deleting and adding the same tuple in one write therefore
+// replaces it. Touch says so explicitly; a separate Add and Delete of one tuple
+// is reported as a contradiction.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultWriteChunkSize is the tuples-per-write used by AllowNonAtomicSplit
+// when no chunk size is given.
+const DefaultWriteChunkSize = 500
+
+var (
+	// ErrInvalidTuple is returned by WriteBatch.Validate for a tuple missing a
+	// field or with other than exactly one subject.
+	ErrInvalidTuple = errors.New("invalid tuple")
+	// ErrDuplicateTuple is returned by WriteBatch.Validate when the same tuple
+	// is added (or deleted) twice.
+	ErrDuplicateTuple = errors.New("duplicate tuple")
+	// ErrContradictoryTuple is returned by WriteBatch.Validate when the same
+	// tuple is both added and deleted; use Touch to replace a tuple.
+	ErrContradictoryTuple = errors.New("tuple both added and deleted")
+)
+
+// PartialWriteError is returned by a split WriteBatch commit that failed after
+// some chunks were committed. Committed counts the chunks that were applied;
+// Ts is the commit zookie of the last of them.
+type PartialWriteError struct {
+	Committed int
+	Chunks    int
+	Ts        Timestamp
+	Err       error
+}
+
+func (e *PartialWriteError) Error() string {
+	return fmt.Sprintf("write batch: %d of %d chunks committed: %v", e.Committed, e.Chunks, e.Err)
+}
+
+func (e *PartialWriteError) Unwrap() error {
+	return e.Err
+}
+
+type batchOp int
+
+const (
+	opAdd batchOp = iota
+	opDelete
+	opTouch
+)
+
+func (op batchOp) String() string {
+	switch op {
+	case opAdd:
+		return "add"
+	case opDelete:
+		return "delete"
+	default:
+		return "touch"
+	}
+}
+
+type batchEntry struct {
+	op    batchOp
+	tuple Tuple
+}
+
+// size is the number of wire tuples of the entry: a touch is a delete and an
+// add that must travel in the same write.
+func (e batchEntry) size() int {
+	if e.op == opTouch {
+		return 2
+	}
+	return 1
+}
+
+// tupleKey identifies a stored tuple; the expiry condition is not part of it.
+type tupleKey struct {
+	ns, obj, rel, userId string
+	userSet              UserSet
+	hasUserSet           bool
+}
+
+func keyOf(t Tuple) tupleKey {
+	k := tupleKey{ns: string(t.Ns), obj: string(t.Obj), rel: string(t.Rel)}
+	if t.UserSet != nil {
+		k.userSet, k.hasUserSet = *t.UserSet, true
+	} else {
+		k.userId = string(t.UserId)
+	}
+	return k
+}
+
+// WriteBatch accumulates tuple changes for one Write. Build it with
+// NewWriteBatch, chain Add/Delete/Touch calls and finish with Commit. A
+// WriteBatch is not safe for concurrent use.
+type WriteBatch struct {
+	c         *checkAPI
+	entries   []batchEntry
+	chunkSize int // > 0: non-atomic split opted in
+}
+
+// NewWriteBatch starts an empty batch committed through c.
+func (c *checkAPI) NewWriteBatch() *WriteBatch {
+	return &WriteBatch{c: c}
+}
+
+func (b *WriteBatch) push(op batchOp, t Tuple) *WriteBatch {
+	b.entries = append(b.entries, batchEntry{op: op, tuple: t})
+	return b
+}
+
+// Add adds userId on ⟨ns, obj, rel⟩.
+func (b *WriteBatch) Add(ns Ns, obj Obj, rel Rel, userId UserId) *WriteBatch {
+	return b.push(opAdd, Tuple{Ns: ns, Obj: obj, Rel: rel, UserId: userId})
+}
+
+// AddWithExpiry adds userId on ⟨ns, obj, rel⟩ until expires (UTC).
+func (b *WriteBatch) AddWithExpiry(ns Ns, obj Obj, rel Rel, userId UserId, expires time.Time) *WriteBatch {
+	exp := expires.UTC()
+	return b.push(opAdd, Tuple{Ns: ns, Obj: obj, Rel: rel, UserId: userId, Expires: &exp})
+}
+
+// AddUserSet adds the userset subject on ⟨ns, obj, rel⟩.
+func (b *WriteBatch) AddUserSet(ns Ns, obj Obj, rel Rel, userSet UserSet) *WriteBatch {
+	us := userSet
+	return b.push(opAdd, Tuple{Ns: ns, Obj: obj, Rel: rel, UserSet: &us})
+}
+
+// AddParent adds the "parent" link from ⟨ns, obj⟩ to ⟨parentNs, parentObj⟩, as
+// checkAPI.AddParent does.
+func (b *WriteBatch) AddParent(ns Ns, obj Obj, parentNs Ns, parentObj Obj) *WriteBatch {
+	return b.AddUserSet(ns, obj, RelParent, UserSet{Ns: parentNs, Obj: parentObj, Rel: RelUnspecified})
+}
+
+// Delete removes the stored tuple t. Expires is not sent: a delete matches the
+// stored tuple without it.
+func (b *WriteBatch) Delete(t Tuple) *WriteBatch {
+	return b.push(opDelete, t)
+}
+
+// Touch writes t whether or not it is already stored: any stored version is
+// deleted and t is added in the same write, which check applies deletes
+// first. Use it to change the expiry of an existing grant.
+func (b *WriteBatch) Touch(t Tuple) *WriteBatch {
+	return b.push(opTouch, t)
+}
+
+// AllowNonAtomicSplit lets Commit split the batch into several writes of at
+// most chunkSize tuples (DefaultWriteChunkSize when <= 0). The batch is then
+// NOT atomic: a failure leaves earlier chunks committed (see
+// PartialWriteError), and the precondition only guards the first chunk.
+func (b *WriteBatch) AllowNonAtomicSplit(chunkSize int) *WriteBatch {
+	if chunkSize <= 0 {
+		chunkSize = DefaultWriteChunkSize
+	}
+	b.chunkSize = chunkSize
+	return b
+}
+
+// Len returns the number of wire tuples the batch writes (a touch counts twice).
+func (b *WriteBatch) Len() int {
+	n := 0
+	for _, e := range b.entries {
+		n += e.size()
+	}
+	return n
+}
+
+// Validate reports every malformed, duplicate and contradictory tuple in the
+// batch, joined into one error; nil means the batch is well-formed. A Touch
+// counts as one add and one delete of its tuple.
+func (b *WriteBatch) Validate() error {
+	var errs []error
+	type counts struct{ add, del int }
+	seen := make(map[tupleKey]*counts, len(b.entries))
+	for i, e := range b.entries {
+		if err := validateTuple(e.tuple); err != nil {
+			errs = append(errs, fmt.Errorf("%s[%d]: %w", e.op, i, err))
+			continue
+		}
+		k := keyOf(e.tuple)
+		n := seen[k]
+		if n == nil {
+			n = &counts{}
+			seen[k] = n
+		}
+		if e.op != opDelete {
+			n.add++
+		}
+		if e.op != opAdd {
+			n.del++
+		}
+		switch {
+		case n.add > 1 || n.del > 1:
+			errs = append(errs, fmt.Errorf("%s[%d] %s: %w", e.op, i, e.tuple, ErrDuplicateTuple))
+		case n.add == 1 && n.del == 1 && e.op != opTouch:
+			errs = append(errs, fmt.Errorf("%s[%d] %s: %w", e.op, i, e.tuple, ErrContradictoryTuple))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateTuple enforces the Tuple contract strictly: all fields set and
+// exactly one of UserId or UserSet.
+func validateTuple(t Tuple) error {
+	switch {
+	case t.Ns == "" || t.Obj == "" || t.Rel == "":
+		return fmt.Errorf("%w: ns, obj and rel are required", ErrInvalidTuple)
+	case t.UserSet != nil && t.UserId != "":
+		return fmt.Errorf("%w: both UserId and UserSet set", ErrInvalidTuple)
+	case t.UserSet == nil && t.UserId == "":
+		return fmt.Errorf("%w: UserId or UserSet required", ErrInvalidTuple)
+	case t.UserSet != nil && (t.UserSet.Ns == "" || t.UserSet.Obj == "" || t.UserSet.Rel == ""):
+		return fmt.Errorf("%w: userset ns, obj and rel are required", ErrInvalidTuple)
+	}
+	return nil
+}
+
+// Commit validates the batch and writes it with the optional precondition
+// zookie. Returns the commit zookie (of the last chunk when split). An empty
+// batch commits nothing and returns TimestampEmpty.
+func (b *WriteBatch) Commit(ctx context.Context, precondition *Timestamp) (Timestamp, error) {
+	if err := b.Validate(); err != nil {
+		return "", fmt.Errorf("write batch: %w", err)
+	}
+	if len(b.entries) == 0 {
+		return TimestampEmpty, nil
+	}
+	chunks := [][]batchEntry{b.entries}
+	if b.chunkSize > 0 {
+		chunks = splitEntries(b.entries, b.chunkSize)
+	}
+
+	var ts Timestamp
+	for i, chunk := range chunks {
+		add, del := chunkTuples(chunk)
+		pre := precondition
+		if i > 0 {
+			pre = nil
+		}
+		next, err := b.c.Write(ctx, add, del, pre)
+		if err != nil {
+			if i == 0 {
+				return "", err
+			}
+			return ts, &PartialWriteError{Committed: i, Chunks: len(chunks), Ts: ts, Err: err}
+		}
+		ts = next
+	}
+	return ts, nil
+}
+
+// splitEntries packs entries into chunks of at most size wire tuples. All
+// entries of one tuple key stay in one chunk, like the two halves of a touch:
+// split apart, a write applying its deletes first could undo an earlier add.
+func splitEntries(entries []batchEntry, size int) [][]batchEntry {
+	var groups [][]batchEntry
+	index := make(map[tupleKey]int, len(entries))
+	for _, e := range entries {
+		k := keyOf(e.tuple)
+		if i, ok := index[k]; ok {
+			groups[i] = append(groups[i], e)
+			continue
+		}
+		index[k] = len(groups)
+		groups = append(groups, []batchEntry{e})
+	}
+
+	var chunks [][]batchEntry
+	var cur []batchEntry
+	n := 0
+	for _, g := range groups {
+		gn := 0
+		for _, e := range g {
+			gn += e.size()
+		}
+		if n > 0 && n+gn > size {
+			chunks = append(chunks, cur)
+			cur, n = nil, 0
+		}
+		cur = append(cur, g...)
+		n += gn
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+// tuples returns the add and delete lists of the whole batch, as one Write
+// sends them.
+func (b *WriteBatch) tuples() (add, del []Tuple) {
+	return chunkTuples(b.entries)
+}
+
+func chunkTuples(chunk []batchEntry) (add, del []Tuple) {
+	for _, e := range chunk {
+		switch e.op {
+		case opAdd:
+			add = append(add, e.tuple)
+		case opDelete:
+			del = append(del, deletion(e.tuple))
+		case opTouch:
+			del = append(del, deletion(e.tuple))
+			add = append(add, e.tuple)
+		}
+	}
+	return add, del
+}
+
+// deletion is t as a delete sends it: without Expires, which is not part of
+// the stored tuple's key.
+func deletion(t Tuple) Tuple {
+	t.Expires = nil
+	return t
+}
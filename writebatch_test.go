@@ -0,0 +1,232 @@
+package nioclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWriteBatchValidate(t *testing.T) {
+	c := &checkAPI{}
+	us := UserSet{Ns: "grp", Obj: "eng", Rel: "member"}
+
+	ok := c.NewWriteBatch().
+		Add("doc", "1", "viewer", "u1").
+		AddUserSet("doc", "1", "viewer", us).
+		AddParent("doc", "1", "folder", "f").
+		Delete(Tuple{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u2"})
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("valid batch: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		b    *WriteBatch
+		want error
+	}{
+		{"no subject", c.NewWriteBatch().Delete(Tuple{Ns: "doc", Obj: "1", Rel: "viewer"}), ErrInvalidTuple},
+		{"two subjects", c.NewWriteBatch().Delete(Tuple{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u", UserSet: &us}), ErrInvalidTuple},
+		{"missing rel", c.NewWriteBatch().Add("doc", "1", "", "u1"), ErrInvalidTuple},
+		{"duplicate", c.NewWriteBatch().Add("doc", "1", "viewer", "u1").AddWithExpiry("doc", "1", "viewer", "u1", time.Now()), ErrDuplicateTuple},
+		{"deleted twice", c.NewWriteBatch().Delete(Tuple{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u1"}).Touch(Tuple{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u1"}), ErrDuplicateTuple},
+		{"touch and add", c.NewWriteBatch().Touch(Tuple{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u1"}).Add("doc", "1", "viewer", "u1"), ErrDuplicateTuple},
+		{"add and delete", c.NewWriteBatch().Add("doc", "1", "viewer", "u1").Delete(Tuple{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u1"}), ErrContradictoryTuple},
+		{"delete and add", c.NewWriteBatch().Delete(Tuple{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u1"}).Add("doc", "1", "viewer", "u1"), ErrContradictoryTuple},
+	}
+	for _, tc := range cases {
+		if err := tc.b.Validate(); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Validate = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestWriteBatchCommitIsOneWrite(t *testing.T) {
+	var got *proto.WriteRequest
+	fake := &fakeCheckService{write: func(in *proto.WriteRequest) (*proto.WriteResponse, error) {
+		got = in
+		return &proto.WriteResponse{Ts: "commit"}, nil
+	}}
+	c := &checkAPI{grpcClient: fake}
+	exp := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	pre := Timestamp("pre")
+
+	ts, err := c.NewWriteBatch().
+		Add("doc", "1", "viewer", "u1").
+		Touch(Tuple{Ns: "doc", Obj: "1", Rel: "editor", UserId: "u2", Expires: &exp}).
+		Delete(Tuple{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u3"}).
+		Commit(context.Background(), &pre)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts != "commit" || fake.count("write") != 1 {
+		t.Fatalf("ts = %q, writes = %d", ts, fake.count("write"))
+	}
+	if got.GetTs() != "pre" || len(got.AddTuples) != 2 || len(got.DelTuples) != 2 {
+		t.Fatalf("request: ts=%q add=%d del=%d", got.GetTs(), len(got.AddTuples), len(got.DelTuples))
+	}
+	if got.DelTuples[0].Condition != nil {
+		t.Fatal("touch must delete the stored tuple without a condition")
+	}
+}
+
+func TestWriteBatchCommitRejectsInvalidWithoutRPC(t *testing.T) {
+	fake := &fakeCheckService{}
+	c := &checkAPI{grpcClient: fake}
+	_, err := c.NewWriteBatch().Add("doc", "1", "viewer", "").Commit(context.Background(), nil)
+	if !errors.Is(err, ErrInvalidTuple) {
+		t.Fatalf("err = %v, want ErrInvalidTuple", err)
+	}
+	if fake.count("write") != 0 {
+		t.Fatal("invalid batch must not reach the server")
+	}
+}
+
+func TestWriteBatchNonAtomicSplit(t *testing.T) {
+	var preconditions []string
+	fake := &fakeCheckService{write: func(in *proto.WriteRequest) (*proto.WriteResponse, error) {
+		preconditions = append(preconditions, in.GetTs())
+		if len(preconditions) == 3 {
+			return nil, status.Error(codes.Unavailable, "down")
+		}
+		if n := len(in.AddTuples) + len(in.DelTuples); n > 3 {
+			return nil, fmt.Errorf("chunk of %d tuples exceeds 3", n)
+		}
+		return &proto.WriteResponse{Ts: fmt.Sprintf("c%d", len(preconditions))}, nil
+	}}
+	c := &checkAPI{grpcClient: fake}
+	b := c.NewWriteBatch().AllowNonAtomicSplit(3)
+	for i := 0; i < 7; i++ {
+		b.Add("doc", Obj(fmt.Sprint(i)), "viewer", "u1")
+	}
+	pre := Timestamp("pre")
+
+	_, err := b.Commit(context.Background(), &pre)
+	var partial *PartialWriteError
+	if !errors.As(err, &partial) {
+		t.Fatalf("err = %v, want *PartialWriteError", err)
+	}
+	if partial.Committed != 2 || partial.Chunks != 3 || partial.Ts != "c2" {
+		t.Fatalf("partial = %+v", partial)
+	}
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("partial error must wrap the cause: %v", err)
+	}
+	if preconditions[0] != "pre" || preconditions[1] != "" {
+		t.Fatalf("preconditions = %q, want only the first chunk guarded", preconditions)
+	}
+}
+
+func TestWriteBatchSplitKeepsTupleTogether(t *testing.T) {
+	exp := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	// splitEntries does not validate: the add and delete of doc:1 must still
+	// travel together.
+	b := (&checkAPI{}).NewWriteBatch().
+		Add("doc", "1", "viewer", "u1").
+		Add("doc", "2", "viewer", "u1").
+		Delete(Tuple{Ns: "doc", Obj: "1", Rel: "viewer", UserId: "u1"}).
+		Touch(Tuple{Ns: "doc", Obj: "1", Rel: "editor", UserId: "u1", Expires: &exp}).
+		Add("doc", "3", "viewer", "u1")
+	chunks := splitEntries(b.entries, 2)
+	if len(chunks) != 4 {
+		t.Fatalf("chunks = %d, want 4", len(chunks))
+	}
+	where := map[tupleKey]int{}
+	for i, chunk := range chunks {
+		n := 0
+		for _, e := range chunk {
+			n += e.size()
+			if j, ok := where[keyOf(e.tuple)]; ok && j != i {
+				t.Fatalf("%s split across chunks %d and %d", e.tuple, j, i)
+			}
+			where[keyOf(e.tuple)] = i
+		}
+		if n > 2 {
+			t.Fatalf("chunk %d has %d tuples", i, n)
+		}
+	}
+}
+
+// contractStore is a fakeCheckService store that applies a write the way check
+// does: its deletes first, then its adds, at one commit.
+func contractStore() *fakeCheckService {
+	stored := map[string]*proto.Tuple{}
+	key := func(t *proto.Tuple) string {
+		return fmt.Sprintf("%s:%s#%s@%s%s", t.Ns, t.Obj, t.Rel, t.GetUserId(), t.GetUserSet())
+	}
+	return &fakeCheckService{
+		write: func(in *proto.WriteRequest) (*proto.WriteResponse, error) {
+			for _, t := range in.DelTuples {
+				delete(stored, key(t))
+			}
+			for _, t := range in.AddTuples {
+				if _, ok := stored[key(t)]; ok {
+					return nil, status.Error(codes.AlreadyExists, "tuple exists")
+				}
+				stored[key(t)] = t
+			}
+			return &proto.WriteResponse{Ts: "commit"}, nil
+		},
+		read: func(*proto.ReadRequest) (*proto.ReadResponse, error) {
+			res := &proto.ReadResponse{Ts: "commit"}
+			for _, t := range stored {
+				res.Tuples = append(res.Tuples, t)
+			}
+			return res, nil
+		},
+	}
+}
+
+// touchContract touches an expiring grant into a permanent one and checks
+// that exactly the permanent tuple is stored afterwards.
+func touchContract(t *testing.T, c *checkAPI, obj Obj) {
+	t.Helper()
+	ctx := context.Background()
+	exp := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	grant := Tuple{Ns: "project", Obj: obj, Rel: "viewer", UserId: "contract-user"}
+	expiring := grant
+	expiring.Expires = &exp
+
+	if _, err := c.NewWriteBatch().Touch(expiring).Commit(ctx, nil); err != nil {
+		t.Fatalf("touch expiring: %v", err)
+	}
+	if _, err := c.NewWriteBatch().Touch(grant).Commit(ctx, nil); err != nil {
+		t.Fatalf("touch permanent: %v", err)
+	}
+	rel := Rel("viewer")
+	res, err := c.Read(ctx, FilterByObject("project", obj, &rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Tuples) != 1 || res.Tuples[0].Expires != nil {
+		t.Fatalf("stored = %v, want one permanent tuple", res.Tuples)
+	}
+	if _, err := c.NewWriteBatch().Delete(grant).Commit(ctx, nil); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+}
+
+func TestWriteTouchContract(t *testing.T) {
+	touchContract(t, &checkAPI{grpcClient: contractStore()}, "p1")
+}
+
+// TestWriteTouchServerContract runs the touch contract against the check
+// server at NIO_CHECK_ADDR (e.g. the docker-compose setup on localhost:50052).
+func TestWriteTouchServerContract(t *testing.T) {
+	addr := os.Getenv("NIO_CHECK_ADDR")
+	if addr == "" {
+		t.Skip("NIO_CHECK_ADDR not set")
+	}
+	conn, err := DialCheckInsecure(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	touchContract(t, New(conn).checkAPI, Obj(fmt.Sprintf("touch-contract-%d", time.Now().UnixNano())))
+}
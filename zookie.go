@@ -0,0 +1,108 @@
+package nioclient
+
+// Zookie inspection and ordering. A Timestamp stays opaque on the wire —
+// callers only ever echo what check returned — but the packed layout
+// ([epoch:u8][millis:u48 BE], standard Base64) is stable, so the client can
+// decode it to log it, and order two zookies to keep the freshest one.
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+const packedTimestampLen = 7
+
+// DecodeTimestamp unpacks a zookie into its epoch and the wall-clock instant
+// (millisecond precision, UTC) of the snapshot. Malformed values return an
+// error matching ErrInvalidZookie.
+func DecodeTimestamp(ts Timestamp) (epoch uint8, at time.Time, err error) {
+	raw, err := base64.StdEncoding.DecodeString(string(ts))
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("%w: %q: %v", ErrInvalidZookie, ts, err)
+	}
+	if len(raw) != packedTimestampLen {
+		return 0, time.Time{}, fmt.Errorf("%w: %q: %d bytes, want %d", ErrInvalidZookie, ts, len(raw), packedTimestampLen)
+	}
+	var millis int64
+	for _, b := range raw[1:] {
+		millis = millis<<8 | int64(b)
+	}
+	return raw[0], time.UnixMilli(millis).UTC(), nil
+}
+
+// Validate reports whether s is a well-formed packed zookie.
+func (s Timestamp) Validate() error {
+	_, _, err := DecodeTimestamp(s)
+	return err
+}
+
+// compare orders zookies by (epoch, millis). A malformed zookie is older than
+// every valid one; two malformed zookies compare equal.
+func (s Timestamp) compare(o Timestamp) int {
+	se, st, serr := DecodeTimestamp(s)
+	oe, ot, oerr := DecodeTimestamp(o)
+	switch {
+	case serr != nil && oerr != nil:
+		return 0
+	case serr != nil:
+		return -1
+	case oerr != nil:
+		return 1
+	case se != oe:
+		if se < oe {
+			return -1
+		}
+		return 1
+	default:
+		return st.Compare(ot)
+	}
+}
+
+// Before reports whether s is an older snapshot than o. Malformed zookies
+// sort before every valid one.
+func (s Timestamp) Before(o Timestamp) bool {
+	return s.compare(o) < 0
+}
+
+// After reports whether s is a fresher snapshot than o. Malformed zookies
+// sort before every valid one.
+func (s Timestamp) After(o Timestamp) bool {
+	return s.compare(o) > 0
+}
+
+// Max returns the fresher of s and o (s when they are equal).
+func (s Timestamp) Max(o Timestamp) Timestamp {
+	if o.After(s) {
+		return o
+	}
+	return s
+}
+
+// Latest returns the freshest valid zookie among ts, ignoring empty and
+// malformed values; TimestampEmpty when there is none. Use it to keep the
+// freshest commit zookie across several writes.
+func Latest(ts ...Timestamp) Timestamp {
+	latest := TimestampEmpty
+	for _, t := range ts {
+		if t.Validate() == nil && t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// snapshotTime is the wall-clock instant of the snapshot ts, or now when ts
+// does not decode.
+func snapshotTime(ts Timestamp) time.Time {
+	if _, at, err := DecodeTimestamp(ts); err == nil {
+		return at
+	}
+	return time.Now()
+}
+
+// lapsedAt reports whether the expiry of t has passed at the instant at, so
+// that check no longer honours the tuple.
+func lapsedAt(t Tuple, at time.Time) bool {
+	return t.Expires != nil && !t.Expires.After(at)
+}
@@ -0,0 +1,84 @@
+package nioclient
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+// packTimestamp builds a zookie the way check packs it. Test-only: the
+// client never invents zookies.
+func packTimestamp(epoch uint8, millis int64) Timestamp {
+	raw := []byte{epoch, byte(millis >> 40), byte(millis >> 32), byte(millis >> 24), byte(millis >> 16), byte(millis >> 8), byte(millis)}
+	return Timestamp(base64.StdEncoding.EncodeToString(raw))
+}
+
+func TestDecodeTimestampEmpty(t *testing.T) {
+	epoch, at, err := DecodeTimestamp(TimestampEmpty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epoch != 1 || !at.Equal(time.UnixMilli(0)) || at.Location() != time.UTC {
+		t.Fatalf("empty zookie = epoch %d at %v", epoch, at)
+	}
+	if packTimestamp(1, 0) != TimestampEmpty {
+		t.Fatalf("test packer disagrees with TimestampEmpty: %q", packTimestamp(1, 0))
+	}
+}
+
+func TestDecodeTimestampMillis(t *testing.T) {
+	want := time.Date(2026, 3, 1, 10, 30, 0, 123e6, time.UTC)
+	epoch, at, err := DecodeTimestamp(packTimestamp(2, want.UnixMilli()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epoch != 2 || !at.Equal(want) {
+		t.Fatalf("decoded epoch %d at %v, want 2 at %v", epoch, at, want)
+	}
+}
+
+func TestDecodeTimestampMalformed(t *testing.T) {
+	for _, ts := range []Timestamp{"", "not base64!", "AQAAAAAA", "AQAAAAAAAAA="} {
+		if _, _, err := DecodeTimestamp(ts); !errors.Is(err, ErrInvalidZookie) {
+			t.Errorf("DecodeTimestamp(%q) = %v, want ErrInvalidZookie", ts, err)
+		}
+		if ts.Validate() == nil {
+			t.Errorf("Validate(%q) = nil", ts)
+		}
+	}
+}
+
+func TestTimestampOrdering(t *testing.T) {
+	a := packTimestamp(1, 1000)
+	b := packTimestamp(1, 2000)
+	nextEpoch := packTimestamp(2, 10)
+
+	if !a.Before(b) || !b.After(a) || a.After(b) {
+		t.Fatal("millis ordering")
+	}
+	if !b.Before(nextEpoch) {
+		t.Fatal("a later epoch must be fresher regardless of millis")
+	}
+	if a.Max(b) != b || b.Max(a) != b || a.Max(a) != a {
+		t.Fatal("Max")
+	}
+	if !Timestamp("garbage").Before(a) || Timestamp("garbage").After(a) {
+		t.Fatal("malformed must sort before valid")
+	}
+}
+
+func TestLatest(t *testing.T) {
+	a := packTimestamp(1, 1000)
+	b := packTimestamp(1, 3000)
+	c := packTimestamp(1, 2000)
+	if got := Latest(a, "", "garbage", b, c); got != b {
+		t.Fatalf("Latest = %q, want %q", got, b)
+	}
+	if got := Latest(); got != TimestampEmpty {
+		t.Fatalf("Latest() = %q, want TimestampEmpty", got)
+	}
+	if got := Latest("", "garbage"); got != TimestampEmpty {
+		t.Fatalf("Latest(invalid) = %q, want TimestampEmpty", got)
+	}
+}
@@ -0,0 +1,268 @@
+package nioclient
+
+// Client-side zookie tracking for read-your-writes. Writes record their commit
+// zookie under the objects they touched and the principals they granted;
+// Check, List, Read and Expand then evaluate at a snapshot at least as fresh as
+// the freshest zookie known for their principal or object. Callers no longer
+// have to thread timestamps through every layer.
+//
+// A store is installed per client (WithZookieStore) or per request
+// (ContextWithZookieStore, which wins). The request-scoped CookieZookieStore
+// keeps the zookie in the check_ts cookie that Wrap already honours.
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ZookieKey names what a recorded zookie is fresh for: a principal or an
+// object. Build keys with PrincipalKey and ObjectKey.
+type ZookieKey string
+
+// PrincipalKey is the key for zookies of writes granting userId.
+func PrincipalKey(userId UserId) ZookieKey {
+	return ZookieKey("principal:" + string(userId))
+}
+
+// ObjectKey is the key for zookies of writes on ⟨ns, obj⟩.
+func ObjectKey(ns Ns, obj Obj) ZookieKey {
+	return ZookieKey("object:" + escape(string(ns), reservedTriple) + ":" + escape(string(obj), reservedTriple))
+}
+
+// ZookieStore records the freshest known zookie per key. Implementations must
+// be safe for concurrent use; an external store (Redis, a session row, …)
+// only needs these two methods.
+type ZookieStore interface {
+	// Get returns the zookie recorded for key; ok=false when there is none.
+	Get(ctx context.Context, key ZookieKey) (ts Timestamp, ok bool, err error)
+	// Put records ts for key, keeping the fresher of ts and any stored value.
+	Put(ctx context.Context, key ZookieKey, ts Timestamp) error
+}
+
+// WithZookieStore installs store for every call of the client. A store in the
+// call's context (ContextWithZookieStore) takes precedence.
+func WithZookieStore(store ZookieStore) ClientOption {
+	return func(o *clientOptions) {
+		o.zookies = store
+	}
+}
+
+type zookieStoreCtxKey struct{}
+
+// ContextWithZookieStore returns ctx carrying a request-scoped store, used
+// instead of the client's store by every call made with the returned context.
+func ContextWithZookieStore(ctx context.Context, store ZookieStore) context.Context {
+	return context.WithValue(ctx, zookieStoreCtxKey{}, store)
+}
+
+func (c *checkAPI) zookieStore(ctx context.Context) ZookieStore {
+	if store, ok := ctx.Value(zookieStoreCtxKey{}).(ZookieStore); ok {
+		return store
+	}
+	return c.zookies
+}
+
+// freshen returns ts or, if fresher, the freshest zookie recorded under keys.
+// Store failures only cost freshness, so they are logged and skipped.
+func (c *checkAPI) freshen(ctx context.Context, ts Timestamp, keys ...ZookieKey) Timestamp {
+	store := c.zookieStore(ctx)
+	if store == nil {
+		return ts
+	}
+	for _, key := range keys {
+		known, ok, err := store.Get(ctx, key)
+		if err != nil {
+			log.Printf("zookie store: get %s: %v", key, err)
+			continue
+		}
+		if ok && known.After(ts) {
+			ts = known
+		}
+	}
+	return ts
+}
+
+// recordWrite stores a commit zookie under every object written and every
+// principal granted or revoked directly.
+func (c *checkAPI) recordWrite(ctx context.Context, ts Timestamp, add, del []Tuple) {
+	store := c.zookieStore(ctx)
+	if store == nil {
+		return
+	}
+	seen := make(map[ZookieKey]struct{})
+	for _, tuples := range [][]Tuple{add, del} {
+		for _, t := range tuples {
+			keys := []ZookieKey{ObjectKey(t.Ns, t.Obj)}
+			if t.UserSet == nil && t.UserId != "" {
+				keys = append(keys, PrincipalKey(t.UserId))
+			}
+			for _, key := range keys {
+				if _, dup := seen[key]; dup {
+					continue
+				}
+				seen[key] = struct{}{}
+				if err := store.Put(ctx, key, ts); err != nil {
+					log.Printf("zookie store: put %s: %v", key, err)
+				}
+			}
+		}
+	}
+}
+
+// readFilterKeys returns the zookie keys a Read with filters depends on.
+func readFilterKeys(filters []ReadFilter) []ZookieKey {
+	keys := make([]ZookieKey, 0, len(filters))
+	for _, f := range filters {
+		if f.set == nil {
+			continue
+		}
+		if os := f.set.GetObjectSpec(); os != nil {
+			keys = append(keys, ObjectKey(Ns(f.set.GetNs()), Obj(os.GetObj())))
+		}
+		if us := f.set.GetUsersetSpec(); us != nil && us.GetUserId() != "" {
+			keys = append(keys, PrincipalKey(UserId(us.GetUserId())))
+		}
+	}
+	return keys
+}
+
+// checkItemKeys returns the zookie keys a CheckBatch depends on.
+func checkItemKeys(items []CheckItem) []ZookieKey {
+	keys := make([]ZookieKey, 0, 2*len(items))
+	seen := make(map[ZookieKey]struct{}, 2*len(items))
+	for _, it := range items {
+		for _, key := range []ZookieKey{PrincipalKey(it.UserId), ObjectKey(it.Ns, it.Obj)} {
+			if _, dup := seen[key]; !dup {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// MemoryZookieStore is an in-process ZookieStore. Entries are dropped ttl
+// after their last update: by then check's own snapshots have caught up and
+// the zookie no longer adds freshness.
+type MemoryZookieStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[ZookieKey]memoryZookie
+}
+
+type memoryZookie struct {
+	ts      Timestamp
+	expires time.Time
+}
+
+// NewMemoryZookieStore creates an in-process store whose entries live for ttl.
+func NewMemoryZookieStore(ttl time.Duration) *MemoryZookieStore {
+	return &MemoryZookieStore{
+		ttl:     ttl,
+		entries: make(map[ZookieKey]memoryZookie),
+	}
+}
+
+func (s *MemoryZookieStore) Get(_ context.Context, key ZookieKey) (Timestamp, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return "", false, nil
+	}
+	if time.Now().After(e.expires) {
+		delete(s.entries, key)
+		return "", false, nil
+	}
+	return e.ts, true, nil
+}
+
+func (s *MemoryZookieStore) Put(_ context.Context, key ZookieKey, ts Timestamp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		ts = e.ts.Max(ts)
+	}
+	s.entries[key] = memoryZookie{ts: ts, expires: now.Add(s.ttl)}
+	return nil
+}
+
+// Sweep drops expired entries. Call it periodically on long-lived stores with
+// many keys; Get already drops the entries it finds expired.
+func (s *MemoryZookieStore) Sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// CheckTimestampCookie is the cookie Wrap reads the request's check zookie from.
+const CheckTimestampCookie = "check_ts"
+
+// CookieZookieStore is a request-scoped ZookieStore backed by the check_ts
+// cookie. A browser session belongs to one principal, so all keys share the
+// one cookie. Put sets the cookie on the response, so writes must happen
+// before the handler starts writing the body.
+type CookieZookieStore struct {
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	ts     Timestamp
+	maxAge time.Duration
+}
+
+// NewCookieZookieStore reads the check_ts cookie of r and writes updates to w.
+// maxAge bounds the cookie lifetime; 0 makes it a session cookie.
+func NewCookieZookieStore(w http.ResponseWriter, r *http.Request, maxAge time.Duration) *CookieZookieStore {
+	s := &CookieZookieStore{w: w, maxAge: maxAge}
+	if c, err := r.Cookie(CheckTimestampCookie); err == nil {
+		s.ts = Timestamp(c.Value)
+	}
+	return s
+}
+
+func (s *CookieZookieStore) Get(_ context.Context, _ ZookieKey) (Timestamp, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ts, s.ts != "", nil
+}
+
+func (s *CookieZookieStore) Put(_ context.Context, _ ZookieKey, ts Timestamp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ts != "" && !ts.After(s.ts) {
+		return nil
+	}
+	s.ts = ts
+	cookie := &http.Cookie{
+		Name:     CheckTimestampCookie,
+		Value:    string(ts),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if s.maxAge > 0 {
+		cookie.MaxAge = int(s.maxAge.Seconds())
+	}
+	http.SetCookie(s.w, cookie)
+	return nil
+}
+
+// WithCookieZookies makes Wrap install a CookieZookieStore in the request
+// context: writes the handler makes through a client with that context set
+// the check_ts cookie, and later requests of the browser check at least that
+// fresh. maxAge bounds the cookie lifetime; 0 makes it a session cookie.
+func WithCookieZookies(maxAge time.Duration) WrapOption {
+	return func(c *wrapConfig) {
+		c.cookieZookies = true
+		c.cookieZookieMaxAge = maxAge
+	}
+}
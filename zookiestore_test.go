@@ -0,0 +1,118 @@
+package nioclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	proto "github.com/ecociel/nioclient-go/proto"
+)
+
+func TestWriteRecordsZookieForCheck(t *testing.T) {
+	commit := packTimestamp(1, 5000)
+	var checkTs []string
+	fake := &fakeCheckService{
+		write: func(*proto.WriteRequest) (*proto.WriteResponse, error) {
+			return &proto.WriteResponse{Ts: string(commit)}, nil
+		},
+		check: func(in *proto.CheckRequest) (*proto.CheckResponse, error) {
+			checkTs = append(checkTs, in.Ts)
+			return allowViewer(in)
+		},
+	}
+	c := &checkAPI{grpcClient: fake, zookies: NewMemoryZookieStore(time.Minute)}
+	ctx := context.Background()
+
+	if _, err := c.AddOneUserId(ctx, "doc", "d1", "viewer", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	// Same principal, other object; other principal, same object; unrelated.
+	c.Check(ctx, "doc", "d2", "viewer", "u1")
+	c.Check(ctx, "doc", "d1", "viewer", "u2")
+	c.Check(ctx, "doc", "d3", "viewer", "u3")
+
+	want := []string{string(commit), string(commit), string(TimestampEmpty)}
+	for i := range want {
+		if checkTs[i] != want[i] {
+			t.Errorf("check[%d] ts = %q, want %q", i, checkTs[i], want[i])
+		}
+	}
+}
+
+func TestFreshenKeepsFresherCallerZookie(t *testing.T) {
+	store := NewMemoryZookieStore(time.Minute)
+	ctx := context.Background()
+	old, fresh := packTimestamp(1, 1000), packTimestamp(1, 2000)
+	store.Put(ctx, PrincipalKey("u1"), old)
+	c := &checkAPI{zookies: store}
+
+	if got := c.freshen(ctx, fresh, PrincipalKey("u1")); got != fresh {
+		t.Errorf("freshen = %q, want caller's %q", got, fresh)
+	}
+	if got := c.freshen(ctx, TimestampEmpty, PrincipalKey("u1")); got != old {
+		t.Errorf("freshen = %q, want stored %q", got, old)
+	}
+}
+
+func TestMemoryZookieStoreKeepsFreshestAndExpires(t *testing.T) {
+	store := NewMemoryZookieStore(20 * time.Millisecond)
+	ctx := context.Background()
+	key := ObjectKey("doc", "d1")
+	store.Put(ctx, key, packTimestamp(1, 2000))
+	store.Put(ctx, key, packTimestamp(1, 1000))
+	if ts, ok, _ := store.Get(ctx, key); !ok || ts != packTimestamp(1, 2000) {
+		t.Fatalf("Get = %q,%v, want the fresher zookie", ts, ok)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok, _ := store.Get(ctx, key); ok {
+		t.Fatal("entry survived its ttl")
+	}
+}
+
+func TestContextZookieStoreOverridesClientStore(t *testing.T) {
+	commit := packTimestamp(1, 5000)
+	fake := &fakeCheckService{
+		write: func(*proto.WriteRequest) (*proto.WriteResponse, error) {
+			return &proto.WriteResponse{Ts: string(commit)}, nil
+		},
+	}
+	clientStore := NewMemoryZookieStore(time.Minute)
+	reqStore := NewMemoryZookieStore(time.Minute)
+	c := &checkAPI{grpcClient: fake, zookies: clientStore}
+	ctx := ContextWithZookieStore(context.Background(), reqStore)
+
+	if _, err := c.AddOneUserId(ctx, "doc", "d1", "viewer", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := clientStore.Get(ctx, PrincipalKey("u1")); ok {
+		t.Error("write recorded in the client store despite a context store")
+	}
+	if ts, ok, _ := reqStore.Get(ctx, PrincipalKey("u1")); !ok || ts != commit {
+		t.Errorf("context store = %q,%v, want %q", ts, ok, commit)
+	}
+}
+
+func TestCookieZookieStoreSetsCookieOnlyWhenFresher(t *testing.T) {
+	old, fresh := packTimestamp(1, 1000), packTimestamp(1, 2000)
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.AddCookie(&http.Cookie{Name: CheckTimestampCookie, Value: string(fresh)})
+	rr := httptest.NewRecorder()
+	store := NewCookieZookieStore(rr, req, 0)
+	ctx := context.Background()
+
+	if ts, ok, _ := store.Get(ctx, PrincipalKey("any")); !ok || ts != fresh {
+		t.Fatalf("Get = %q,%v, want request cookie %q", ts, ok, fresh)
+	}
+	store.Put(ctx, PrincipalKey("u1"), old)
+	if got := rr.Header().Values("Set-Cookie"); len(got) != 0 {
+		t.Fatalf("older zookie set cookie %v", got)
+	}
+	newer := packTimestamp(1, 3000)
+	store.Put(ctx, PrincipalKey("u1"), newer)
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CheckTimestampCookie || cookies[0].Value != string(newer) {
+		t.Fatalf("cookies = %v, want check_ts=%s", cookies, newer)
+	}
+}